@@ -0,0 +1,48 @@
+package access
+
+import (
+	"context"
+	"strings"
+)
+
+// Identity is the caller of an MCP session: who they are and the role their
+// requests are evaluated under.
+type Identity struct {
+	User string
+	Role string
+	// Attributes are session attributes (e.g. "tenant") that row filters
+	// reference as {{user.<name>}}.
+	Attributes map[string]any
+}
+
+// Lookup resolves a row filter template name: "user.id" is the user,
+// "user.role" the role, and any other "user.<name>" the named attribute.
+func (id *Identity) Lookup(name string) (any, bool) {
+	key, ok := strings.CutPrefix(name, "user.")
+	if !ok || id == nil {
+		return nil, false
+	}
+	if v, ok := id.Attributes[key]; ok {
+		return v, true
+	}
+	switch key {
+	case "id":
+		return id.User, id.User != ""
+	case "role":
+		return id.Role, id.Role != ""
+	}
+	return nil, false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the Identity stored in ctx, or nil if none.
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
@@ -27,3 +27,10 @@ func DefaultAdminRole() Role {
 		},
 	}
 }
+
+// WithDefaultRoles returns the built-in readonly and admin roles followed by
+// roles. Passed to NewEngine, a configured role with a built-in name replaces
+// the built-in one.
+func WithDefaultRoles(roles []Role) []Role {
+	return append([]Role{DefaultReadOnlyRole(), DefaultAdminRole()}, roles...)
+}
@@ -25,10 +25,11 @@ type Role struct {
 // TablePolicy defines access rules for a specific table or wildcard.
 type TablePolicy struct {
 	Name        string   `yaml:"name"`          // table name or "*" for wildcard
-	Verbs       []string `yaml:"verbs"`         // SELECT, INSERT, UPDATE, DELETE
+	Verbs       []string `yaml:"verbs"`         // SELECT, INSERT, UPDATE, DELETE; omit to inherit from "*"
 	DenyColumns []string `yaml:"deny_columns"`  // columns to hide entirely
 	MaskColumns []string `yaml:"mask_columns"`  // columns to mask (PII)
-	RowFilter   string   `yaml:"row_filter"`    // injected WHERE clause
+	RowFilter   string   `yaml:"row_filter"`    // filter AND-ed into every query; may use {{user.*}}
+	AllowRawSQL bool     `yaml:"allow_raw_sql"` // let raw SQL use the table, bypassing its row filter
 }
 
 // Engine evaluates access control policies.
@@ -47,6 +48,84 @@ func NewEngine(roles []Role) *Engine {
 	return e
 }
 
+// HasRole reports whether a role with the given name is defined.
+func (e *Engine) HasRole(roleName string) bool {
+	_, ok := e.roles[roleName]
+	return ok
+}
+
+// policy returns the effective policy of a role for a table: the
+// table-specific policy layered over the wildcard ("*") policy. Verbs come
+// from the table-specific policy unless it omits them (an explicit empty list
+// denies the table); denied and masked columns accumulate from both, and row
+// filters from both are AND-ed. A specific policy's allow_raw_sql applies to
+// the merged filter; the wildcard's only if the specific policy adds no
+// filter of its own. Table names match case-insensitively, and a policy for
+// a bare name also applies to the schema-qualified table.
+func (r *Role) policy(table string) (TablePolicy, bool) {
+	var wildcard, specific *TablePolicy
+	for i := range r.Tables {
+		p := &r.Tables[i]
+		switch {
+		case p.Name == "*":
+			if wildcard == nil {
+				wildcard = p
+			}
+		case matchTable(p.Name, table):
+			if specific == nil {
+				specific = p
+			}
+		}
+	}
+
+	switch {
+	case specific == nil && wildcard == nil:
+		return TablePolicy{}, false
+	case specific == nil:
+		return *wildcard, true
+	case wildcard == nil:
+		return *specific, true
+	}
+
+	merged := *specific
+	if merged.Verbs == nil {
+		merged.Verbs = wildcard.Verbs
+	}
+	merged.DenyColumns = appendUnique(wildcard.DenyColumns, specific.DenyColumns)
+	merged.MaskColumns = appendUnique(wildcard.MaskColumns, specific.MaskColumns)
+	switch {
+	case merged.RowFilter == "":
+		merged.RowFilter = wildcard.RowFilter
+	case wildcard.RowFilter != "":
+		merged.RowFilter = "(" + wildcard.RowFilter + ") AND (" + specific.RowFilter + ")"
+	}
+	merged.AllowRawSQL = specific.AllowRawSQL || (wildcard.AllowRawSQL && specific.RowFilter == "")
+	return merged, true
+}
+
+// matchTable reports whether a policy name refers to table.
+func matchTable(name, table string) bool {
+	if strings.EqualFold(name, table) {
+		return true
+	}
+	if i := strings.LastIndex(table, "."); i >= 0 && !strings.Contains(name, ".") {
+		return strings.EqualFold(name, table[i+1:])
+	}
+	return false
+}
+
+func appendUnique(a, b []string) []string {
+	out := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, s := range append(append([]string{}, a...), b...) {
+		if key := strings.ToLower(s); !seen[key] {
+			seen[key] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 // CheckAccess verifies if a role has the given verb on a table.
 func (e *Engine) CheckAccess(roleName, table string, verb Verb) error {
 	role, ok := e.roles[roleName]
@@ -54,18 +133,11 @@ func (e *Engine) CheckAccess(roleName, table string, verb Verb) error {
 		return fmt.Errorf("unknown role: %q", roleName)
 	}
 
-	verbStr := string(verb)
-
-	// Check table-specific policies first, then wildcard
-	for _, policy := range role.Tables {
-		if policy.Name == table || policy.Name == "*" {
-			for _, v := range policy.Verbs {
-				if strings.EqualFold(v, verbStr) {
-					return nil
-				}
-			}
-			if policy.Name == table {
-				return fmt.Errorf("role %q does not have %s access on table %q", roleName, verb, table)
+	// The table-specific policy takes precedence over the wildcard.
+	if policy, ok := role.policy(table); ok {
+		for _, v := range policy.Verbs {
+			if strings.EqualFold(v, string(verb)) {
+				return nil
 			}
 		}
 	}
@@ -73,49 +145,74 @@ func (e *Engine) CheckAccess(roleName, table string, verb Verb) error {
 	return fmt.Errorf("role %q does not have %s access on table %q", roleName, verb, table)
 }
 
-// GetDeniedColumns returns columns that should be hidden for a role on a table.
-func (e *Engine) GetDeniedColumns(roleName, table string) []string {
+// CanAccess reports whether a role has any verb on a table.
+func (e *Engine) CanAccess(roleName, table string) bool {
+	for _, verb := range []Verb{VerbSelect, VerbInsert, VerbUpdate, VerbDelete} {
+		if e.CheckAccess(roleName, table, verb) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether a role has INSERT, UPDATE, or DELETE on any table.
+func (e *Engine) CanWrite(roleName string) bool {
 	role, ok := e.roles[roleName]
 	if !ok {
-		return nil
+		return false
 	}
-
 	for _, policy := range role.Tables {
-		if policy.Name == table || policy.Name == "*" {
-			return policy.DenyColumns
+		for _, v := range policy.Verbs {
+			if !strings.EqualFold(v, string(VerbSelect)) {
+				return true
+			}
 		}
 	}
-	return nil
+	return false
 }
 
-// GetMaskedColumns returns columns that should be masked for a role on a table.
-func (e *Engine) GetMaskedColumns(roleName, table string) []string {
+// GetDeniedColumns returns columns that should be hidden for a role on a table.
+func (e *Engine) GetDeniedColumns(roleName, table string) []string {
 	role, ok := e.roles[roleName]
 	if !ok {
 		return nil
 	}
+	policy, _ := role.policy(table)
+	return policy.DenyColumns
+}
 
-	for _, policy := range role.Tables {
-		if policy.Name == table || policy.Name == "*" {
-			return policy.MaskColumns
-		}
+// GetMaskedColumns returns columns that should be masked for a role on a table.
+func (e *Engine) GetMaskedColumns(roleName, table string) []string {
+	role, ok := e.roles[roleName]
+	if !ok {
+		return nil
 	}
-	return nil
+	policy, _ := role.policy(table)
+	return policy.MaskColumns
 }
 
-// GetRowFilter returns the row-level security filter for a role on a table.
+// GetRowFilter returns the row-level security filter for a role on a table,
+// in filter syntax. It may contain {{user.*}} templates for session attributes.
 func (e *Engine) GetRowFilter(roleName, table string) string {
 	role, ok := e.roles[roleName]
 	if !ok {
 		return ""
 	}
+	policy, _ := role.policy(table)
+	return policy.RowFilter
+}
 
-	for _, policy := range role.Tables {
-		if policy.Name == table {
-			return policy.RowFilter
-		}
+// AllowsRawSQL reports whether raw SQL may reference a table under a role's
+// row filter. Raw statements cannot have a row filter applied, so tables
+// with one are closed to raw SQL unless the policy sets allow_raw_sql.
+// Tables without a row filter are always allowed; CheckAccess still applies.
+func (e *Engine) AllowsRawSQL(roleName, table string) bool {
+	role, ok := e.roles[roleName]
+	if !ok {
+		return false
 	}
-	return ""
+	policy, _ := role.policy(table)
+	return policy.RowFilter == "" || policy.AllowRawSQL
 }
 
 // GetMaxRows returns the max rows per query for a role.
@@ -0,0 +1,112 @@
+package access
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testEngine() *Engine {
+	return NewEngine(WithDefaultRoles([]Role{
+		{
+			Name:            "analyst",
+			MaxRowsPerQuery: 500,
+			Tables: []TablePolicy{
+				{Name: "*", Verbs: []string{"SELECT"}, DenyColumns: []string{"password_hash"}, RowFilter: "tenant_id = {{user.tenant}}"},
+				{Name: "users", DenyColumns: []string{"ssn"}, MaskColumns: []string{"email"}},
+				{Name: "secrets", Verbs: []string{}},
+				{Name: "orders", Verbs: []string{"select", "update"}, RowFilter: "status <> 'void'"},
+				{Name: "reports", AllowRawSQL: true},
+			},
+		},
+		{
+			Name:   "admin",
+			Tables: []TablePolicy{{Name: "*", Verbs: []string{"SELECT"}}},
+		},
+	}))
+}
+
+func TestEngine_CheckAccess(t *testing.T) {
+	e := testEngine()
+	tests := []struct {
+		name    string
+		role    string
+		table   string
+		verb    Verb
+		allowed bool
+	}{
+		{"wildcard select", "analyst", "products", VerbSelect, true},
+		{"wildcard denies insert", "analyst", "products", VerbInsert, false},
+		{"specific inherits wildcard verbs", "analyst", "users", VerbSelect, true},
+		{"empty verbs deny table", "analyst", "secrets", VerbSelect, false},
+		{"specific verbs case-insensitive", "analyst", "orders", VerbUpdate, true},
+		{"bare policy matches qualified table", "analyst", "public.secrets", VerbSelect, false},
+		{"builtin readonly", "readonly", "anything", VerbSelect, true},
+		{"configured role replaces builtin", "admin", "products", VerbDelete, false},
+		{"unknown role", "ghost", "products", VerbSelect, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.CheckAccess(tt.role, tt.table, tt.verb)
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckAccess(%q, %q, %s) = %v, want allowed=%v", tt.role, tt.table, tt.verb, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestEngine_Columns(t *testing.T) {
+	e := testEngine()
+	if got, want := e.GetDeniedColumns("analyst", "users"), []string{"password_hash", "ssn"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("denied columns = %v, want %v", got, want)
+	}
+	if got, want := e.GetDeniedColumns("analyst", "products"), []string{"password_hash"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("denied columns = %v, want %v", got, want)
+	}
+	if got, want := e.GetMaskedColumns("analyst", "users"), []string{"email"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("masked columns = %v, want %v", got, want)
+	}
+	if e.CanWrite("readonly") || !e.CanWrite("analyst") {
+		t.Error("CanWrite: readonly must not write, analyst may update orders")
+	}
+	if e.CanAccess("analyst", "secrets") {
+		t.Error("analyst must not see secrets")
+	}
+}
+
+func TestEngine_GetRowFilter(t *testing.T) {
+	e := testEngine()
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"products", "tenant_id = {{user.tenant}}"},
+		{"users", "tenant_id = {{user.tenant}}"},
+		{"public.orders", "(tenant_id = {{user.tenant}}) AND (status <> 'void')"},
+	}
+	for _, tt := range tests {
+		if got := e.GetRowFilter("analyst", tt.table); got != tt.want {
+			t.Errorf("GetRowFilter(%q) = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+	if got := e.GetRowFilter("readonly", "products"); got != "" {
+		t.Errorf("readonly has no row filter, got %q", got)
+	}
+}
+
+func TestEngine_AllowsRawSQL(t *testing.T) {
+	e := testEngine()
+	tests := []struct {
+		role, table string
+		want        bool
+	}{
+		{"analyst", "products", false}, // wildcard row filter
+		{"analyst", "reports", true},   // explicitly allowed
+		{"readonly", "products", true}, // no row filter
+		{"ghost", "products", false},
+	}
+	for _, tt := range tests {
+		if got := e.AllowsRawSQL(tt.role, tt.table); got != tt.want {
+			t.Errorf("AllowsRawSQL(%q, %q) = %v, want %v", tt.role, tt.table, got, tt.want)
+		}
+	}
+}
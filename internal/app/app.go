@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/conduitdb/conduit/internal/access"
 	"github.com/conduitdb/conduit/internal/connector"
 	"github.com/conduitdb/conduit/internal/query"
 	"github.com/conduitdb/conduit/internal/schema"
@@ -15,34 +16,74 @@ import (
 
 // Config holds all application-level configuration.
 type Config struct {
+	// Sources lists the databases to open. The first source is the default
+	// for callers that do not name one.
+	Sources []SourceConfig
+
+	// Cache settings, shared by all sources.
+	Cache schema.CacheConfig
+
+	// Access is the RBAC engine shared by all sources. Optional; without it
+	// no role restrictions apply.
+	Access *access.Engine
+
+	// Logger is the structured logger. If nil, slog.Default() is used.
+	Logger *slog.Logger
+}
+
+// SourceConfig holds the settings for one database source.
+type SourceConfig struct {
+	// Name identifies the source in tool arguments and tool names.
+	Name string
+
 	// DSN is the database connection string.
 	DSN string
 
+	// Driver overrides the driver detected from the DSN scheme. Optional.
+	Driver string
+
 	// Connection settings.
 	Connection connector.ConnectionConfig
 
-	// Cache settings.
-	Cache schema.CacheConfig
-
 	// Query limits.
 	QueryLimits query.Limits
 
 	// MaskPII enables PII detection and masking.
 	MaskPII bool
+}
 
-	// Logger is the structured logger. If nil, slog.Default() is used.
-	Logger *slog.Logger
+// Source is an opened database source with its own connector, schema cache,
+// and query engine.
+type Source struct {
+	cfg    SourceConfig
+	conn   connector.Connector
+	cache  *schema.Cache
+	engine *query.Engine
 }
 
+// Name returns the source name.
+func (s *Source) Name() string { return s.cfg.Name }
+
+// Config returns the configuration the source was opened with.
+func (s *Source) Config() SourceConfig { return s.cfg }
+
+// Connector returns the source's database connector.
+func (s *Source) Connector() connector.Connector { return s.conn }
+
+// Cache returns the source's schema cache.
+func (s *Source) Cache() *schema.Cache { return s.cache }
+
+// Engine returns the source's query engine.
+func (s *Source) Engine() *query.Engine { return s.engine }
+
 // App is the central orchestrator. It owns the lifecycle of all subsystems
 // and provides the top-level API consumed by the MCP server layer and CLI.
 type App struct {
-	cfg       Config
-	logger    *slog.Logger
-	conn      connector.Connector
-	cache     *schema.Cache
-	engine    *query.Engine
-	pii       *schema.PIIDetector
+	cfg     Config
+	logger  *slog.Logger
+	sources []*Source
+	byName  map[string]*Source
+	pii     *schema.PIIDetector
 }
 
 // New creates a new App from the given configuration. It does not open
@@ -54,102 +95,153 @@ func New(cfg Config) *App {
 	return &App{
 		cfg:    cfg,
 		logger: cfg.Logger,
+		byName: make(map[string]*Source),
 		pii:    schema.NewPIIDetector(),
 	}
 }
 
-// Start initializes the connector, populates the schema cache, and starts
-// background refresh. The provided context controls the background refresh
-// goroutine's lifecycle.
+// Start opens every configured source, populates their schema caches, and
+// starts background refresh. The provided context controls the background
+// refresh goroutines' lifecycle. If any source fails to open, the sources
+// opened so far are closed and the error is returned.
 func (a *App) Start(ctx context.Context) error {
-	a.logger.Info("starting conduit",
-		slog.String("dsn", connector.SanitizeDSN(a.cfg.DSN)))
+	if len(a.cfg.Sources) == 0 {
+		return fmt.Errorf("no database sources configured")
+	}
+	for _, cfg := range a.cfg.Sources {
+		if _, dup := a.byName[cfg.Name]; dup {
+			a.Stop()
+			return fmt.Errorf("duplicate source name %q", cfg.Name)
+		}
+		src, err := a.startSource(ctx, cfg)
+		if err != nil {
+			a.Stop()
+			if len(a.cfg.Sources) > 1 {
+				return fmt.Errorf("source %q: %w", cfg.Name, err)
+			}
+			return err
+		}
+		a.sources = append(a.sources, src)
+		a.byName[cfg.Name] = src
+	}
+	return nil
+}
 
-	// Resolve driver from DSN.
-	driver, err := connector.ParseDSN(a.cfg.DSN)
-	if err != nil {
-		return fmt.Errorf("failed to parse DSN: %w", err)
+// startSource opens a single source.
+func (a *App) startSource(ctx context.Context, cfg SourceConfig) (*Source, error) {
+	logger := a.logger.With(slog.String("source", cfg.Name))
+	logger.Info("starting conduit",
+		slog.String("dsn", connector.SanitizeDSN(cfg.DSN)))
+
+	// Resolve driver from config or DSN.
+	driver := cfg.Driver
+	if driver == "" {
+		var err error
+		driver, err = connector.ParseDSN(cfg.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DSN: %w", err)
+		}
 	}
-	a.logger.Info("detected database driver", slog.String("driver", driver))
+	logger.Info("detected database driver", slog.String("driver", driver))
 
 	// Create and open the connector.
 	conn, err := connector.New(driver)
 	if err != nil {
-		return fmt.Errorf("failed to create connector: %w", err)
+		return nil, fmt.Errorf("failed to create connector: %w", err)
 	}
 
-	if err := conn.Open(ctx, a.cfg.Connection); err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
+	if err := conn.Open(ctx, cfg.Connection); err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
 	if err := conn.Ping(ctx); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	a.conn = conn
-	a.logger.Info("database connection established")
+	conn = connector.WithTableFilter(conn, cfg.Connection)
+	logger.Info("database connection established")
+
+	src := &Source{cfg: cfg, conn: conn}
 
 	// Build the schema cache backed by the connector.
-	a.cache = schema.NewCache(connectorSchemaAdapter{conn}, a.cfg.Cache, a.logger)
-	a.cache.Start(ctx)
+	src.cache = schema.NewCache(connectorSchemaAdapter{conn}, a.cfg.Cache, logger)
+	src.cache.Start(ctx)
 
 	// Perform initial schema load.
-	if err := a.cache.Refresh(ctx); err != nil {
-		a.logger.Warn("initial schema refresh failed (will retry in background)",
+	if err := src.cache.Refresh(ctx); err != nil {
+		logger.Warn("initial schema refresh failed (will retry in background)",
 			slog.String("error", err.Error()))
 	} else {
-		stats := a.cache.Stats()
-		a.logger.Info("schema loaded",
+		stats := src.cache.Stats()
+		logger.Info("schema loaded",
 			slog.Int("tables", stats.TableCount),
 			slog.Int("details", stats.DetailCount))
 	}
 
 	// Build the query engine.
-	a.engine = query.NewEngine(conn, a.cache, query.EngineConfig{
-		Limits:  a.cfg.QueryLimits,
-		MaskPII: a.cfg.MaskPII,
-	}, a.logger)
+	src.engine = query.NewEngine(conn, src.cache, query.EngineConfig{
+		Limits:  cfg.QueryLimits,
+		MaskPII: cfg.MaskPII,
+		Access:  a.cfg.Access,
+	}, logger)
 
-	a.logger.Info("conduit ready",
+	logger.Info("conduit ready",
 		slog.String("driver", driver),
-		slog.Bool("mask_pii", a.cfg.MaskPII),
-		slog.Bool("allow_writes", a.cfg.QueryLimits.AllowWrites),
-		slog.Int("max_rows", a.cfg.QueryLimits.MaxRows))
+		slog.Bool("mask_pii", cfg.MaskPII),
+		slog.Bool("allow_writes", cfg.QueryLimits.AllowWrites),
+		slog.Int("max_rows", cfg.QueryLimits.MaxRows))
 
-	return nil
+	return src, nil
 }
 
 // Stop gracefully shuts down all subsystems.
 func (a *App) Stop() error {
 	a.logger.Info("stopping conduit")
 
-	if a.cache != nil {
-		a.cache.Stop()
-	}
-	if a.conn != nil {
-		if err := a.conn.Close(); err != nil {
+	var firstErr error
+	for _, src := range a.sources {
+		if src.cache != nil {
+			src.cache.Stop()
+		}
+		if err := src.conn.Close(); err != nil {
 			a.logger.Warn("error closing database connection",
+				slog.String("source", src.Name()),
 				slog.String("error", err.Error()))
-			return err
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
+	a.sources = nil
+	a.byName = make(map[string]*Source)
 
 	a.logger.Info("conduit stopped")
-	return nil
+	return firstErr
+}
+
+// Sources returns the opened sources in configuration order.
+func (a *App) Sources() []*Source {
+	return a.sources
+}
+
+// Source returns the named source, or nil if there is no such source.
+func (a *App) Source(name string) *Source {
+	return a.byName[name]
 }
 
-// Connector returns the underlying database connector.
+// Connector returns the default (first) source's database connector.
 func (a *App) Connector() connector.Connector {
-	return a.conn
+	return a.sources[0].conn
 }
 
-// Cache returns the schema cache.
+// Cache returns the default (first) source's schema cache.
 func (a *App) Cache() *schema.Cache {
-	return a.cache
+	return a.sources[0].cache
 }
 
-// Engine returns the query engine.
+// Engine returns the default (first) source's query engine.
 func (a *App) Engine() *query.Engine {
-	return a.engine
+	return a.sources[0].engine
 }
 
 // PIIDetector returns the PII detector.
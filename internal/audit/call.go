@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Call accumulates details about one tool call that only the layers below
+// the tool handler know, such as the number of rows the database returned.
+// The server starts a Call for every tool call and copies it into the
+// call's Event.
+type Call struct {
+	rows atomic.Int64
+
+	mu     sync.Mutex
+	tables []string
+}
+
+type callKey struct{}
+
+// WithCall returns a context carrying a new Call.
+func WithCall(ctx context.Context) (context.Context, *Call) {
+	c := &Call{}
+	return context.WithValue(ctx, callKey{}, c), c
+}
+
+// AddRows records n rows returned to the caller of the tool call in ctx, if
+// any.
+func AddRows(ctx context.Context, n int) {
+	if c, ok := ctx.Value(callKey{}).(*Call); ok {
+		c.rows.Add(int64(n))
+	}
+}
+
+// AddTables records tables referenced by the tool call in ctx, if any, such
+// as those named in raw SQL.
+func AddTables(ctx context.Context, tables ...string) {
+	if c, ok := ctx.Value(callKey{}).(*Call); ok {
+		c.mu.Lock()
+		c.tables = append(c.tables, tables...)
+		c.mu.Unlock()
+	}
+}
+
+// Rows returns the number of rows recorded so far.
+func (c *Call) Rows() int {
+	return int(c.rows.Load())
+}
+
+// Tables returns the tables recorded so far.
+func (c *Call) Tables() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.tables...)
+}
+
+const (
+	// redacted replaces the values of sensitive parameters.
+	redacted = "[REDACTED]"
+	// maxParamLen is the longest string parameter kept verbatim; longer
+	// values are truncated so large payloads do not bloat the log.
+	maxParamLen = 1024
+)
+
+// sensitiveParams are substrings of parameter names whose values are never
+// logged.
+var sensitiveParams = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "credential", "private_key"}
+
+// SanitizeParams decodes tool call arguments for an Event. Values of
+// sensitive-looking keys are redacted and long strings truncated, at any
+// depth. Arguments that are not valid JSON are logged as a truncated string.
+func SanitizeParams(args json.RawMessage) any {
+	if len(args) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(args, &v); err != nil {
+		return truncate(string(args))
+	}
+	return sanitize(v)
+}
+
+func sanitize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if isSensitive(k) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = sanitize(val)
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = sanitize(val)
+		}
+		return v
+	case string:
+		return truncate(v)
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveParams {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string) string {
+	if len(s) <= maxParamLen {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxParamLen], "") + "...(truncated)"
+}
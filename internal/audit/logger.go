@@ -1,10 +1,13 @@
 package audit
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,7 +33,8 @@ type Output string
 const (
 	OutputStdout  Output = "stdout"
 	OutputSQLite  Output = "sqlite"
-	OutputSyslog  Output = "syslog"
+	OutputFile    Output = "file"
+	OutputSyslog  Output = "syslog" // alias for OutputFile
 	OutputWebhook Output = "webhook"
 )
 
@@ -40,23 +44,97 @@ type Config struct {
 	Output        Output `yaml:"output"`
 	RetentionDays int    `yaml:"retention_days"`
 	WebhookURL    string `yaml:"webhook_url,omitempty"`
+
+	// Path is the SQLite database or JSONL file to write. Defaults to
+	// conduit-audit.db or conduit-audit.jsonl.
+	Path string `yaml:"path"`
+	// MaxSizeMB rotates the JSONL file when it exceeds this size
+	// (default 100); MaxFiles is the number of rotated files kept
+	// (default 5).
+	MaxSizeMB int `yaml:"max_size_mb"`
+	MaxFiles  int `yaml:"max_files"`
+	// WebhookHeaders are added to every webhook request, e.g. for auth.
+	WebhookHeaders map[string]string `yaml:"webhook_headers"`
+
+	// Console is where the stdout output writes; defaults to os.Stdout.
+	// Set it to os.Stderr when stdout carries the stdio MCP transport.
+	Console io.Writer `yaml:"-"`
 }
 
-// Logger records audit events.
+const (
+	// queueSize is the number of events buffered for the sink. Log drops
+	// events rather than block when the queue is full.
+	queueSize = 4096
+	// batchSize is the maximum number of events per sink write.
+	batchSize = 100
+	// flushInterval is how long events wait for a batch to fill.
+	flushInterval = time.Second
+)
+
+// Logger records audit events. Events are kept in memory for the dashboard
+// and written to the configured sink asynchronously, so logging never
+// blocks a tool call.
 type Logger struct {
 	config Config
 	events []Event
 	mu     sync.RWMutex
 	logger *slog.Logger
+
+	sink      Sink
+	queue     chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Int64
 }
 
-// NewLogger creates a new audit logger.
-func NewLogger(cfg Config, logger *slog.Logger) *Logger {
-	return &Logger{
+// NewLogger creates a new audit logger and opens its sink. Call Close to
+// flush buffered events on shutdown.
+func NewLogger(cfg Config, logger *slog.Logger) (*Logger, error) {
+	l := &Logger{
 		config: cfg,
 		events: make([]Event, 0, 1000),
 		logger: logger,
 	}
+	if !cfg.Enabled {
+		return l, nil
+	}
+	sink, err := openSink(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	l.sink = sink
+	l.queue = make(chan Event, queueSize)
+	l.done = make(chan struct{})
+	go l.run()
+	return l, nil
+}
+
+// openSink creates the sink for cfg.Output.
+func openSink(cfg Config, logger *slog.Logger) (Sink, error) {
+	switch cfg.Output {
+	case "", OutputStdout:
+		w := cfg.Console
+		if w == nil {
+			w = os.Stdout
+		}
+		return NewWriterSink(w), nil
+	case OutputSQLite:
+		path := cfg.Path
+		if path == "" {
+			path = "conduit-audit.db"
+		}
+		return OpenSQLiteSink(path, cfg.RetentionDays)
+	case OutputFile, OutputSyslog:
+		path := cfg.Path
+		if path == "" {
+			path = "conduit-audit.jsonl"
+		}
+		return OpenFileSink(path, int64(cfg.MaxSizeMB)<<20, cfg.MaxFiles)
+	case OutputWebhook:
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookHeaders, logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported audit output %q", cfg.Output)
+	}
 }
 
 // Log records an audit event.
@@ -65,7 +143,9 @@ func (l *Logger) Log(event Event) {
 		return
 	}
 
-	event.Timestamp = time.Now()
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
 
 	l.mu.Lock()
 	l.events = append(l.events, event)
@@ -75,14 +155,66 @@ func (l *Logger) Log(event Event) {
 	}
 	l.mu.Unlock()
 
-	switch l.config.Output {
-	case OutputStdout:
-		l.writeStdout(event)
-	case OutputSQLite:
-		// TODO: Write to SQLite
-		l.writeStdout(event)
+	if l.queue == nil {
+		return
+	}
+	select {
+	case l.queue <- event:
 	default:
-		l.writeStdout(event)
+		// The sink is not keeping up; dropping is preferable to stalling
+		// tool calls. Report the first drop and every 1000th after it.
+		if n := l.dropped.Add(1); n%1000 == 1 {
+			l.logger.Warn("audit queue full, dropping events", "dropped", n)
+		}
+	}
+}
+
+// Dropped returns the number of events not written because the queue was
+// full.
+func (l *Logger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Close flushes buffered events to the sink and closes it.
+func (l *Logger) Close() error {
+	if l.queue == nil {
+		return nil
+	}
+	l.closeOnce.Do(func() { close(l.queue) })
+	<-l.done
+	return l.sink.Close()
+}
+
+// run writes queued events to the sink in batches until the queue closes.
+func (l *Logger) run() {
+	defer close(l.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.sink.Write(context.Background(), batch); err != nil {
+			l.logger.Error("failed to write audit events", "events", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case event, ok := <-l.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
 	}
 }
 
@@ -146,13 +278,3 @@ func (l *Logger) AvgDurationMs(n int) float64 {
 
 	return float64(total) / float64(n)
 }
-
-func (l *Logger) writeStdout(event Event) {
-	data, err := json.Marshal(event)
-	if err != nil {
-		l.logger.Error("failed to marshal audit event", "error", err)
-		return
-	}
-	os.Stdout.Write(data)
-	os.Stdout.Write([]byte("\n"))
-}
@@ -0,0 +1,222 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestLogger_Console(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewLogger(Config{Enabled: true, Output: OutputStdout, Console: &buf}, testLogger)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	l.Log(Event{Tool: "query", Table: "orders", RowsReturned: 3})
+	l.Log(Event{Tool: "list_tables"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil || e.Tool != "query" || e.Timestamp.IsZero() {
+		t.Errorf("unexpected event %q: %v", lines[0], err)
+	}
+	if got := l.Recent(10); len(got) != 2 || got[0].Tool != "list_tables" {
+		t.Errorf("unexpected recent events: %+v", got)
+	}
+}
+
+// blockingSink blocks writes until release is closed.
+type blockingSink struct {
+	release chan struct{}
+	written atomic.Int64
+}
+
+func (s *blockingSink) Write(ctx context.Context, events []Event) error {
+	<-s.release
+	s.written.Add(int64(len(events)))
+	return nil
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func TestLogger_NeverBlocks(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	l := &Logger{config: Config{Enabled: true}, logger: testLogger, sink: sink,
+		queue: make(chan Event, queueSize), done: make(chan struct{})}
+	go l.run()
+
+	start := time.Now()
+	for i := 0; i < queueSize+batchSize+500; i++ {
+		l.Log(Event{Tool: "query"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Log blocked for %v", elapsed)
+	}
+	if l.Dropped() == 0 {
+		t.Error("expected events to be dropped while the sink is stalled")
+	}
+
+	close(sink.release)
+	l.Close()
+	if total := sink.written.Load() + l.Dropped(); total != int64(queueSize+batchSize+500) {
+		t.Errorf("written %d + dropped %d != logged", sink.written.Load(), l.Dropped())
+	}
+}
+
+func TestSQLiteSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	sink, err := OpenSQLiteSink(path, 30)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	events := []Event{
+		{Timestamp: time.Now().AddDate(0, 0, -45), Tool: "query", Table: "old"},
+		{Timestamp: time.Now(), SessionID: "s1", User: "alice", Role: "analyst", Tool: "query", Table: "orders",
+			Params: map[string]any{"filter": "id = 1"}, RowsReturned: 1, DurationMs: 5, SourceIP: "10.0.0.1"},
+	}
+	if err := sink.Write(context.Background(), events); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sink.Close()
+
+	// Reopening prunes events past the retention period.
+	sink, err = OpenSQLiteSink(path, 30)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer sink.Close()
+
+	var count int
+	var user, params string
+	row := sink.db.QueryRow("SELECT COUNT(*), MAX(user), MAX(params) FROM audit_events")
+	if err := row.Scan(&count, &user, &params); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if count != 1 || user != "alice" || params != `{"filter":"id = 1"}` {
+		t.Errorf("unexpected rows: count=%d user=%q params=%q", count, user, params)
+	}
+}
+
+func TestFileSink_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := OpenFileSink(path, 300, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(context.Background(), []Event{{Tool: "query", Table: "orders", Params: map[string]any{"i": i}}}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	sink.Close()
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("%s is %d bytes, over the limit", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files, found %s.3", path)
+	}
+}
+
+func TestWebhookSink_Retries(t *testing.T) {
+	var calls atomic.Int32
+	var got struct {
+		Events []Event `json:"events"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			calls.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	sink := NewWebhookSink(ts.URL, map[string]string{"Authorization": "Bearer secret"}, testLogger)
+	sink.backoff = time.Millisecond
+	if err := sink.Write(context.Background(), []Event{{Tool: "query"}, {Tool: "insert_orders"}}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if calls.Load() != 3 || len(got.Events) != 2 {
+		t.Errorf("calls=%d events=%d", calls.Load(), len(got.Events))
+	}
+
+	// Client errors other than 429 are not retried.
+	calls.Store(0)
+	bad := NewWebhookSink(ts.URL, nil, testLogger)
+	bad.backoff = time.Millisecond
+	if err := bad.Write(context.Background(), []Event{{Tool: "query"}}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("401 should not be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestSanitizeParams(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "empty", args: "", want: "null"},
+		{name: "plain", args: `{"table":"orders","limit":5}`, want: `{"limit":5,"table":"orders"}`},
+		{name: "redacted", args: `{"sql":"select 1","auth":{"Password":"hunter2"},"api_key":"k"}`, want: `{"api_key":"[REDACTED]","auth":{"Password":"[REDACTED]"},"sql":"select 1"}`},
+		{name: "truncated", args: `{"rows":[{"note":"` + strings.Repeat("x", 2000) + `"}]}`, want: `{"rows":[{"note":"` + strings.Repeat("x", 1024) + `...(truncated)"}]}`},
+		{name: "invalid", args: `{not json`, want: `"{not json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(SanitizeParams(json.RawMessage(tt.args)))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestCall(t *testing.T) {
+	AddRows(context.Background(), 5) // no call in context: ignored
+	ctx, call := WithCall(context.Background())
+	AddRows(ctx, 2)
+	AddRows(ctx, 3)
+	if call.Rows() != 5 {
+		t.Errorf("expected 5 rows, got %d", call.Rows())
+	}
+	AddTables(ctx, "orders", "customers")
+	if got := call.Tables(); len(got) != 2 || got[0] != "orders" {
+		t.Errorf("unexpected tables %v", got)
+	}
+}
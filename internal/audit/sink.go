@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Sink persists batches of audit events. The Logger calls Write from a
+// single goroutine.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// WriterSink writes events as JSON lines to an io.Writer.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a sink writing JSON lines to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write implements Sink.
+func (s *WriterSink) Write(ctx context.Context, events []Event) error {
+	data, err := encodeLines(events)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(data)
+	return err
+}
+
+// Close implements Sink. The writer is owned by the caller and left open.
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// encodeLines encodes events as newline-terminated JSON objects.
+func encodeLines(events []Event) ([]byte, error) {
+	var buf []byte
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+	return buf, nil
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// pruneInterval is how often the SQLite sink deletes expired events.
+const pruneInterval = time.Hour
+
+// timestampLayout stores timestamps in UTC with a fixed width, so they sort
+// and compare correctly as text.
+const timestampLayout = "2006-01-02T15:04:05.000000Z"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp TEXT NOT NULL,
+    session_id TEXT,
+    user TEXT,
+    role TEXT,
+    tool TEXT NOT NULL,
+    source TEXT,
+    table_name TEXT,
+    params TEXT,
+    rows_returned INTEGER,
+    duration_ms INTEGER,
+    source_ip TEXT,
+    error TEXT
+);
+CREATE INDEX IF NOT EXISTS audit_events_timestamp ON audit_events (timestamp);
+`
+
+// SQLiteSink stores events in a local SQLite database and deletes events
+// older than the retention period.
+type SQLiteSink struct {
+	db            *sql.DB
+	retentionDays int
+	lastPrune     time.Time
+}
+
+// OpenSQLiteSink opens (creating if needed) the audit database at path.
+// retentionDays of 0 keeps events forever.
+func OpenSQLiteSink(path string, retentionDays int) (*SQLiteSink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit database: %w", err)
+	}
+	// A single connection serializes writers and keeps the pragmas below.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;" + sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing audit database %s: %w", path, err)
+	}
+	s := &SQLiteSink{db: db, retentionDays: retentionDays}
+	if err := s.prune(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write implements Sink. Each batch is inserted in one transaction.
+func (s *SQLiteSink) Write(ctx context.Context, events []Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO audit_events
+        (timestamp, session_id, user, role, tool, source, table_name, params, rows_returned, duration_ms, source_ip, error)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		var params any
+		if e.Params != nil {
+			data, err := json.Marshal(e.Params)
+			if err != nil {
+				return err
+			}
+			params = string(data)
+		}
+		if _, err := stmt.ExecContext(ctx,
+			e.Timestamp.UTC().Format(timestampLayout), e.SessionID, e.User, e.Role, e.Tool, e.Source,
+			e.Table, params, e.RowsReturned, e.DurationMs, e.SourceIP, e.Error,
+		); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if time.Since(s.lastPrune) > pruneInterval {
+		return s.prune(ctx)
+	}
+	return nil
+}
+
+// prune deletes events older than the retention period.
+func (s *SQLiteSink) prune(ctx context.Context) error {
+	s.lastPrune = time.Now()
+	if s.retentionDays <= 0 {
+		return nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -s.retentionDays).UTC().Format(timestampLayout)
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM audit_events WHERE timestamp < ?", cutoff); err != nil {
+		return fmt.Errorf("pruning audit events: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink.
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookAttempts = 4
+	webhookTimeout  = 10 * time.Second
+)
+
+// WebhookSink POSTs batches of events as {"events": [...]} to a URL,
+// retrying transient failures with exponential backoff.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	backoff time.Duration // first retry delay, doubled per attempt
+	logger  *slog.Logger
+}
+
+// NewWebhookSink returns a sink posting to url with the given extra headers.
+func NewWebhookSink(url string, headers map[string]string, logger *slog.Logger) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: webhookTimeout},
+		backoff: time.Second,
+		logger:  logger,
+	}
+}
+
+// Write implements Sink. Server errors, 429s and network errors are
+// retried; other client errors are not.
+func (s *WebhookSink) Write(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return err
+	}
+
+	delay := s.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return fmt.Errorf("audit webhook: %w", err)
+		}
+		s.logger.Warn("audit webhook failed, retrying", "attempt", attempt, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying.
+func (s *WebhookSink) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("%s returned %s", s.url, resp.Status)
+}
+
+// Close implements Sink.
+func (s *WebhookSink) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package auth authenticates HTTP callers and resolves them to an identity
+// (user, role and session attributes) for access control.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+)
+
+const (
+	apiKeyPrefix    = "conduit_sk_live_"
+	apiKeyMinLength = 32
+
+	// hashPrefix marks the algorithm of a stored key hash.
+	hashPrefix = "sha256:"
+)
+
+// GenerateAPIKey creates a new API key with the conduit prefix.
+func GenerateAPIKey() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(bytes), nil
+}
+
+// ValidateAPIKeyFormat checks if an API key meets minimum requirements.
+func ValidateAPIKeyFormat(key string) error {
+	if len(key) < apiKeyMinLength {
+		return fmt.Errorf("API key must be at least %d characters (got %d)", apiKeyMinLength, len(key))
+	}
+	return nil
+}
+
+// HashAPIKey returns the stored form of an API key, "sha256:<hex>".
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// ValidateKeyHash checks that hash has the form produced by HashAPIKey.
+func ValidateKeyHash(hash string) error {
+	_, err := parseKeyHash(hash)
+	return err
+}
+
+func parseKeyHash(hash string) ([]byte, error) {
+	hexSum, ok := strings.CutPrefix(hash, hashPrefix)
+	if !ok {
+		return nil, fmt.Errorf("key hash must start with %q", hashPrefix)
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("key hash must be %s followed by %d hex characters", hashPrefix, 2*sha256.Size)
+	}
+	return sum, nil
+}
+
+// Key is a stored API key. Only the hash of the key is kept.
+type Key struct {
+	ID         string            `json:"id"`
+	Hash       string            `json:"hash"`
+	User       string            `json:"user"`
+	Role       string            `json:"role,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Identity returns the identity a caller presenting the key acts as. Keys
+// without a user name identify the caller by key ID.
+func (k *Key) Identity() *access.Identity {
+	id := &access.Identity{User: k.User, Role: k.Role}
+	if id.User == "" {
+		id.User = k.ID
+	}
+	if len(k.Attributes) > 0 {
+		id.Attributes = make(map[string]any, len(k.Attributes))
+		for name, v := range k.Attributes {
+			id.Attributes[name] = v
+		}
+	}
+	return id
+}
+
+// Keyring verifies presented API keys against a set of stored keys.
+type Keyring struct {
+	keys []Key
+	sums [][]byte
+}
+
+// NewKeyring builds a keyring from stored keys. It fails if any key hash is
+// malformed.
+func NewKeyring(keys []Key) (*Keyring, error) {
+	kr := &Keyring{keys: keys, sums: make([][]byte, len(keys))}
+	for i, k := range keys {
+		sum, err := parseKeyHash(k.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		kr.sums[i] = sum
+	}
+	return kr, nil
+}
+
+// Len returns the number of keys in the keyring.
+func (kr *Keyring) Len() int {
+	return len(kr.keys)
+}
+
+// Verify returns the stored key matching key. Every stored hash is compared
+// in constant time, so timing reveals neither which key matched nor how much
+// of it did.
+func (kr *Keyring) Verify(key string) (*Key, bool) {
+	sum := sha256.Sum256([]byte(key))
+	match := -1
+	for i, want := range kr.sums {
+		if subtle.ConstantTimeCompare(sum[:], want) == 1 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, false
+	}
+	return &kr.keys[match], true
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyring_Verify(t *testing.T) {
+	kr, err := NewKeyring([]Key{
+		{ID: "a", Hash: HashAPIKey("conduit_sk_live_aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), User: "alice", Role: "analyst"},
+		{ID: "b", Hash: HashAPIKey("conduit_sk_live_bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		want string // matched key ID, "" for no match
+	}{
+		{"first key", "conduit_sk_live_aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "a"},
+		{"second key", "conduit_sk_live_bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "b"},
+		{"unknown key", "conduit_sk_live_cccccccccccccccccccccccccccccccc", ""},
+		{"prefix of a key", "conduit_sk_live_aaaa", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if key, ok := kr.Verify(tt.key); ok {
+				got = key.ID
+			}
+			if got != tt.want {
+				t.Errorf("Verify matched %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	key, _ := kr.Verify("conduit_sk_live_bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	if id := key.Identity(); id.User != "b" || id.Role != "" {
+		t.Errorf("key without a user should identify by ID: %+v", id)
+	}
+}
+
+func TestNewKeyring_RejectsBadHash(t *testing.T) {
+	for _, hash := range []string{"", "md5:abc", "sha256:zz", "sha256:abcd"} {
+		if _, err := NewKeyring([]Key{{ID: "k", Hash: hash}}); err == nil {
+			t.Errorf("expected error for hash %q", hash)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := OpenStore(filepath.Join(t.TempDir(), "conduit", "keys.json"))
+	if keys, err := store.List(); err != nil || len(keys) != 0 {
+		t.Fatalf("missing store should be empty: %v, %v", keys, err)
+	}
+
+	plaintext, created, err := store.Create("alice", "analyst", map[string]string{"tenant": "42"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) || strings.Contains(created.Hash, plaintext) {
+		t.Errorf("unexpected key %q / hash %q", plaintext, created.Hash)
+	}
+	if _, _, err := store.Create("bob", "", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	keys, err := store.List()
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %v, %v", keys, err)
+	}
+	kr, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	key, ok := kr.Verify(plaintext)
+	if !ok || key.ID != created.ID {
+		t.Fatalf("created key does not verify")
+	}
+	if id := key.Identity(); id.User != "alice" || id.Role != "analyst" || id.Attributes["tenant"] != "42" {
+		t.Errorf("unexpected identity: %+v", id)
+	}
+
+	if err := store.Revoke(created.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := store.Revoke(created.ID); err == nil {
+		t.Error("revoking twice should fail")
+	}
+	keys, _ = store.List()
+	if len(keys) != 1 || keys[0].User != "bob" {
+		t.Errorf("unexpected keys after revoke: %+v", keys)
+	}
+}
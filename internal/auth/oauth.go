@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthConfig configures Conduit as an OAuth 2.1 resource server that
+// accepts JWT access tokens issued by an external authorization server.
+type OAuthConfig struct {
+	// Resource is the canonical URL of the MCP endpoint, advertised in the
+	// protected resource metadata.
+	Resource string `yaml:"resource"`
+	// Issuer is the required "iss" claim, and the advertised authorization
+	// server unless AuthorizationServers is set.
+	Issuer               string   `yaml:"issuer"`
+	AuthorizationServers []string `yaml:"authorization_servers"`
+	// Audience is the required "aud" claim. Defaults to Resource.
+	Audience string `yaml:"audience"`
+	// JWKSURL or JWKSFile supplies the token signing keys.
+	JWKSURL  string `yaml:"jwks_url"`
+	JWKSFile string `yaml:"jwks_file"`
+	// Scopes lists scopes every token must carry.
+	Scopes []string `yaml:"scopes"`
+
+	// UserClaim names the claim identifying the user. Defaults to "sub".
+	UserClaim string `yaml:"user_claim"`
+	// RoleMappings map claim values to roles; the first match wins. Tokens
+	// matching none get auth.default_role.
+	RoleMappings []RoleMapping `yaml:"role_mappings"`
+	// AttributeClaims map session attributes used by row filters to claims,
+	// e.g. {tenant: org_id}.
+	AttributeClaims map[string]string `yaml:"attribute_claims"`
+}
+
+// RoleMapping grants Role to tokens whose Claim equals Value or, for array
+// claims such as "groups", contains it. Claim may be a dotted path into
+// nested objects, e.g. "realm_access.roles".
+type RoleMapping struct {
+	Claim string `yaml:"claim"`
+	Value string `yaml:"value"`
+	Role  string `yaml:"role"`
+}
+
+// signingMethods are the accepted JWT algorithms. Symmetric and "none"
+// algorithms are never accepted.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// clockSkew is the leeway allowed when checking exp and nbf.
+const clockSkew = 30 * time.Second
+
+// JWTVerifier validates JWT access tokens against an OAuthConfig.
+type JWTVerifier struct {
+	cfg    OAuthConfig
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+// Token is a verified access token.
+type Token struct {
+	Identity *access.Identity
+	Scopes   []string
+	Expiry   time.Time
+}
+
+// NewJWTVerifier returns a verifier for cfg. A JWKS file is read
+// immediately; a JWKS URL is fetched with client (nil for a default client)
+// on first use.
+func NewJWTVerifier(cfg OAuthConfig, client *http.Client) (*JWTVerifier, error) {
+	var keys *keySet
+	switch {
+	case cfg.JWKSFile != "":
+		var err error
+		if keys, err = newFileKeySet(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	case cfg.JWKSURL != "":
+		keys = newURLKeySet(cfg.JWKSURL, client)
+	default:
+		return nil, errors.New("oauth: jwks_url or jwks_file is required")
+	}
+	audience := cfg.Audience
+	if audience == "" {
+		audience = cfg.Resource
+	}
+	return &JWTVerifier{
+		cfg:  cfg,
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(signingMethods),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(clockSkew),
+		),
+	}, nil
+}
+
+// Refresh fetches the signing keys from the JWKS URL, if one is configured.
+func (v *JWTVerifier) Refresh(ctx context.Context) error {
+	return v.keys.refresh(ctx)
+}
+
+// Verify validates a token's signature, issuer, audience, lifetime and
+// required scopes, and maps its claims to an identity.
+func (v *JWTVerifier) Verify(ctx context.Context, raw string) (*Token, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, err
+	}
+	tok := &Token{Scopes: tokenScopes(claims), Expiry: exp.Time}
+	for _, want := range v.cfg.Scopes {
+		if !contains(tok.Scopes, want) {
+			return nil, fmt.Errorf("token is missing scope %q", want)
+		}
+	}
+
+	userClaim := v.cfg.UserClaim
+	if userClaim == "" {
+		userClaim = "sub"
+	}
+	user, _ := claimValue(claims, userClaim).(string)
+	if user == "" {
+		return nil, fmt.Errorf("token has no %q claim", userClaim)
+	}
+	tok.Identity = &access.Identity{User: user, Role: v.role(claims)}
+	for attr, claim := range v.cfg.AttributeClaims {
+		if val := claimValue(claims, claim); val != nil {
+			if tok.Identity.Attributes == nil {
+				tok.Identity.Attributes = make(map[string]any)
+			}
+			tok.Identity.Attributes[attr] = val
+		}
+	}
+	return tok, nil
+}
+
+// role returns the role of the first mapping the claims match, or "".
+func (v *JWTVerifier) role(claims jwt.MapClaims) string {
+	for _, m := range v.cfg.RoleMappings {
+		switch val := claimValue(claims, m.Claim).(type) {
+		case string:
+			if val == m.Value {
+				return m.Role
+			}
+		case []any:
+			for _, item := range val {
+				if s, ok := item.(string); ok && s == m.Value {
+					return m.Role
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// claimValue resolves a dotted claim path such as "realm_access.roles".
+func claimValue(claims jwt.MapClaims, path string) any {
+	var cur any = map[string]any(claims)
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = obj[name]
+	}
+	return cur
+}
+
+// tokenScopes reads the space-delimited "scope" claim (RFC 8693), falling
+// back to the "scp" array some providers use.
+func tokenScopes(claims jwt.MapClaims) []string {
+	if s, ok := claims["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	var scopes []string
+	if arr, ok := claims["scp"].([]any); ok {
+		for _, item := range arr {
+			if s, ok := item.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MetadataURL returns the RFC 9728 protected resource metadata URL for a
+// resource: the well-known path on the resource's origin, followed by the
+// resource's path.
+func MetadataURL(resource string) (string, error) {
+	u, err := url.Parse(resource)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("resource %q must be an absolute URL", resource)
+	}
+	return u.Scheme + "://" + u.Host + "/.well-known/oauth-protected-resource" + strings.TrimSuffix(u.Path, "/"), nil
+}
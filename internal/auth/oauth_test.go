@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/"
+	testResource = "https://conduit.example.com/mcp"
+)
+
+// testKeys is a locally generated RSA and EC key pair with their JWKS.
+type testKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	jwks []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": b64(rsaKey.N), "e": "AQAB"},
+	}})
+	return &testKeys{rsa: rsaKey, ec: ecKey, jwks: jwks}
+}
+
+// sign returns a token with standard valid claims overridden by claims.
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	all := jwt.MapClaims{
+		"iss": testIssuer,
+		"aud": testResource,
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		if v == nil {
+			delete(all, k)
+			continue
+		}
+		all[k] = v
+	}
+	tok := jwt.NewWithClaims(method, all)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testOAuthConfig(jwksFile string) OAuthConfig {
+	return OAuthConfig{
+		Resource: testResource,
+		Issuer:   testIssuer,
+		JWKSFile: jwksFile,
+		Scopes:   []string{"mcp"},
+		RoleMappings: []RoleMapping{
+			{Claim: "realm_access.roles", Value: "db-admins", Role: "admin"},
+			{Claim: "groups", Value: "analysts", Role: "analyst"},
+		},
+		AttributeClaims: map[string]string{"tenant": "org_id"},
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	keys := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keys.jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewJWTVerifier(testOAuthConfig(path), nil)
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name    string
+		token   string
+		role    string
+		wantErr string
+	}{
+		{
+			name:  "rsa with group mapping",
+			token: sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp read", "groups": []string{"staff", "analysts"}, "org_id": "acme"}),
+			role:  "analyst",
+		},
+		{
+			name:  "ec with nested role claim",
+			token: sign(t, jwt.SigningMethodES256, "ec-1", keys.ec, jwt.MapClaims{"scp": []string{"mcp"}, "realm_access": map[string]any{"roles": []string{"db-admins"}}}),
+			role:  "admin",
+		},
+		{
+			name:  "no mapping",
+			token: sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp"}),
+		},
+		{
+			name:    "wrong audience",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp", "aud": "https://other.example.com"}),
+			wantErr: "audience",
+		},
+		{
+			name:    "wrong issuer",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp", "iss": "https://evil.example.com/"}),
+			wantErr: "issuer",
+		},
+		{
+			name:    "expired",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp", "exp": time.Now().Add(-time.Hour).Unix()}),
+			wantErr: "expired",
+		},
+		{
+			name:    "no expiry",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "mcp", "exp": nil}),
+			wantErr: "exp",
+		},
+		{
+			name:    "signed by another key",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, jwt.MapClaims{"scope": "mcp"}),
+			wantErr: "signature",
+		},
+		{
+			name:    "encryption key",
+			token:   sign(t, jwt.SigningMethodRS256, "enc-1", keys.rsa, jwt.MapClaims{"scope": "mcp"}),
+			wantErr: "no signing key",
+		},
+		{
+			name:    "symmetric algorithm",
+			token:   sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), jwt.MapClaims{"scope": "mcp"}),
+			wantErr: "signing method",
+		},
+		{
+			name:    "missing scope",
+			token:   sign(t, jwt.SigningMethodRS256, "rsa-1", keys.rsa, jwt.MapClaims{"scope": "read"}),
+			wantErr: `missing scope "mcp"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tok.Identity.User != "alice" || tok.Identity.Role != tt.role {
+				t.Errorf("unexpected identity: %+v", tok.Identity)
+			}
+			if tok.Expiry.Before(time.Now()) {
+				t.Errorf("unexpected expiry %v", tok.Expiry)
+			}
+		})
+	}
+
+	tok, err := v.Verify(context.Background(), tests[0].token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Identity.Attributes["tenant"] != "acme" {
+		t.Errorf("attribute claim not mapped: %+v", tok.Identity.Attributes)
+	}
+}
+
+func TestJWTVerifier_JWKSURL(t *testing.T) {
+	keys := newTestKeys(t)
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write(keys.jwks)
+	}))
+	defer ts.Close()
+
+	cfg := testOAuthConfig("")
+	cfg.JWKSURL = ts.URL
+	v, err := NewJWTVerifier(cfg, ts.Client())
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		token := sign(t, jwt.SigningMethodES256, "ec-1", keys.ec, jwt.MapClaims{"scope": "mcp"})
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	}
+	// Unknown key IDs must not trigger a fetch per request.
+	token := sign(t, jwt.SigningMethodES256, "rotated", keys.ec, jwt.MapClaims{"scope": "mcp"})
+	if _, err := v.Verify(context.Background(), token); err == nil {
+		t.Error("expected error for unknown key ID")
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 JWKS fetch, got %d", fetches)
+	}
+}
+
+func TestMetadataURL(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{"https://conduit.example.com/mcp", "https://conduit.example.com/.well-known/oauth-protected-resource/mcp"},
+		{"https://conduit.example.com", "https://conduit.example.com/.well-known/oauth-protected-resource"},
+		{"http://localhost:8090/", "http://localhost:8090/.well-known/oauth-protected-resource"},
+	}
+	for _, tt := range tests {
+		got, err := MetadataURL(tt.resource)
+		if err != nil || got != tt.want {
+			t.Errorf("MetadataURL(%q) = %q, %v; want %q", tt.resource, got, err, tt.want)
+		}
+	}
+	if _, err := MetadataURL("/mcp"); err == nil {
+		t.Error("expected error for a relative resource")
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store is a local JSON file of API keys, managed by `conduit keys`.
+type Store struct {
+	path string
+}
+
+type storeFile struct {
+	Keys []Key `json:"keys"`
+}
+
+// DefaultStorePath returns the default key store location,
+// ~/.conduit/keys.json.
+func DefaultStorePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating key store: %w", err)
+	}
+	return filepath.Join(home, ".conduit", "keys.json"), nil
+}
+
+// OpenStore returns the key store at path. The file is created on the first
+// write.
+func OpenStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the store's file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns the stored keys. A missing store has no keys.
+func (s *Store) List() ([]Key, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading key store: %w", err)
+	}
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing key store %s: %w", s.path, err)
+	}
+	return f.Keys, nil
+}
+
+// Create generates a new key for user with the given role and attributes,
+// stores its hash, and returns the plaintext key, which is not recoverable
+// afterwards.
+func (s *Store) Create(user, role string, attributes map[string]string) (string, *Key, error) {
+	keys, err := s.List()
+	if err != nil {
+		return "", nil, err
+	}
+	plaintext, err := GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	key := Key{
+		ID:         "key_" + hex.EncodeToString(id),
+		Hash:       HashAPIKey(plaintext),
+		User:       user,
+		Role:       role,
+		Attributes: attributes,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	if err := s.save(append(keys, key)); err != nil {
+		return "", nil, err
+	}
+	return plaintext, &key, nil
+}
+
+// Revoke deletes the key with the given ID.
+func (s *Store) Revoke(id string) error {
+	keys, err := s.List()
+	if err != nil {
+		return err
+	}
+	for i, k := range keys {
+		if k.ID == id {
+			return s.save(append(keys[:i], keys[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no key with ID %q", id)
+}
+
+// save writes keys atomically, readable only by the owner.
+func (s *Store) save(keys []Key) error {
+	if keys == nil {
+		keys = []Key{}
+	}
+	data, err := json.MarshalIndent(storeFile{Keys: keys}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("creating key store directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing key store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing key store: %w", err)
+	}
+	return nil
+}
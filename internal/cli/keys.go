@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/conduitdb/conduit/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+func newKeysCmd() *cobra.Command {
+	var storePath string
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage API keys for the HTTP transport",
+		Long: `Create, list and revoke API keys in the local key store (default
+~/.conduit/keys.json). Only key hashes are stored. The server reads the store
+when auth.mode is apikey; see auth.key_store to use another file.`,
+	}
+	cmd.PersistentFlags().StringVar(&storePath, "store", "", "Path to the key store (default ~/.conduit/keys.json)")
+
+	openStore := func() (*auth.Store, error) {
+		if storePath != "" {
+			return auth.OpenStore(storePath), nil
+		}
+		path, err := auth.DefaultStorePath()
+		if err != nil {
+			return nil, err
+		}
+		return auth.OpenStore(path), nil
+	}
+
+	var user, role string
+	var attrs map[string]string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create an API key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			return runKeysCreate(store, user, role, attrs)
+		},
+	}
+	create.Flags().StringVar(&user, "user", "", "User name the key authenticates as")
+	create.Flags().StringVar(&role, "role", "", "Role for requests made with the key (default: auth.default_role)")
+	create.Flags().StringToStringVar(&attrs, "attr", nil, "Session attribute for row filters, e.g. --attr tenant=42")
+	_ = create.MarkFlagRequired("user")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List API keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			return runKeysList(store)
+		},
+	}
+
+	revoke := &cobra.Command{
+		Use:   "revoke KEY_ID",
+		Short: "Revoke an API key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			if err := store.Revoke(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Revoked %s. Restart the server for the change to take effect.\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.AddCommand(create, list, revoke)
+	return cmd
+}
+
+func runKeysCreate(store *auth.Store, user, role string, attrs map[string]string) error {
+	plaintext, key, err := store.Create(user, role, attrs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Created %s for %s in %s\n", key.ID, user, store.Path())
+	fmt.Fprintf(os.Stderr, "Copy the key now; it cannot be shown again.\n\n")
+	// The key itself goes to stdout so it can be captured by scripts.
+	fmt.Println(plaintext)
+	return nil
+}
+
+func runKeysList(store *auth.Store) error {
+	keys, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Fprintf(os.Stderr, "No keys in %s\n", store.Path())
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSER\tROLE\tATTRIBUTES\tCREATED")
+	for _, k := range keys {
+		role := k.Role
+		if role == "" {
+			role = "(default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.User, role, formatAttributes(k.Attributes), k.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+func formatAttributes(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(attrs))
+	for name, v := range attrs {
+		pairs = append(pairs, name+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -12,6 +12,7 @@ var version = "dev"
 // NewRootCmd creates the root Cobra command.
 func NewRootCmd(ver, commit, date string) *cobra.Command {
 	version = ver
+	var configFile string
 
 	rootCmd := &cobra.Command{
 		Use:   "conduit [DSN]",
@@ -21,23 +22,33 @@ Point it at any SQL database and get typed, per-table tools for AI agents in sec
 
 Usage:
   conduit <DSN>                    Start MCP server (stdio) for a database
+  conduit --config conduit.yaml    Start MCP server from a config file
   conduit serve <DSN> --http       Start HTTP MCP server with dashboard
   conduit demo                     Demo with sample data (no database needed)
-  conduit config --client cursor   Generate MCP client config`,
+  conduit config --client cursor   Generate MCP client config
+  conduit keys create --user NAME  Create an API key for HTTP auth`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("cannot combine a DSN argument with --config")
+				}
+				return runServeConfig(configFile, serveFlags{}, func(string) bool { return false })
+			}
 			if len(args) == 0 {
 				return cmd.Help()
 			}
-			return runServe(args[0], serveFlags{stdio: true})
+			return runServe(args[0], serveFlags{stdio: true, host: "localhost", port: 8090, maxRows: 1000})
 		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
+	rootCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to config file (conduit.yaml)")
 
 	rootCmd.AddCommand(
 		newServeCmd(),
 		newDemoCmd(),
 		newConfigCmd(),
+		newKeysCmd(),
 		newVersionCmd(ver, commit, date),
 	)
 
@@ -7,20 +7,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/conduitdb/conduit/internal/app"
-	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/auth"
+	"github.com/conduitdb/conduit/internal/config"
 	_ "github.com/conduitdb/conduit/internal/connector/mssql"
 	_ "github.com/conduitdb/conduit/internal/connector/mysql"
 	_ "github.com/conduitdb/conduit/internal/connector/oracle"
 	_ "github.com/conduitdb/conduit/internal/connector/postgres"
 	_ "github.com/conduitdb/conduit/internal/connector/snowflake"
 	_ "github.com/conduitdb/conduit/internal/connector/sqlite"
-	"github.com/conduitdb/conduit/internal/query"
+	"github.com/conduitdb/conduit/internal/format"
 	"github.com/conduitdb/conduit/internal/server"
 	"github.com/conduitdb/conduit/internal/web"
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
 	"github.com/spf13/cobra"
 )
 
@@ -33,8 +38,12 @@ type serveFlags struct {
 	allowRawSQL bool
 	maskPII     bool
 	maxRows     int
+	format      string
+	confirm     bool
+	confirmRows int64
 	authToken   string
 	configFile  string
+	role        string
 }
 
 func newServeCmd() *cobra.Command {
@@ -46,14 +55,16 @@ func newServeCmd() *cobra.Command {
 		Long:  `Start the Conduit MCP server. By default uses stdio transport. Use --http for Streamable HTTP with web dashboard.`,
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 && flags.configFile == "" {
-				return fmt.Errorf("provide a DSN or use --config")
+			if flags.configFile != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("cannot combine a DSN argument with --config")
+				}
+				return runServeConfig(flags.configFile, flags, cmd.Flags().Changed)
 			}
-			dsn := ""
-			if len(args) > 0 {
-				dsn = args[0]
+			if len(args) == 0 {
+				return fmt.Errorf("provide a DSN or use --config")
 			}
-			return runServe(dsn, flags)
+			return runServe(args[0], flags)
 		},
 	}
 
@@ -65,13 +76,112 @@ func newServeCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&flags.allowRawSQL, "allow-raw-sql", false, "Enable raw SQL tool")
 	cmd.Flags().BoolVar(&flags.maskPII, "mask-pii", false, "Mask PII columns in output")
 	cmd.Flags().IntVar(&flags.maxRows, "max-rows", 1000, "Maximum rows per query")
-	cmd.Flags().StringVar(&flags.authToken, "auth-token", "", "Bearer token for HTTP auth")
+	cmd.Flags().StringVar(&flags.format, "format", "", "Default result format: json, arrays, csv, or markdown")
+	cmd.Flags().BoolVar(&flags.confirm, "confirm-writes", false, "Require updates and deletes to be previewed and confirmed")
+	cmd.Flags().Int64Var(&flags.confirmRows, "confirm-threshold", 0, "Require confirmation of updates and deletes affecting at least this many rows")
+	cmd.Flags().StringVar(&flags.authToken, "auth-token", "", "API key accepted as a bearer token for HTTP auth")
+	cmd.Flags().StringVar(&flags.role, "role", "", "Role for callers without credentials (e.g. readonly)")
 	cmd.Flags().StringVarP(&flags.configFile, "config", "c", "", "Path to config file")
 
 	return cmd
 }
 
+// runServe starts the server for a single DSN configured entirely by flags.
 func runServe(dsn string, flags serveFlags) error {
+	cfg := config.Default()
+	cfg.Sources = []config.SourceConfig{{
+		Name:        "default",
+		DSN:         dsn,
+		AllowWrites: flags.allowWrites,
+		MaskPII:     flags.maskPII,
+	}}
+	cfg.Query.MaxRows = flags.maxRows
+	cfg.Query.AllowRawSQL = flags.allowRawSQL
+	cfg.Query.OutputFormat = flags.format
+	cfg.Query.ConfirmWrites = flags.confirm
+	cfg.Query.ConfirmThreshold = flags.confirmRows
+	if flags.httpMode {
+		cfg.Server.Transport = "http"
+	}
+	cfg.Server.Host = flags.host
+	cfg.Server.Port = flags.port
+	cfg.Auth.DefaultRole = flags.role
+	if flags.authToken != "" {
+		addAuthToken(cfg, flags.authToken)
+	}
+	return serve(cfg)
+}
+
+// runServeConfig starts the server from a conduit.yaml file. Flags that were
+// set explicitly on the command line override the corresponding file values.
+func runServeConfig(path string, flags serveFlags, changed func(name string) bool) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	applyFlagOverrides(cfg, flags, changed)
+	return serve(cfg)
+}
+
+// applyFlagOverrides layers explicitly set command-line flags over cfg.
+func applyFlagOverrides(cfg *config.Config, flags serveFlags, changed func(name string) bool) {
+	switch {
+	case changed("http") && flags.httpMode:
+		cfg.Server.Transport = "http"
+	case changed("stdio") && flags.stdio:
+		cfg.Server.Transport = "stdio"
+	}
+	if changed("host") {
+		cfg.Server.Host = flags.host
+	}
+	if changed("port") {
+		cfg.Server.Port = flags.port
+	}
+	if changed("max-rows") {
+		cfg.Query.MaxRows = flags.maxRows
+	}
+	if changed("allow-raw-sql") {
+		cfg.Query.AllowRawSQL = flags.allowRawSQL
+	}
+	if changed("format") {
+		cfg.Query.OutputFormat = flags.format
+	}
+	if changed("confirm-writes") {
+		cfg.Query.ConfirmWrites = flags.confirm
+	}
+	if changed("confirm-threshold") {
+		cfg.Query.ConfirmThreshold = flags.confirmRows
+	}
+	if changed("role") {
+		cfg.Auth.DefaultRole = flags.role
+	}
+	if changed("auth-token") && flags.authToken != "" {
+		addAuthToken(cfg, flags.authToken)
+	}
+	for i := range cfg.Sources {
+		src := &cfg.Sources[i]
+		// --allow-writes never overrides a source marked read_only.
+		if changed("allow-writes") && !src.ReadOnly {
+			src.AllowWrites = flags.allowWrites
+		}
+		if changed("mask-pii") {
+			src.MaskPII = flags.maskPII
+		}
+		if changed("max-rows") {
+			src.MaxRows = flags.maxRows
+		}
+	}
+}
+
+// addAuthToken enables API key auth with token as an additional key. Callers
+// using it get the default role, which Config.Keyring requires when roles are
+// configured.
+func addAuthToken(cfg *config.Config, token string) {
+	cfg.Auth.Mode = "apikey"
+	cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, config.APIKeyConfig{Key: token, User: "auth-token"})
+}
+
+func serve(cfg *config.Config) error {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
@@ -88,19 +198,47 @@ func runServe(dsn string, flags serveFlags) error {
 		cancel()
 	}()
 
+	outputFormat, err := format.Parse(cfg.Query.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	accessEngine := cfg.Access()
+	if role := cfg.Auth.DefaultRole; role != "" && !accessEngine.HasRole(role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+
+	// Authentication only applies to the HTTP transport; stdio callers are
+	// local.
+	var authn httpAuth
+	if cfg.Server.Transport == "http" {
+		var err error
+		if authn, err = newHTTPAuth(ctx, cfg, logger); err != nil {
+			return err
+		}
+	}
+
+	auditLog, err := newAuditLogger(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+	}
+
 	// Build and start the application.
-	application := app.New(app.Config{
-		DSN: dsn,
-		Connection: connector.ConnectionConfig{
-			DSN: dsn,
-		},
-		QueryLimits: query.Limits{
-			MaxRows:     flags.maxRows,
-			AllowWrites: flags.allowWrites,
-		},
-		MaskPII: flags.maskPII,
-		Logger:  logger,
-	})
+	appCfg := app.Config{Logger: logger, Access: accessEngine}
+	for _, src := range cfg.Sources {
+		appCfg.Sources = append(appCfg.Sources, app.SourceConfig{
+			Name:        src.Name,
+			DSN:         src.DSN,
+			Driver:      src.Driver,
+			Connection:  src.ConnectionConfig(),
+			QueryLimits: cfg.QueryLimits(src),
+			MaskPII:     src.MaskPII,
+		})
+	}
+	application := app.New(appCfg)
 
 	if err := application.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start: %w", err)
@@ -108,31 +246,75 @@ func runServe(dsn string, flags serveFlags) error {
 	defer application.Stop()
 
 	// Build the MCP server.
-	mcpSrv := server.New(application.Connector(), server.ServerConfig{
-		Name:        "conduit",
-		Version:     version,
-		AllowWrites: flags.allowWrites,
-		AllowRawSQL: flags.allowRawSQL,
-		MaskPII:     flags.maskPII,
-		MaxRows:     flags.maxRows,
-		Instructions: fmt.Sprintf(
-			"Connected to %s database. Use list_tables to see available tables, "+
-				"describe_table for details, and query to read data. "+
-				"Call enable_table_tools to load typed tools for specific tables.",
-			application.Connector().DriverName()),
+	var sources []server.Source
+	for _, src := range application.Sources() {
+		srcCfg := src.Config()
+		sources = append(sources, server.Source{
+			Name:        srcCfg.Name,
+			Engine:      src.Engine(),
+			AllowWrites: srcCfg.QueryLimits.AllowWrites,
+			MaskPII:     srcCfg.MaskPII,
+			MaxRows:     srcCfg.QueryLimits.MaxRows,
+		})
+	}
+	mcpSrv := server.New(sources, server.ServerConfig{
+		Name:         "conduit",
+		Version:      version,
+		AllowRawSQL:  cfg.Query.AllowRawSQL,
+		OutputFormat: outputFormat,
+		Instructions: instructions(application),
+		Access:       accessEngine,
+		DefaultRole:  cfg.Auth.DefaultRole,
+		RequireRole:  len(cfg.Roles) > 0,
+		Audit:        auditLog,
 	}, logger)
 
-	// Decide transport mode.
-	useHTTP := flags.httpMode
-	if !useHTTP && !flags.stdio {
-		// Default: stdio if no --http flag.
-		flags.stdio = true
+	if cfg.Server.Transport == "http" {
+		return runHTTP(ctx, mcpSrv, cfg, authn, logger)
 	}
+	return runStdio(ctx, mcpSrv, logger)
+}
 
-	if useHTTP {
-		return runHTTP(ctx, mcpSrv, flags, logger)
+// newAuditLogger opens the audit log, or returns nil when auditing is
+// disabled.
+func newAuditLogger(cfg *config.Config, logger *slog.Logger) (*audit.Logger, error) {
+	if !cfg.Audit.Enabled {
+		return nil, nil
 	}
-	return runStdio(ctx, mcpSrv, logger)
+	auditCfg := cfg.Audit
+	if cfg.Server.Transport != "http" {
+		// Stdout carries the stdio transport.
+		auditCfg.Console = os.Stderr
+	}
+	l, err := audit.NewLogger(auditCfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	logger.Info("audit logging enabled", "output", auditCfg.Output)
+	return l, nil
+}
+
+// instructions describes the connected sources to the MCP client.
+func instructions(application *app.App) string {
+	sources := application.Sources()
+	if len(sources) == 1 {
+		return fmt.Sprintf(
+			"Connected to %s database. Use list_tables to see available tables, "+
+				"describe_table for details, and query to read data. "+
+				"Call enable_table_tools to load typed tools for specific tables.",
+			sources[0].Connector().DriverName())
+	}
+	descs := make([]string, len(sources))
+	for i, src := range sources {
+		descs[i] = fmt.Sprintf("%s (%s)", src.Name(), src.Connector().DriverName())
+	}
+	return fmt.Sprintf(
+		"Connected to %d database sources: %s. Every core tool takes an optional "+
+			"source argument (default: %s). Use list_tables to see available tables, "+
+			"describe_table for details, and query to read data. "+
+			"Call enable_table_tools to load typed tools for specific tables; "+
+			"their names are prefixed with the source name.",
+		len(sources), strings.Join(descs, ", "), sources[0].Name())
 }
 
 func runStdio(ctx context.Context, srv *server.Server, logger *slog.Logger) error {
@@ -140,8 +322,63 @@ func runStdio(ctx context.Context, srv *server.Server, logger *slog.Logger) erro
 	return srv.Run(ctx, &mcp.StdioTransport{})
 }
 
-func runHTTP(ctx context.Context, srv *server.Server, flags serveFlags, logger *slog.Logger) error {
-	addr := fmt.Sprintf("%s:%d", flags.host, flags.port)
+// httpAuth is the authentication applied to /mcp and /api/.
+type httpAuth struct {
+	verifier sdkauth.TokenVerifier // nil when auth is disabled
+	opts     *sdkauth.RequireBearerTokenOptions
+	metadata *oauthex.ProtectedResourceMetadata // served in oauth mode
+}
+
+func (a httpAuth) wrap(next http.Handler) http.Handler {
+	return server.BearerAuthMiddleware(a.verifier, a.opts, next)
+}
+
+// newHTTPAuth builds the verifier for cfg.Auth.Mode.
+func newHTTPAuth(ctx context.Context, cfg *config.Config, logger *slog.Logger) (httpAuth, error) {
+	switch cfg.Auth.Mode {
+	case "apikey":
+		// Config files are validated on load; this catches --auth-token.
+		for _, k := range cfg.Auth.APIKeys {
+			if k.Key != "" {
+				if err := auth.ValidateAPIKeyFormat(k.Key); err != nil {
+					return httpAuth{}, err
+				}
+			}
+		}
+		keyring, err := cfg.Keyring()
+		if err != nil {
+			return httpAuth{}, err
+		}
+		logger.Info("API key authentication enabled", "keys", keyring.Len())
+		return httpAuth{verifier: server.APIKeyVerifier(keyring)}, nil
+
+	case "oauth":
+		oauth := cfg.Auth.OAuth
+		verifier, err := auth.NewJWTVerifier(oauth, nil)
+		if err != nil {
+			return httpAuth{}, err
+		}
+		// Fetch the signing keys now so a misconfigured JWKS URL shows up at
+		// startup; verification retries on demand.
+		if err := verifier.Refresh(ctx); err != nil {
+			logger.Warn("could not fetch JWKS", "url", oauth.JWKSURL, "error", err)
+		}
+		metadataURL, err := auth.MetadataURL(oauth.Resource)
+		if err != nil {
+			return httpAuth{}, err
+		}
+		logger.Info("OAuth authentication enabled", "issuer", oauth.Issuer, "resource", oauth.Resource)
+		return httpAuth{
+			verifier: server.OAuthVerifier(verifier),
+			opts:     &sdkauth.RequireBearerTokenOptions{ResourceMetadataURL: metadataURL},
+			metadata: server.ProtectedResourceMetadata(oauth),
+		}, nil
+	}
+	return httpAuth{}, nil
+}
+
+func runHTTP(ctx context.Context, srv *server.Server, cfg *config.Config, authn httpAuth, logger *slog.Logger) error {
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	logger.Info("starting HTTP server", "addr", addr)
 
 	httpMux := http.NewServeMux()
@@ -151,19 +388,27 @@ func runHTTP(ctx context.Context, srv *server.Server, flags serveFlags, logger *
 		func(r *http.Request) *mcp.Server { return srv.MCPServer() },
 		nil,
 	)
-	httpMux.Handle("/mcp", mcpHandler)
+	httpMux.Handle("/mcp", server.ClientIPMiddleware(authn.wrap(mcpHandler)))
 
-	// Web dashboard.
+	// Web dashboard and API.
 	webHandler := web.NewHandler(version, logger)
-	httpMux.Handle("/ui/", webHandler)
-	httpMux.Handle("/ui", webHandler)
-	httpMux.Handle("/api/", webHandler)
+	if cfg.UI.Enabled {
+		httpMux.Handle("/ui/", webHandler)
+		httpMux.Handle("/ui", webHandler)
+	}
+	httpMux.Handle("/api/", authn.wrap(webHandler))
 	httpMux.Handle("/healthz", webHandler)
 	httpMux.Handle("/.well-known/mcp.json", webHandler)
+	if authn.metadata != nil {
+		// RFC 9728 metadata, at the root and at the resource-path suffix.
+		metadata := sdkauth.ProtectedResourceMetadataHandler(authn.metadata)
+		httpMux.Handle("/.well-known/oauth-protected-resource", metadata)
+		httpMux.Handle("/.well-known/oauth-protected-resource/", metadata)
+	}
 
 	// Root redirect to dashboard.
 	httpMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
+		if r.URL.Path == "/" && cfg.UI.Enabled {
 			http.Redirect(w, r, "/ui/", http.StatusTemporaryRedirect)
 			return
 		}
@@ -177,11 +422,17 @@ func runHTTP(ctx context.Context, srv *server.Server, flags serveFlags, logger *
 
 	// Print connection info.
 	fmt.Fprintf(os.Stderr, "\n  Conduit is running!\n\n")
-	fmt.Fprintf(os.Stderr, "  Dashboard:    http://%s/ui\n", addr)
+	if cfg.UI.Enabled {
+		fmt.Fprintf(os.Stderr, "  Dashboard:    http://%s/ui\n", addr)
+	}
 	fmt.Fprintf(os.Stderr, "  MCP endpoint: http://%s/mcp\n", addr)
 	fmt.Fprintf(os.Stderr, "  Health check: http://%s/healthz\n\n", addr)
 	fmt.Fprintf(os.Stderr, "  Add to Claude Code:\n")
-	fmt.Fprintf(os.Stderr, "    claude mcp add conduit --transport http http://%s/mcp\n\n", addr)
+	if authn.verifier != nil && authn.metadata == nil {
+		fmt.Fprintf(os.Stderr, "    claude mcp add conduit --transport http http://%s/mcp --header \"Authorization: Bearer <api-key>\"\n\n", addr)
+	} else {
+		fmt.Fprintf(os.Stderr, "    claude mcp add conduit --transport http http://%s/mcp\n\n", addr)
+	}
 
 	go func() {
 		<-ctx.Done()
@@ -0,0 +1,687 @@
+// Package config loads, expands, and validates the conduit.yaml configuration
+// file and maps it onto the settings consumed by the app, server, and
+// connector layers.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/auth"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/format"
+	"github.com/conduitdb/conduit/internal/query"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the top-level structure of a conduit.yaml file.
+type Config struct {
+	Server  ServerConfig   `yaml:"server"`
+	Sources []SourceConfig `yaml:"sources"`
+	Auth    AuthConfig     `yaml:"auth"`
+	Roles   []access.Role  `yaml:"roles"`
+	Query   QueryConfig    `yaml:"query"`
+	Audit   audit.Config   `yaml:"audit"`
+	UI      UIConfig       `yaml:"ui"`
+
+	// file is the path the config was loaded from, used in error messages.
+	file string
+	// lines maps dotted field paths (e.g. "sources[0].dsn") to the YAML line
+	// they were declared on.
+	lines map[string]int
+}
+
+// ServerConfig controls the MCP transport.
+type ServerConfig struct {
+	Transport string `yaml:"transport"` // "stdio" (default) or "http"
+	Host      string `yaml:"host"`
+	Port      int    `yaml:"port"`
+}
+
+// SourceConfig describes one database source.
+type SourceConfig struct {
+	Name           string   `yaml:"name"`
+	Driver         string   `yaml:"driver"` // optional; detected from the DSN scheme if empty
+	DSN            string   `yaml:"dsn"`
+	ReadOnly       bool     `yaml:"read_only"`
+	AllowWrites    bool     `yaml:"allow_writes"`
+	MaskPII        bool     `yaml:"mask_pii"`
+	MaxRows        int      `yaml:"max_rows"` // overrides query.max_rows for this source
+	MaxConnections int      `yaml:"max_connections"`
+	Schemas        []string `yaml:"schemas"`
+	IncludeTables  []string `yaml:"include_tables"`
+	ExcludeTables  []string `yaml:"exclude_tables"`
+}
+
+// AuthConfig configures HTTP transport authentication.
+type AuthConfig struct {
+	Mode    string           `yaml:"mode"` // "none" (default), "apikey" or "oauth"
+	APIKeys []APIKeyConfig   `yaml:"api_keys"`
+	OAuth   auth.OAuthConfig `yaml:"oauth"`
+
+	// KeyStore is a key file managed by `conduit keys`, read in addition to
+	// APIKeys. Defaults to ~/.conduit/keys.json.
+	KeyStore string `yaml:"key_store"`
+
+	// DefaultRole is the role for callers that present no credentials, such
+	// as stdio clients. When empty, such callers are not role-restricted.
+	DefaultRole string `yaml:"default_role"`
+}
+
+// APIKeyConfig binds an API key to a user and role. The key is given either
+// in plaintext (typically from an environment variable) or as its hash, as
+// printed by `conduit keys create`.
+type APIKeyConfig struct {
+	Key        string            `yaml:"key"`
+	Hash       string            `yaml:"hash"` // "sha256:<hex>"
+	User       string            `yaml:"user"`
+	Role       string            `yaml:"role"`
+	Attributes map[string]string `yaml:"attributes"` // session attributes for row filters
+}
+
+// QueryConfig holds global query limits.
+type QueryConfig struct {
+	MaxRows            int      `yaml:"max_rows"`
+	Timeout            Duration `yaml:"timeout"`
+	MaxResultSizeBytes int64    `yaml:"max_result_size_bytes"`
+	MaxCellBytes       int      `yaml:"max_cell_bytes"`
+	AllowRawSQL        bool     `yaml:"allow_raw_sql"`
+	// OutputFormat is the default result format of the query tools: json
+	// (default), arrays, csv, or markdown.
+	OutputFormat string `yaml:"output_format"`
+	// ConfirmWrites requires every update and delete to be previewed and
+	// confirmed. ConfirmThreshold requires it of those affecting at least
+	// that many rows.
+	ConfirmWrites    bool  `yaml:"confirm_writes"`
+	ConfirmThreshold int64 `yaml:"confirm_threshold"`
+	// InsertBatchSize caps the rows of each statement of a bulk insert,
+	// which otherwise takes as many as the database allows.
+	InsertBatchSize int `yaml:"insert_batch_size"`
+}
+
+// UIConfig controls the embedded web dashboard.
+type UIConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Duration is a time.Duration that unmarshals from strings like "30s".
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q: %w", node.Line, s, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns a Config populated with the built-in defaults. Values read
+// from a file are layered on top of it.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Transport: "stdio",
+			Host:      "localhost",
+			Port:      8090,
+		},
+		Query: QueryConfig{
+			MaxRows: 1000,
+			Timeout: Duration(30 * time.Second),
+		},
+		Audit: audit.Config{
+			Output: audit.OutputStdout,
+		},
+		UI: UIConfig{Enabled: true},
+	}
+}
+
+// Load reads, expands, and validates the config file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return Parse(data, path)
+}
+
+// Parse decodes and validates a YAML config document. The filename is only
+// used to prefix error messages.
+func Parse(data []byte, filename string) (*Config, error) {
+	cfg := Default()
+	cfg.file = filename
+	cfg.lines = make(map[string]int)
+
+	var doc yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: config file is empty", filename)
+	}
+	root := doc.Content[0]
+
+	var errs []error
+	walkNode(root, "", func(n *yaml.Node, path string) {
+		if path != "" {
+			cfg.lines[path] = n.Line
+		}
+		if n.Kind == yaml.ScalarNode {
+			expanded, err := expandEnv(n.Value)
+			if err != nil {
+				errs = append(errs, cfg.errorf(path, "%v", err))
+				return
+			}
+			if expanded != n.Value && n.Style == 0 {
+				// Let plain scalars such as `port: ${PORT}` resolve to
+				// their expanded type instead of staying strings.
+				n.Tag = ""
+			}
+			n.Value = expanded
+		}
+	})
+	errs = append(errs, checkKeys(cfg, root, reflect.TypeOf(cfg), "")...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := root.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration for errors and normalizes driver names.
+// All problems are reported together; each carries the YAML line number when
+// the config was loaded from a file.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Server.Transport {
+	case "", "stdio", "http":
+	default:
+		errs = append(errs, c.errorf("server.transport", "unsupported transport %q (expected stdio or http)", c.Server.Transport))
+	}
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		errs = append(errs, c.errorf("server.port", "port %d is out of range", c.Server.Port))
+	}
+
+	if len(c.Sources) == 0 {
+		errs = append(errs, c.errorf("sources", "at least one source is required"))
+	}
+	seen := make(map[string]bool, len(c.Sources))
+	for i := range c.Sources {
+		errs = append(errs, c.validateSource(i, seen)...)
+	}
+
+	roles := map[string]bool{"readonly": true, "admin": true}
+	for i, r := range c.Roles {
+		path := fmt.Sprintf("roles[%d]", i)
+		if r.Name == "" {
+			errs = append(errs, c.errorf(path+".name", "role name is required"))
+			continue
+		}
+		roles[r.Name] = true
+		if r.MaxRowsPerQuery < 0 {
+			errs = append(errs, c.errorf(path+".max_rows_per_query", "must not be negative"))
+		}
+		for j, tp := range r.Tables {
+			tpath := fmt.Sprintf("%s.tables[%d]", path, j)
+			if tp.Name == "" {
+				errs = append(errs, c.errorf(tpath+".name", "table name is required (use \"*\" for all tables)"))
+			}
+			for k, v := range tp.Verbs {
+				switch access.Verb(strings.ToUpper(v)) {
+				case access.VerbSelect, access.VerbInsert, access.VerbUpdate, access.VerbDelete:
+				default:
+					errs = append(errs, c.errorf(fmt.Sprintf("%s.verbs[%d]", tpath, k), "unknown verb %q (expected SELECT, INSERT, UPDATE, or DELETE)", v))
+				}
+			}
+			if tp.RowFilter != "" {
+				if err := query.ValidateRowFilter(tp.RowFilter); err != nil {
+					errs = append(errs, c.errorf(tpath+".row_filter", "%v", err))
+				}
+			}
+		}
+	}
+
+	switch c.Auth.Mode {
+	case "", "none":
+	case "apikey":
+		// Keys may also come from the key store, which is checked when the
+		// server starts (see Keyring).
+	case "oauth":
+		errs = append(errs, c.validateOAuth(roles)...)
+	default:
+		errs = append(errs, c.errorf("auth.mode", "unsupported auth mode %q (expected none, apikey, or oauth)", c.Auth.Mode))
+	}
+	if c.Auth.DefaultRole != "" && !roles[c.Auth.DefaultRole] {
+		errs = append(errs, c.errorf("auth.default_role", "unknown role %q", c.Auth.DefaultRole))
+	}
+	for i, k := range c.Auth.APIKeys {
+		path := fmt.Sprintf("auth.api_keys[%d]", i)
+		switch {
+		case k.Key == "" && k.Hash == "":
+			errs = append(errs, c.errorf(path+".key", "key or hash is required"))
+		case k.Key != "" && k.Hash != "":
+			errs = append(errs, c.errorf(path+".hash", "key and hash cannot both be set"))
+		case k.Key != "":
+			if err := auth.ValidateAPIKeyFormat(k.Key); err != nil {
+				errs = append(errs, c.errorf(path+".key", "%v", err))
+			}
+		default:
+			if err := auth.ValidateKeyHash(k.Hash); err != nil {
+				errs = append(errs, c.errorf(path+".hash", "%v", err))
+			}
+		}
+		switch {
+		case k.Role != "" && !roles[k.Role]:
+			errs = append(errs, c.errorf(path+".role", "unknown role %q", k.Role))
+		case k.Role == "" && len(c.Roles) > 0 && c.Auth.DefaultRole == "":
+			errs = append(errs, c.errorf(path+".role", "role is required when roles are configured and auth.default_role is not set"))
+		}
+	}
+
+	if c.Query.MaxRows < 0 {
+		errs = append(errs, c.errorf("query.max_rows", "must not be negative"))
+	}
+	if c.Query.Timeout < 0 {
+		errs = append(errs, c.errorf("query.timeout", "must not be negative"))
+	}
+	if c.Query.MaxResultSizeBytes < 0 {
+		errs = append(errs, c.errorf("query.max_result_size_bytes", "must not be negative"))
+	}
+	if c.Query.MaxCellBytes < 0 {
+		errs = append(errs, c.errorf("query.max_cell_bytes", "must not be negative"))
+	}
+	if c.Query.ConfirmThreshold < 0 {
+		errs = append(errs, c.errorf("query.confirm_threshold", "must not be negative"))
+	}
+	if c.Query.InsertBatchSize < 0 {
+		errs = append(errs, c.errorf("query.insert_batch_size", "must not be negative"))
+	}
+	if _, err := format.Parse(c.Query.OutputFormat); err != nil {
+		errs = append(errs, c.errorf("query.output_format", "%v", err))
+	}
+
+	switch c.Audit.Output {
+	case "", audit.OutputStdout, audit.OutputSQLite, audit.OutputFile, audit.OutputSyslog:
+	case audit.OutputWebhook:
+		if c.Audit.WebhookURL == "" {
+			errs = append(errs, c.errorf("audit.webhook_url", "webhook_url is required when output is webhook"))
+		}
+	default:
+		errs = append(errs, c.errorf("audit.output", "unsupported audit output %q", c.Audit.Output))
+	}
+	if c.Audit.RetentionDays < 0 {
+		errs = append(errs, c.errorf("audit.retention_days", "must not be negative"))
+	}
+	if c.Audit.MaxSizeMB < 0 {
+		errs = append(errs, c.errorf("audit.max_size_mb", "must not be negative"))
+	}
+	if c.Audit.MaxFiles < 0 {
+		errs = append(errs, c.errorf("audit.max_files", "must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateOAuth(roles map[string]bool) []error {
+	var errs []error
+	o := &c.Auth.OAuth
+	if o.Resource == "" {
+		errs = append(errs, c.errorf("auth.oauth.resource", "resource (the public URL of /mcp) is required when mode is oauth"))
+	} else if _, err := auth.MetadataURL(o.Resource); err != nil {
+		errs = append(errs, c.errorf("auth.oauth.resource", "%v", err))
+	}
+	if o.Issuer == "" {
+		errs = append(errs, c.errorf("auth.oauth.issuer", "issuer is required when mode is oauth"))
+	}
+	switch {
+	case o.JWKSURL == "" && o.JWKSFile == "":
+		errs = append(errs, c.errorf("auth.oauth", "jwks_url or jwks_file is required when mode is oauth"))
+	case o.JWKSURL != "" && o.JWKSFile != "":
+		errs = append(errs, c.errorf("auth.oauth.jwks_file", "jwks_url and jwks_file cannot both be set"))
+	}
+	for i, m := range o.RoleMappings {
+		path := fmt.Sprintf("auth.oauth.role_mappings[%d]", i)
+		if m.Claim == "" {
+			errs = append(errs, c.errorf(path+".claim", "claim is required"))
+		}
+		if !roles[m.Role] {
+			errs = append(errs, c.errorf(path+".role", "unknown role %q", m.Role))
+		}
+	}
+	return errs
+}
+
+// sourceNamePattern restricts source names to characters that are safe to use
+// in tool names.
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+func (c *Config) validateSource(i int, seen map[string]bool) []error {
+	var errs []error
+	src := &c.Sources[i]
+	path := fmt.Sprintf("sources[%d]", i)
+
+	switch {
+	case src.Name == "":
+		errs = append(errs, c.errorf(path+".name", "source name is required"))
+	case !sourceNamePattern.MatchString(src.Name):
+		errs = append(errs, c.errorf(path+".name", "invalid source name %q: must start with a letter and contain only letters, digits, '_' or '-'", src.Name))
+	case seen[src.Name]:
+		errs = append(errs, c.errorf(path+".name", "duplicate source name %q", src.Name))
+	}
+	seen[src.Name] = true
+
+	if src.DSN == "" {
+		errs = append(errs, c.errorf(path+".dsn", "dsn is required"))
+	}
+
+	detected, dsnErr := connector.ParseDSN(src.DSN)
+	if src.Driver != "" {
+		driver, err := connector.NormalizeDriver(src.Driver)
+		if err != nil {
+			errs = append(errs, c.errorf(path+".driver", "%v", err))
+		} else {
+			if dsnErr == nil && detected != driver {
+				errs = append(errs, c.errorf(path+".driver", "driver %q does not match the DSN scheme (%s)", src.Driver, detected))
+			}
+			src.Driver = driver
+		}
+	} else if src.DSN != "" {
+		if dsnErr != nil {
+			errs = append(errs, c.errorf(path+".dsn", "%v (set driver explicitly or use a URI-style DSN)", dsnErr))
+		} else {
+			src.Driver = detected
+		}
+	}
+
+	if src.ReadOnly && src.AllowWrites {
+		errs = append(errs, c.errorf(path+".allow_writes", "allow_writes cannot be combined with read_only"))
+	}
+	if src.MaxRows < 0 {
+		errs = append(errs, c.errorf(path+".max_rows", "must not be negative"))
+	}
+	if src.MaxConnections < 0 {
+		errs = append(errs, c.errorf(path+".max_connections", "must not be negative"))
+	}
+	return errs
+}
+
+// ConnectionConfig returns the connector settings for the source.
+func (s SourceConfig) ConnectionConfig() connector.ConnectionConfig {
+	return connector.ConnectionConfig{
+		DSN:           s.DSN,
+		MaxOpenConns:  s.MaxConnections,
+		ReadOnly:      s.ReadOnly,
+		Schemas:       s.Schemas,
+		ExcludeTables: s.ExcludeTables,
+		IncludeTables: s.IncludeTables,
+	}
+}
+
+// QueryLimits returns the query limits that apply to the given source.
+func (c *Config) QueryLimits(src SourceConfig) query.Limits {
+	limits := query.DefaultLimits()
+	if c.Query.MaxRows > 0 {
+		limits.MaxRows = c.Query.MaxRows
+	}
+	if src.MaxRows > 0 {
+		limits.MaxRows = src.MaxRows
+	}
+	if c.Query.Timeout > 0 {
+		limits.QueryTimeout = time.Duration(c.Query.Timeout)
+	}
+	if c.Query.MaxResultSizeBytes > 0 {
+		limits.MaxResultSizeBytes = c.Query.MaxResultSizeBytes
+	}
+	if c.Query.MaxCellBytes > 0 {
+		limits.MaxCellBytes = c.Query.MaxCellBytes
+	}
+	limits.AllowWrites = src.AllowWrites && !src.ReadOnly
+	limits.ConfirmWrites = c.Query.ConfirmWrites
+	limits.ConfirmThreshold = c.Query.ConfirmThreshold
+	limits.InsertBatchSize = c.Query.InsertBatchSize
+	return limits
+}
+
+// Access returns the RBAC engine for the configured roles, including the
+// built-in readonly and admin roles unless the file redefines them.
+func (c *Config) Access() *access.Engine {
+	return access.NewEngine(access.WithDefaultRoles(c.Roles))
+}
+
+// Keyring returns the API keys accepted when auth.mode is apikey: the keys
+// listed in the file plus those in the key store. It fails if there are none,
+// or if roles are configured and a key has no role to fall back on.
+func (c *Config) Keyring() (*auth.Keyring, error) {
+	var keys []auth.Key
+	for i, k := range c.Auth.APIKeys {
+		hash := k.Hash
+		if k.Key != "" {
+			hash = auth.HashAPIKey(k.Key)
+		}
+		keys = append(keys, auth.Key{
+			ID:         fmt.Sprintf("auth.api_keys[%d]", i),
+			Hash:       hash,
+			User:       k.User,
+			Role:       k.Role,
+			Attributes: k.Attributes,
+		})
+	}
+
+	path := c.Auth.KeyStore
+	if path == "" {
+		var err error
+		if path, err = auth.DefaultStorePath(); err != nil {
+			return nil, err
+		}
+	}
+	stored, err := auth.OpenStore(path).List()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range stored {
+		if k.Role != "" && !c.Access().HasRole(k.Role) {
+			return nil, fmt.Errorf("key store %s: key %q has unknown role %q", path, k.ID, k.Role)
+		}
+	}
+	keys = append(keys, stored...)
+
+	// A key without a role would not be role-restricted at all.
+	if len(c.Roles) > 0 && c.Auth.DefaultRole == "" {
+		for _, k := range keys {
+			if k.Role == "" {
+				return nil, fmt.Errorf("API key %q has no role; roles are configured, so every key needs one unless auth.default_role is set", k.ID)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("auth.mode is apikey but no API keys are configured (add auth.api_keys or run `conduit keys create`)")
+	}
+	return auth.NewKeyring(keys)
+}
+
+// Error is a validation error tied to a location in the config file.
+type Error struct {
+	File  string
+	Line  int
+	Field string
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&b, ":%d", e.Line)
+		}
+		b.WriteString(": ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// errorf builds an Error for the field at path, resolving the line number
+// from the closest declared ancestor.
+func (c *Config) errorf(path, format string, args ...any) error {
+	return &Error{
+		File:  c.file,
+		Line:  c.lineFor(path),
+		Field: path,
+		Msg:   fmt.Sprintf(format, args...),
+	}
+}
+
+func (c *Config) lineFor(path string) int {
+	for path != "" {
+		if line, ok := c.lines[path]; ok {
+			return line
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0
+}
+
+// envPattern matches ${VAR} and ${VAR:-default} references.
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces environment variable references in s. A reference to an
+// unset variable without a default is an error so that missing secrets are
+// caught at startup rather than producing an empty DSN or key.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var missing []string
+	out := envPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := envPattern.FindStringSubmatch(m)
+		if v, ok := os.LookupEnv(sub[1]); ok && v != "" {
+			return v
+		}
+		if sub[2] != "" {
+			return sub[3]
+		}
+		missing = append(missing, sub[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// walkNode visits every node below n, passing the dotted field path.
+func walkNode(n *yaml.Node, path string, fn func(n *yaml.Node, path string)) {
+	fn(n, path)
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			walkNode(n.Content[i+1], child, fn)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			walkNode(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+}
+
+// checkKeys reports keys in n that do not name a yaml field of t, with their
+// line numbers. It descends into fields holding structs, or slices or
+// pointers of them; maps, such as attributes, take any keys.
+func checkKeys(c *Config, n *yaml.Node, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	var errs []error
+	switch {
+	case n.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range n.Content {
+			errs = append(errs, checkKeys(c, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			ft, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, &Error{File: c.file, Line: key.Line, Field: child, Msg: "unknown field"})
+				continue
+			}
+			errs = append(errs, checkKeys(c, n.Content[i+1], ft, child)...)
+		}
+	}
+	return errs
+}
+
+var unmarshalerType = reflect.TypeFor[yaml.Unmarshaler]()
+
+// yamlFields maps the keys yaml decodes into struct type t to the types of
+// their fields.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue
+		case strings.Contains(opts, "inline"):
+			maps.Copy(fields, yamlFields(f.Type))
+			continue
+		case name == "":
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
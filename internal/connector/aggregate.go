@@ -0,0 +1,103 @@
+package connector
+
+import "strings"
+
+// AggregateFunc is an aggregate function an AggregateRequest may apply.
+type AggregateFunc string
+
+const (
+	AggCount AggregateFunc = "COUNT"
+	AggSum   AggregateFunc = "SUM"
+	AggAvg   AggregateFunc = "AVG"
+	AggMin   AggregateFunc = "MIN"
+	AggMax   AggregateFunc = "MAX"
+)
+
+// Aggregate is one aggregate column of an AggregateRequest.
+type Aggregate struct {
+	Func   AggregateFunc
+	Column string // empty for COUNT(*)
+	Alias  string // result column name
+}
+
+// AggregateRequest represents a grouped aggregation query.
+//
+// As with SelectRequest, Filter and HavingFilter are the caller's filter
+// expressions as written; the query engine compiles them into Where and
+// Having, and connectors only read the compiled forms. Having and OrderBy
+// may name group-by columns and aggregate aliases.
+type AggregateRequest struct {
+	Table        string
+	GroupBy      []string
+	Aggregates   []Aggregate
+	Filter       string
+	Where        *Filter
+	HavingFilter string
+	Having       *Filter
+	// OrderBy is a comma-separated list of group-by columns or aliases,
+	// each optionally followed by ASC or DESC. Connectors render it with
+	// OrderByClause rather than splicing it in as written.
+	OrderBy string
+	Limit   int
+}
+
+// SQL renders the aggregate call in standard SQL, e.g. SUM("total").
+func (a Aggregate) SQL(quote func(string) string) string {
+	if a.Column == "" {
+		return string(a.Func) + "(*)"
+	}
+	return string(a.Func) + "(" + quote(a.Column) + ")"
+}
+
+// SelectList renders the group-by columns followed by each aggregate, as
+// rendered by expr, under its quoted alias.
+func (r AggregateRequest) SelectList(quote func(string) string, expr func(Aggregate) string) string {
+	items := make([]string, 0, len(r.GroupBy)+len(r.Aggregates))
+	for _, col := range r.GroupBy {
+		items = append(items, quote(col))
+	}
+	for _, a := range r.Aggregates {
+		items = append(items, expr(a)+" AS "+quote(a.Alias))
+	}
+	return strings.Join(items, ", ")
+}
+
+// GroupByList renders the quoted group-by columns.
+func (r AggregateRequest) GroupByList(quote func(string) string) string {
+	cols := make([]string, len(r.GroupBy))
+	for i, col := range r.GroupBy {
+		cols[i] = quote(col)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// HavingColumn returns the column renderer for Having: aggregate aliases are
+// replaced by their expression, since not every database accepts select-list
+// aliases in HAVING, and other names are quoted.
+func (r AggregateRequest) HavingColumn(quote func(string) string, expr func(Aggregate) string) func(string) string {
+	return func(name string) string {
+		for _, a := range r.Aggregates {
+			if a.Alias == name {
+				return expr(a)
+			}
+		}
+		return quote(name)
+	}
+}
+
+// OrderByClause renders OrderBy with each column or alias quoted.
+func (r AggregateRequest) OrderByClause(quote func(string) string) string {
+	var terms []string
+	for _, term := range strings.Split(r.OrderBy, ",") {
+		fields := strings.Fields(term)
+		if len(fields) == 0 {
+			continue
+		}
+		rendered := quote(fields[0])
+		if len(fields) > 1 && strings.EqualFold(fields[1], "DESC") {
+			rendered += " DESC"
+		}
+		terms = append(terms, rendered)
+	}
+	return strings.Join(terms, ", ")
+}
@@ -0,0 +1,13 @@
+package connector
+
+// BatchSize returns the most rows that set the given number of columns a
+// single statement can take, when it may bind at most maxParams parameters
+// and, if maxRows is positive, hold at most maxRows rows. It is at least 1,
+// so that a statement can always take one row.
+func BatchSize(columns, maxParams, maxRows int) int {
+	size := maxParams / max(columns, 1)
+	if maxRows > 0 && maxRows < size {
+		size = maxRows
+	}
+	return max(size, 1)
+}
@@ -0,0 +1,24 @@
+package connector
+
+import "testing"
+
+func TestBatchSize(t *testing.T) {
+	tests := []struct {
+		name                        string
+		columns, maxParams, maxRows int
+		want                        int
+	}{
+		{"parameter limit", 10, 65535, 0, 6553},
+		{"row limit", 1, 2100, 1000, 1000},
+		{"parameter limit under row limit", 5, 2100, 1000, 420},
+		{"more columns than parameters", 3000, 2100, 1000, 1},
+		{"no columns", 0, 999, 0, 999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BatchSize(tt.columns, tt.maxParams, tt.maxRows); got != tt.want {
+				t.Errorf("BatchSize(%d, %d, %d) = %d, want %d", tt.columns, tt.maxParams, tt.maxRows, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package connector
+
+import "strings"
+
+// FilterPartKind identifies the kind of a FilterPart.
+type FilterPartKind int
+
+const (
+	// FilterSQL is literal SQL text such as an operator or keyword.
+	FilterSQL FilterPartKind = iota
+	// FilterColumn is a column reference, quoted by the connector.
+	FilterColumn
+	// FilterParam is a bound value, rendered as a placeholder.
+	FilterParam
+)
+
+// FilterPart is one element of a compiled Filter.
+type FilterPart struct {
+	Kind  FilterPartKind
+	Text  string // SQL text for FilterSQL, column name for FilterColumn
+	Value any    // bound value for FilterParam
+}
+
+// Filter is a compiled WHERE condition in dialect-neutral form: a sequence of
+// SQL fragments, column references, and bound values. It never contains
+// user-supplied text outside of column names (which are validated when the
+// filter is compiled) and bound values. Connectors render it with their own
+// identifier quoting and placeholder style via Render.
+//
+// Filters are built by query.CompileFilter, or directly with the builder
+// methods for conditions constructed in code.
+type Filter struct {
+	Parts []FilterPart
+}
+
+// SQL appends literal SQL text and returns f.
+func (f *Filter) SQL(text string) *Filter {
+	f.Parts = append(f.Parts, FilterPart{Kind: FilterSQL, Text: text})
+	return f
+}
+
+// Column appends a column reference and returns f.
+func (f *Filter) Column(name string) *Filter {
+	f.Parts = append(f.Parts, FilterPart{Kind: FilterColumn, Text: name})
+	return f
+}
+
+// Param appends a bound value and returns f.
+func (f *Filter) Param(value any) *Filter {
+	f.Parts = append(f.Parts, FilterPart{Kind: FilterParam, Value: value})
+	return f
+}
+
+// IsEmpty reports whether f is nil or has no parts.
+func (f *Filter) IsEmpty() bool {
+	return f == nil || len(f.Parts) == 0
+}
+
+// Params returns the bound values in placeholder order.
+func (f *Filter) Params() []any {
+	if f == nil {
+		return nil
+	}
+	var params []any
+	for _, p := range f.Parts {
+		if p.Kind == FilterParam {
+			params = append(params, p.Value)
+		}
+	}
+	return params
+}
+
+// Render produces the SQL condition and its bound values. firstIndex is the
+// 1-based placeholder index of the first filter value, so that the filter can
+// follow parameters a statement has already bound (e.g. UPDATE ... SET).
+func (f *Filter) Render(quote func(string) string, placeholder func(int) string, firstIndex int) (string, []any) {
+	if f.IsEmpty() {
+		return "", nil
+	}
+	var sb strings.Builder
+	var params []any
+	for _, p := range f.Parts {
+		switch p.Kind {
+		case FilterSQL:
+			sb.WriteString(p.Text)
+		case FilterColumn:
+			sb.WriteString(quote(p.Text))
+		case FilterParam:
+			sb.WriteString(placeholder(firstIndex + len(params)))
+			params = append(params, p.Value)
+		}
+	}
+	return sb.String(), params
+}
+
+// Eq returns a filter matching rows where each column equals its value,
+// AND-ed together in the given column order.
+func Eq(columns []string, values []any) *Filter {
+	f := &Filter{}
+	for i, col := range columns {
+		if i > 0 {
+			f.SQL(" AND ")
+		}
+		if values[i] == nil {
+			f.Column(col).SQL(" IS NULL")
+			continue
+		}
+		f.Column(col).SQL(" = ").Param(values[i])
+	}
+	return f
+}
+
+// In returns a filter matching rows where column equals one of values. An
+// empty values list matches no rows.
+func In(column string, values []any) *Filter {
+	f := &Filter{}
+	if len(values) == 0 {
+		return f.SQL("1 = 0")
+	}
+	f.Column(column).SQL(" IN (")
+	for i, v := range values {
+		if i > 0 {
+			f.SQL(", ")
+		}
+		f.Param(v)
+	}
+	return f.SQL(")")
+}
+
+// KeyIn returns a filter matching the rows whose columns equal one of keys,
+// each holding one value per column. A single column is matched with IN;
+// several are matched key by key, OR-ed together. An empty keys list
+// matches no rows.
+func KeyIn(columns []string, keys [][]any) *Filter {
+	if len(columns) == 1 {
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return In(columns[0], values)
+	}
+	f := &Filter{}
+	if len(keys) == 0 {
+		return f.SQL("1 = 0")
+	}
+	for i, key := range keys {
+		if i > 0 {
+			f.SQL(" OR ")
+		}
+		f.SQL("(")
+		f.Parts = append(f.Parts, Eq(columns, key).Parts...)
+		f.SQL(")")
+	}
+	return f
+}
+
+// AndFilters combines filters with AND, parenthesizing each so operator
+// precedence inside them is preserved. Empty filters are skipped; if all are
+// empty, the result is nil.
+func AndFilters(filters ...*Filter) *Filter {
+	var nonEmpty []*Filter
+	for _, f := range filters {
+		if !f.IsEmpty() {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	switch len(nonEmpty) {
+	case 0:
+		return nil
+	case 1:
+		return nonEmpty[0]
+	}
+	out := &Filter{}
+	for i, f := range nonEmpty {
+		if i > 0 {
+			out.SQL(" AND ")
+		}
+		out.SQL("(")
+		out.Parts = append(out.Parts, f.Parts...)
+		out.SQL(")")
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package connector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func quote(name string) string { return `"` + name + `"` }
+
+func dollar(i int) string { return fmt.Sprintf("$%d", i) }
+
+func TestFilter_Render(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     *Filter
+		first      int
+		wantClause string
+		wantParams []any
+	}{
+		{"nil", nil, 1, "", nil},
+		{"eq", Eq([]string{"id"}, []any{7}), 1, `"id" = $1`, []any{7}},
+		{"eq after set params", Eq([]string{"a", "b"}, []any{"x", 2}), 3, `"a" = $3 AND "b" = $4`, []any{"x", 2}},
+		{"eq null", Eq([]string{"deleted_at"}, []any{nil}), 1, `"deleted_at" IS NULL`, nil},
+		{"in", In("id", []any{1, 2, 3}), 1, `"id" IN ($1, $2, $3)`, []any{1, 2, 3}},
+		{"in empty", In("id", nil), 1, `1 = 0`, nil},
+		{"key in", KeyIn([]string{"id"}, [][]any{{4}, {5}}), 1, `"id" IN ($1, $2)`, []any{4, 5}},
+		{
+			"key in composite",
+			KeyIn([]string{"a", "b"}, [][]any{{1, "x"}, {2, "y"}}),
+			1,
+			`("a" = $1 AND "b" = $2) OR ("a" = $3 AND "b" = $4)`,
+			[]any{1, "x", 2, "y"},
+		},
+		{"key in empty", KeyIn([]string{"a", "b"}, nil), 1, `1 = 0`, nil},
+		{
+			"keyset",
+			(&Keyset{Columns: []SortKey{{Column: "a"}, {Column: "b", Desc: true}}, After: []any{1, 2}}).Filter(),
+			1,
+			`("a" > $1) OR ("a" = $2 AND "b" < $3)`,
+			[]any{1, 1, 2},
+		},
+		{"keyset first page", (&Keyset{Columns: []SortKey{{Column: "a"}}}).Filter(), 1, "", nil},
+		{
+			"and parenthesizes",
+			AndFilters(
+				(&Filter{}).Column("a").SQL(" = ").Param(1).SQL(" OR ").Column("b").SQL(" = ").Param(2),
+				nil,
+				Eq([]string{"tenant"}, []any{"t1"}),
+			),
+			1,
+			`("a" = $1 OR "b" = $2) AND ("tenant" = $3)`,
+			[]any{1, 2, "t1"},
+		},
+		{"and single", AndFilters(&Filter{}, Eq([]string{"id"}, []any{1})), 1, `"id" = $1`, []any{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, params := tt.filter.Render(quote, dollar, tt.first)
+			if clause != tt.wantClause {
+				t.Errorf("clause = %q, want %q", clause, tt.wantClause)
+			}
+			if fmt.Sprint(params) != fmt.Sprint(tt.wantParams) {
+				t.Errorf("params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
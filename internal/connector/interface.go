@@ -2,6 +2,8 @@ package connector
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/conduitdb/conduit/internal/schema"
@@ -25,14 +27,34 @@ type Connector interface {
 	Insert(ctx context.Context, req InsertRequest) (*MutationResult, error)
 	Update(ctx context.Context, req UpdateRequest) (*MutationResult, error)
 	Delete(ctx context.Context, req DeleteRequest) (*MutationResult, error)
+	Upsert(ctx context.Context, req UpsertRequest) (*MutationResult, error)
+
+	// Transactions. Begin starts a read-write transaction; CRUD and
+	// aggregate calls whose context carries it (see WithTx) run inside it.
+	// Savepoint runs fn under a savepoint of that transaction, rolling back
+	// to it if fn fails so the transaction can go on; see RunSavepoint.
+	Begin(ctx context.Context) (*sql.Tx, error)
+	Savepoint(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Aggregation
+	Aggregate(ctx context.Context, req AggregateRequest) (*ResultSet, error)
 
 	// Stored procedures
 	CallProcedure(ctx context.Context, req ProcedureCallRequest) (*ResultSet, error)
 
-	// SQL dialect helpers
+	// Raw SQL. QueryRaw runs a caller-written read statement inside a
+	// transaction that is never committed, read-only where the database
+	// supports it. ExecRaw runs any statement and reports rows affected.
+	QueryRaw(ctx context.Context, req RawQueryRequest) (*ResultSet, error)
+	ExecRaw(ctx context.Context, sql string) (*MutationResult, error)
+
+	// SQL dialect helpers. InsertBatchSize returns the most rows a single
+	// INSERT can take when they set the given number of columns, within
+	// the database's limits on bind parameters and rows per statement.
 	DriverName() string
 	QuoteIdentifier(name string) string
 	ParameterPlaceholder(index int) string
+	InsertBatchSize(columns int) int
 }
 
 // ConnectionConfig holds database connection settings.
@@ -48,32 +70,151 @@ type ConnectionConfig struct {
 }
 
 // SelectRequest represents a typed query request.
+//
+// Filter is the caller's filter expression as written; the query engine
+// compiles it into Where. Connectors only read Where and never splice Filter
+// into SQL. Likewise the engine turns Cursor into Keyset, and answers Count
+// itself; connectors render Condition and OrderByClause.
 type SelectRequest struct {
 	Table   string
 	Columns []string
 	Filter  string
+	Where   *Filter
 	OrderBy string
 	Limit   int
 	Offset  int
+	// Cursor is the NextCursor of a previous page of the same query.
+	Cursor string
+	Keyset *Keyset
+	// Count requests the total number of matching rows in ResultSet.Total.
+	Count bool
+	// Expand lists foreign key relationships to follow from the result
+	// rows. The query engine resolves them with further selects on the
+	// related tables; connectors ignore it.
+	Expand []Expand
+}
+
+// Expand follows a declared foreign key from the rows of a SelectRequest to
+// a related table, either many-to-one (a foreign key of the queried table
+// references Table) or one-to-many (a foreign key of Table references the
+// queried table). A table that references itself expands many-to-one.
+type Expand struct {
+	Table string
+	// Via names the foreign key column, on the queried table for
+	// many-to-one and on Table for one-to-many. It is only needed when
+	// more than one foreign key relates the two tables.
+	Via string
+	// As is the result key of the expansion; it defaults to Table.
+	As      string
+	Columns []string
+	// Limit caps the related rows attached to each row in a one-to-many
+	// expansion.
+	Limit int
+	// Flatten adds the columns of a many-to-one expansion to each row as
+	// "<as>.<column>" instead of nesting the related row under As.
+	Flatten bool
 }
 
 // InsertRequest represents a typed insert request.
 type InsertRequest struct {
 	Table string
 	Rows  []map[string]any
+	// Returning asks for the inserted rows, with generated keys and
+	// defaults filled in, in MutationResult.Returning. Connectors whose
+	// database cannot report them leave it empty.
+	Returning bool
+	// ContinueOnError asks the query engine to insert the rows that can be
+	// inserted and report the others in MutationResult.RowErrors, rather
+	// than insert none if any fails. Connectors ignore it.
+	ContinueOnError bool
 }
 
-// UpdateRequest represents a typed update request.
+// UpdateRequest represents a typed update request. As with SelectRequest,
+// connectors only read Where.
 type UpdateRequest struct {
 	Table  string
 	Filter string
+	Where  *Filter
 	Set    map[string]any
+	// Returning asks for the updated rows, as for InsertRequest.
+	Returning bool
+	// ConfirmToken is the token of a WritePreview of the same update,
+	// which the query engine checks; connectors ignore it.
+	ConfirmToken string
 }
 
-// DeleteRequest represents a typed delete request.
+// DeleteRequest represents a typed delete request. As with SelectRequest,
+// connectors only read Where.
 type DeleteRequest struct {
 	Table  string
 	Filter string
+	Where  *Filter
+	// Returning asks for the deleted rows, as for InsertRequest.
+	Returning bool
+	// ConfirmToken is the token of a WritePreview of the same delete, as
+	// for UpdateRequest.
+	ConfirmToken string
+}
+
+// UpsertRequest represents a typed insert-or-update request. Each row is
+// inserted, or, if a row with the same values in ConflictColumns exists,
+// that row's other columns are set from it. ConflictColumns are the columns
+// of the table's primary key or of one of its unique keys; the query engine
+// ensures that every row sets them, and that all rows set the same columns.
+type UpsertRequest struct {
+	Table           string
+	Rows            []map[string]any
+	ConflictColumns []string
+	// Returning asks for the inserted and updated rows, as for
+	// InsertRequest.
+	Returning bool
+}
+
+// ErrConfirmationRequired is returned for an update or delete that must be
+// previewed, and then run again with the preview's ConfirmToken.
+var ErrConfirmationRequired = errors.New("confirmation required")
+
+// WritePreview describes the rows an update or delete would change, without
+// changing them. Passing ConfirmToken with the same request before
+// ExpiresAt runs it, provided RowsAffected rows still match.
+type WritePreview struct {
+	Op           string           `json:"op"`
+	Table        string           `json:"table"`
+	RowsAffected int64            `json:"rows_affected"`
+	Sample       []map[string]any `json:"sample"`
+	ConfirmToken string           `json:"confirm_token"`
+	ExpiresAt    time.Time        `json:"expires_at"`
+}
+
+// TransactionRequest represents writes to run in a single transaction, in
+// order. With DryRun, every step runs and the transaction is then rolled
+// back.
+type TransactionRequest struct {
+	Steps  []WriteStep
+	DryRun bool
+}
+
+// WriteStep is one step of a TransactionRequest. Exactly one of Insert,
+// Update, and Delete is set.
+type WriteStep struct {
+	Insert *InsertRequest
+	Update *UpdateRequest
+	Delete *DeleteRequest
+}
+
+// TransactionResult reports the rows affected by each step of a
+// transaction, and whether it was committed.
+type TransactionResult struct {
+	Steps     []StepResult `json:"steps"`
+	Committed bool         `json:"committed"`
+	DryRun    bool         `json:"dry_run,omitempty"`
+}
+
+// StepResult reports the outcome of one step of a transaction.
+type StepResult struct {
+	Op           string `json:"op"`
+	Table        string `json:"table"`
+	RowsAffected int64  `json:"rows_affected"`
 }
 
 // ProcedureCallRequest represents a stored procedure call.
@@ -82,15 +223,52 @@ type ProcedureCallRequest struct {
 	Params map[string]any
 }
 
+// RawQueryRequest represents a caller-written SQL query.
+type RawQueryRequest struct {
+	SQL string
+	// MaxRows caps the rows returned; further rows are not read. Zero
+	// means no cap.
+	MaxRows int
+	// Timeout bounds the query, in addition to any context deadline. Zero
+	// means no additional bound.
+	Timeout time.Duration
+}
+
 // ResultSet holds query results.
 type ResultSet struct {
 	Columns []string         `json:"columns"`
 	Rows    []map[string]any `json:"rows"`
 	Total   int64            `json:"total,omitempty"`
+	// HasMore reports that rows beyond the limit match the query, and
+	// NextCursor, when set, continues after the last row returned.
+	HasMore    bool   `json:"has_more,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Truncated reports that the result was cut to fit the scan limits,
+	// and TruncatedReason says how. See SetTruncation.
+	Truncated       bool   `json:"truncated,omitempty"`
+	TruncatedReason string `json:"truncated_reason,omitempty"`
+	// CutValues counts values cut at the cell size limit, and SizeLimited
+	// reports that rows were left unread at the result size limit.
+	CutValues   int  `json:"-"`
+	SizeLimited bool `json:"-"`
 }
 
-// MutationResult holds the result of an insert/update/delete.
+// MutationResult holds the result of an insert/update/delete. A write
+// awaiting confirmation changes nothing and carries its Preview instead.
 type MutationResult struct {
 	RowsAffected int64            `json:"rows_affected"`
 	Returning    []map[string]any `json:"returning,omitempty"`
+	// ReturningTruncated reports that rows were left out of Returning at
+	// the result size limit. They are still counted in RowsAffected.
+	ReturningTruncated bool `json:"returning_truncated,omitempty"`
+	// RowErrors reports the input rows an insert that continued past
+	// failures could not insert.
+	RowErrors []RowError    `json:"row_errors,omitempty"`
+	Preview   *WritePreview `json:"preview,omitempty"`
+}
+
+// RowError reports why an input row of a write failed. Row counts from 1.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
 }
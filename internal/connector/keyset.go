@@ -0,0 +1,71 @@
+package connector
+
+import "strings"
+
+// SortKey is one column of a Keyset ordering.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset orders a select by Columns, which together identify a row, and
+// when After is set selects only the rows that follow the row with those
+// key values. Unlike OFFSET, the database can seek straight to the page
+// through an index, and concurrent writes do not shift page boundaries.
+type Keyset struct {
+	Columns []SortKey
+	After   []any // one value per column; nil for the first page
+}
+
+// Filter returns the condition selecting the rows after k.After, or nil if
+// k or k.After is unset. Row-value comparisons such as (a, b) > (1, 2) are
+// not supported everywhere and cannot mix directions, so the condition is
+// expanded to a > 1 OR (a = 1 AND b > 2), with < for descending columns.
+func (k *Keyset) Filter() *Filter {
+	if k == nil || len(k.After) == 0 {
+		return nil
+	}
+	f := &Filter{}
+	for i, key := range k.Columns {
+		if i > 0 {
+			f.SQL(" OR ")
+		}
+		f.SQL("(")
+		for j := 0; j < i; j++ {
+			f.Column(k.Columns[j].Column).SQL(" = ").Param(k.After[j]).SQL(" AND ")
+		}
+		op := " > "
+		if key.Desc {
+			op = " < "
+		}
+		f.Column(key.Column).SQL(op).Param(k.After[i]).SQL(")")
+	}
+	return f
+}
+
+// OrderByClause renders the quoted key columns in order.
+func (k *Keyset) OrderByClause(quote func(string) string) string {
+	terms := make([]string, len(k.Columns))
+	for i, key := range k.Columns {
+		terms[i] = quote(key.Column)
+		if key.Desc {
+			terms[i] += " DESC"
+		}
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Condition returns the WHERE condition of r: Where, restricted to the rows
+// after the keyset cursor when there is one.
+func (r SelectRequest) Condition() *Filter {
+	return AndFilters(r.Where, r.Keyset.Filter())
+}
+
+// OrderByClause returns the ORDER BY list of r: the keyset ordering when r
+// has one, and OrderBy otherwise.
+func (r SelectRequest) OrderByClause(quote func(string) string) string {
+	if r.Keyset != nil {
+		return r.Keyset.OrderByClause(quote)
+	}
+	return r.OrderBy
+}
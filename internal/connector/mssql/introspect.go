@@ -153,6 +153,13 @@ func (c *MSSQLConnector) DescribeTable(ctx context.Context, tableName string) (*
 	}
 	detail.Indexes = indexes
 
+	// Fetch unique keys.
+	uniqueKeys, err := c.describeUniqueKeys(ctx, schemaName, tblName)
+	if err != nil {
+		return nil, err
+	}
+	detail.UniqueKeys = uniqueKeys
+
 	return detail, nil
 }
 
@@ -291,6 +298,40 @@ func (c *MSSQLConnector) describeIndexes(ctx context.Context, schemaName, tableN
 	return indexes, rows.Err()
 }
 
+// describeUniqueKeys returns the unique indexes of a table other than its
+// primary key, with their columns in key order.
+// Filtered indexes are left out, since they only hold some rows unique.
+func (c *MSSQLConnector) describeUniqueKeys(ctx context.Context, schemaName, tableName string) ([]schema.UniqueKey, error) {
+	query := `
+		SELECT i.name, col.name
+		FROM sys.indexes i
+		INNER JOIN sys.tables t ON t.object_id = i.object_id
+		INNER JOIN sys.schemas s ON s.schema_id = t.schema_id
+		INNER JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+		INNER JOIN sys.columns col ON col.object_id = ic.object_id AND col.column_id = ic.column_id
+		WHERE s.name = @p1 AND t.name = @p2
+			AND i.is_unique = 1 AND i.is_primary_key = 0 AND i.has_filter = 0
+			AND ic.is_included_column = 0
+		ORDER BY i.name, ic.key_ordinal
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("mssql: describe unique keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []schema.UniqueKey
+	for rows.Next() {
+		var name, col string
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, fmt.Errorf("mssql: scan unique key: %w", err)
+		}
+		keys = schema.AppendUniqueColumn(keys, name, col)
+	}
+	return keys, rows.Err()
+}
+
 // ListProcedures returns stored procedures from sys.procedures.
 func (c *MSSQLConnector) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
 	schemaPlaceholders, schemaArgs := c.schemaPlaceholders(1)
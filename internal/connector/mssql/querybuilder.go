@@ -51,17 +51,19 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	sb.WriteString(" FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
 
-	// WHERE (pass-through filter — the filter parser upstream handles safety)
-	if req.Filter != "" {
+	// WHERE (compiled filter; values are bound before LIMIT/OFFSET params)
+	if where := req.Condition(); !where.IsEmpty() {
+		clause, params := where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
 	}
 
 	// ORDER BY — required for OFFSET/FETCH NEXT in SQL Server.
 	needsPagination := req.Limit > 0 || req.Offset > 0
-	if req.OrderBy != "" {
+	if orderBy := req.OrderByClause(qb.QuoteIdentifier); orderBy != "" {
 		sb.WriteString(" ORDER BY ")
-		sb.WriteString(req.OrderBy)
+		sb.WriteString(orderBy)
 	} else if needsPagination {
 		// SQL Server requires ORDER BY for OFFSET/FETCH NEXT.
 		// Use a deterministic no-op ordering.
@@ -83,9 +85,72 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	return sb.String(), args
 }
 
+// BuildAggregate builds a grouped aggregate query from an AggregateRequest.
+// HAVING conditions on aliases are rendered as the aggregate expression,
+// since SQL Server does not accept column aliases in HAVING.
+func (qb *QueryBuilder) BuildAggregate(req connector.AggregateRequest) (string, []any) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("SELECT ")
+	sb.WriteString(req.SelectList(qb.QuoteIdentifier, qb.aggregateExpr))
+	sb.WriteString(" FROM ")
+	sb.WriteString(qb.QuoteIdentifier(req.Table))
+
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" WHERE ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	if len(req.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(req.GroupByList(qb.QuoteIdentifier))
+	}
+
+	if !req.Having.IsEmpty() {
+		clause, params := req.Having.Render(req.HavingColumn(qb.QuoteIdentifier, qb.aggregateExpr), qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" HAVING ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	// ORDER BY — required for FETCH NEXT in SQL Server.
+	if req.OrderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(req.OrderByClause(qb.QuoteIdentifier))
+	} else if req.Limit > 0 {
+		sb.WriteString(" ORDER BY (SELECT NULL)")
+	}
+
+	if req.Limit > 0 {
+		args = append(args, req.Limit)
+		sb.WriteString(fmt.Sprintf(" OFFSET 0 ROWS FETCH NEXT @p%d ROWS ONLY", len(args)))
+	}
+
+	return sb.String(), args
+}
+
+// aggregateExpr renders an aggregate for SQL Server. COUNT_BIG avoids int
+// overflow on large tables, and AVG is computed in floating point because
+// SQL Server averages integer columns with integer division.
+func (qb *QueryBuilder) aggregateExpr(a connector.Aggregate) string {
+	switch {
+	case a.Func == connector.AggCount && a.Column == "":
+		return "COUNT_BIG(*)"
+	case a.Func == connector.AggCount:
+		return "COUNT_BIG(" + qb.QuoteIdentifier(a.Column) + ")"
+	case a.Func == connector.AggAvg:
+		return "AVG(CAST(" + qb.QuoteIdentifier(a.Column) + " AS FLOAT))"
+	}
+	return a.SQL(qb.QuoteIdentifier)
+}
+
 // BuildInsert builds an INSERT statement for one or more rows.
 // Uses a single multi-row VALUES clause for efficiency.
-// Column order is deterministic (sorted).
+// Column order is deterministic (sorted). With req.Returning, an OUTPUT
+// clause returns the inserted rows; likewise for updates and deletes.
 func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any) {
 	if len(req.Rows) == 0 {
 		return "", nil
@@ -115,7 +180,11 @@ func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any)
 		quoted[i] = qb.QuoteIdentifier(col)
 	}
 	sb.WriteString(strings.Join(quoted, ", "))
-	sb.WriteString(") VALUES ")
+	sb.WriteString(")")
+	if req.Returning {
+		sb.WriteString(" OUTPUT INSERTED.*")
+	}
+	sb.WriteString(" VALUES ")
 
 	for rowIdx, row := range req.Rows {
 		if rowIdx > 0 {
@@ -140,8 +209,81 @@ func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any)
 	return sb.String(), args
 }
 
+// BuildUpsert builds a MERGE statement that inserts the rows, or updates the
+// other columns of the rows they conflict with. HOLDLOCK keeps concurrent
+// upserts of the same key from both inserting it.
+func (qb *QueryBuilder) BuildUpsert(req connector.UpsertRequest) (string, []any) {
+	if len(req.Rows) == 0 {
+		return "", nil
+	}
+	cols := req.Columns()
+
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("MERGE INTO ")
+	sb.WriteString(qb.QuoteIdentifier(req.Table))
+	sb.WriteString(" WITH (HOLDLOCK) AS target USING (VALUES ")
+	for rowIdx, row := range req.Rows {
+		if rowIdx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for colIdx, col := range cols {
+			if colIdx > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, row[col])
+			sb.WriteString(fmt.Sprintf("@p%d", len(args)))
+		}
+		sb.WriteString(")")
+	}
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = qb.QuoteIdentifier(col)
+	}
+	sb.WriteString(") AS source (")
+	sb.WriteString(strings.Join(quoted, ", "))
+	sb.WriteString(") ON ")
+	for i, col := range req.ConflictColumns {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		q := qb.QuoteIdentifier(col)
+		sb.WriteString("target." + q + " = source." + q)
+	}
+
+	if update := req.UpdateColumns(); len(update) > 0 {
+		sb.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		for i, col := range update {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			q := qb.QuoteIdentifier(col)
+			sb.WriteString("target." + q + " = source." + q)
+		}
+	}
+
+	sb.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	sb.WriteString(strings.Join(quoted, ", "))
+	sb.WriteString(") VALUES (")
+	for i, q := range quoted {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("source." + q)
+	}
+	sb.WriteString(")")
+	if req.Returning {
+		sb.WriteString(" OUTPUT INSERTED.*")
+	}
+	sb.WriteString(";")
+
+	return sb.String(), args
+}
+
 // BuildUpdate builds an UPDATE statement.
-// SET columns are sorted deterministically. Filter is passed through.
+// SET columns are sorted deterministically. WHERE params follow the SET params.
 func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any) {
 	cols := make([]string, 0, len(req.Set))
 	for col := range req.Set {
@@ -163,28 +305,37 @@ func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any)
 		args = append(args, req.Set[col])
 		sb.WriteString(fmt.Sprintf("%s = @p%d", qb.QuoteIdentifier(col), len(args)))
 	}
+	if req.Returning {
+		sb.WriteString(" OUTPUT INSERTED.*")
+	}
 
-	if req.Filter != "" {
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
 	}
 
 	return sb.String(), args
 }
 
-// BuildDelete builds a DELETE statement. Filter is required at the caller level.
+// BuildDelete builds a DELETE statement. A filter is required at the caller level.
 func (qb *QueryBuilder) BuildDelete(req connector.DeleteRequest) (string, []any) {
 	var sb strings.Builder
 
 	sb.WriteString("DELETE FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
+	if req.Returning {
+		sb.WriteString(" OUTPUT DELETED.*")
+	}
 
-	if req.Filter != "" {
+	clause, args := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, 1)
+	if clause != "" {
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
 	}
 
-	return sb.String(), nil
+	return sb.String(), args
 }
 
 // BuildProcedureCall builds an EXEC statement for calling a stored procedure.
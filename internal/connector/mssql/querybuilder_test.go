@@ -1,6 +1,7 @@
 package mssql
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -86,16 +87,19 @@ func TestBuildSelect(t *testing.T) {
 
 	t.Run("select with filter", func(t *testing.T) {
 		req := connector.SelectRequest{
-			Table:  "orders",
-			Filter: "status = 'active'",
+			Table: "orders",
+			Where: connector.Eq([]string{"status"}, []any{"active"}),
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM [orders] WHERE status = 'active'`
+		wantQuery := `SELECT * FROM [orders] WHERE [status] = @p1`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
 		}
 	})
 
@@ -117,24 +121,27 @@ func TestBuildSelect(t *testing.T) {
 	t.Run("select with limit and offset uses OFFSET FETCH NEXT", func(t *testing.T) {
 		req := connector.SelectRequest{
 			Table:   "orders",
-			Filter:  "status = 'active'",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
 			OrderBy: "created_at DESC",
 			Limit:   50,
 			Offset:  10,
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM [orders] WHERE status = 'active' ORDER BY created_at DESC OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY`
+		wantQuery := `SELECT * FROM [orders] WHERE [status] = @p1 ORDER BY created_at DESC OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
 		}
-		if args[0] != 10 {
-			t.Errorf("args[0] = %v, want 10 (offset)", args[0])
+		if args[1] != 10 {
+			t.Errorf("args[1] = %v, want 10 (offset)", args[1])
 		}
-		if args[1] != 50 {
-			t.Errorf("args[1] = %v, want 50 (limit)", args[1])
+		if args[2] != 50 {
+			t.Errorf("args[2] = %v, want 50 (limit)", args[2])
 		}
 	})
 
@@ -177,6 +184,27 @@ func TestBuildSelect(t *testing.T) {
 			t.Errorf("args[0] = %v, want 5 (offset)", args[0])
 		}
 	})
+
+	t.Run("keyset page replaces order by", func(t *testing.T) {
+		req := connector.SelectRequest{
+			Table:   "orders",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
+			OrderBy: "created_at DESC",
+			Limit:   21,
+			Keyset: &connector.Keyset{
+				Columns: []connector.SortKey{{Column: "created_at", Desc: true}, {Column: "id"}},
+				After:   []any{"2025-01-01", 7},
+			},
+		}
+		query, args := qb.BuildSelect(req)
+		wantQuery := `SELECT * FROM [orders] WHERE ([status] = @p1) AND (([created_at] < @p2) OR ([created_at] = @p3 AND [id] > @p4)) ORDER BY [created_at] DESC, [id] OFFSET @p5 ROWS FETCH NEXT @p6 ROWS ONLY`
+		if query != wantQuery {
+			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
+		}
+		if want := []any{"active", "2025-01-01", "2025-01-01", 7, 0, 21}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
+		}
+	})
 }
 
 func TestBuildInsert(t *testing.T) {
@@ -290,18 +318,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("basic update", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "users",
-			Set:    map[string]any{"email": "new@b.com", "name": "Bob"},
-			Filter: "id = 1",
+			Table: "users",
+			Set:   map[string]any{"email": "new@b.com", "name": "Bob"},
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns are sorted: email, name
-		wantQuery := `UPDATE [users] SET [email] = @p1, [name] = @p2 WHERE id = 1`
+		wantQuery := `UPDATE [users] SET [email] = @p1, [name] = @p2 WHERE [id] = @p3`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[2] != 1 {
+			t.Errorf("args[2] = %v, want 1", args[2])
 		}
 		if args[0] != "new@b.com" {
 			t.Errorf("args[0] = %v, want %q", args[0], "new@b.com")
@@ -313,18 +344,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("multiple columns sorted deterministically", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "products",
-			Set:    map[string]any{"zebra": "z", "alpha": "a", "middle": "m"},
-			Filter: "id = 5",
+			Table: "products",
+			Set:   map[string]any{"zebra": "z", "alpha": "a", "middle": "m"},
+			Where: connector.Eq([]string{"id"}, []any{5}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns sorted: alpha, middle, zebra
-		wantQuery := `UPDATE [products] SET [alpha] = @p1, [middle] = @p2, [zebra] = @p3 WHERE id = 5`
+		wantQuery := `UPDATE [products] SET [alpha] = @p1, [middle] = @p2, [zebra] = @p3 WHERE [id] = @p4`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 3 {
-			t.Fatalf("got %d args, want 3", len(args))
+		if len(args) != 4 {
+			t.Fatalf("got %d args, want 4", len(args))
+		}
+		if args[3] != 5 {
+			t.Errorf("args[3] = %v, want 5", args[3])
 		}
 		if args[0] != "a" {
 			t.Errorf("args[0] = %v, want %q", args[0], "a")
@@ -361,16 +395,19 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("delete with filter", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "users",
-			Filter: "id = 1",
+			Table: "users",
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := `DELETE FROM [users] WHERE id = 1`
+		wantQuery := `DELETE FROM [users] WHERE [id] = @p1`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != 1 {
+			t.Errorf("args[0] = %v, want 1", args[0])
 		}
 	})
 
@@ -386,6 +423,158 @@ func TestBuildDelete(t *testing.T) {
 	})
 }
 
+func TestBuildReturning(t *testing.T) {
+	qb := &QueryBuilder{}
+	where := connector.Eq([]string{"id"}, []any{1})
+
+	tests := []struct {
+		name  string
+		build func() (string, []any)
+		want  string
+	}{
+		{
+			"insert",
+			func() (string, []any) {
+				return qb.BuildInsert(connector.InsertRequest{
+					Table:     "users",
+					Rows:      []map[string]any{{"name": "Alice"}, {"name": "Bob"}},
+					Returning: true,
+				})
+			},
+			`INSERT INTO [users] ([name]) OUTPUT INSERTED.* VALUES (@p1), (@p2)`,
+		},
+		{
+			"update",
+			func() (string, []any) {
+				return qb.BuildUpdate(connector.UpdateRequest{
+					Table:     "users",
+					Set:       map[string]any{"name": "Carol"},
+					Where:     where,
+					Returning: true,
+				})
+			},
+			`UPDATE [users] SET [name] = @p1 OUTPUT INSERTED.* WHERE [id] = @p2`,
+		},
+		{
+			"delete",
+			func() (string, []any) {
+				return qb.BuildDelete(connector.DeleteRequest{Table: "users", Where: where, Returning: true})
+			},
+			`DELETE FROM [users] OUTPUT DELETED.* WHERE [id] = @p1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if query, _ := tt.build(); query != tt.want {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		req       connector.UpsertRequest
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			"update other columns",
+			connector.UpsertRequest{
+				Table:           "users",
+				Rows:            []map[string]any{{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob"}},
+				ConflictColumns: []string{"id"},
+				Returning:       true,
+			},
+			"MERGE INTO [users] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2), (@p3, @p4)) AS source ([id], [name]) " +
+				"ON target.[id] = source.[id] WHEN MATCHED THEN UPDATE SET target.[name] = source.[name] " +
+				"WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (source.[id], source.[name]) OUTPUT INSERTED.*;",
+			[]any{1, "Ann", 2, "Bob"},
+		},
+		{
+			"key columns only",
+			connector.UpsertRequest{
+				Table:           "tags",
+				Rows:            []map[string]any{{"slug": "go"}},
+				ConflictColumns: []string{"slug"},
+			},
+			"MERGE INTO [tags] WITH (HOLDLOCK) AS target USING (VALUES (@p1)) AS source ([slug]) " +
+				"ON target.[slug] = source.[slug] WHEN NOT MATCHED THEN INSERT ([slug]) VALUES (source.[slug]);",
+			[]any{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := qb.BuildUpsert(tt.req)
+			if query != tt.wantQuery {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildAggregate(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	t.Run("group by with having", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:   "orders",
+			GroupBy: []string{"status"},
+			Aggregates: []connector.Aggregate{
+				{Func: connector.AggCount, Alias: "n"},
+				{Func: connector.AggAvg, Column: "total", Alias: "avg_total"},
+			},
+			Where:   connector.Eq([]string{"region"}, []any{"eu"}),
+			Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(5),
+			OrderBy: "n DESC",
+			Limit:   10,
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT [status], COUNT_BIG(*) AS [n], AVG(CAST([total] AS FLOAT)) AS [avg_total] FROM [orders] WHERE [region] = @p1 GROUP BY [status] HAVING COUNT_BIG(*) > @p2 ORDER BY [n] DESC OFFSET 0 ROWS FETCH NEXT @p3 ROWS ONLY`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 3 || args[0] != "eu" || args[1] != 5 || args[2] != 10 {
+			t.Errorf("args = %v, want [eu 5 10]", args)
+		}
+	})
+
+	t.Run("count only", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT COUNT_BIG(*) AS [count] FROM [orders]`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 0 {
+			t.Errorf("got %d args, want 0", len(args))
+		}
+	})
+
+	t.Run("limit without order", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			GroupBy:    []string{"status"},
+			Aggregates: []connector.Aggregate{{Func: connector.AggMax, Column: "total", Alias: "max_total"}},
+			Limit:      5,
+		}
+		query, _ := qb.BuildAggregate(req)
+		wantQuery := `SELECT [status], MAX([total]) AS [max_total] FROM [orders] GROUP BY [status] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT @p1 ROWS ONLY`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+	})
+}
+
 func TestBuildProcedureCall(t *testing.T) {
 	qb := &QueryBuilder{}
 
@@ -92,19 +92,40 @@ func (c *MySQLConnector) ParameterPlaceholder(index int) string {
 	return c.qb.ParameterPlaceholder(index)
 }
 
+// InsertBatchSize returns the most rows one INSERT can take, within the
+// 65535 placeholders MySQL allows a prepared statement.
+func (c *MySQLConnector) InsertBatchSize(columns int) int {
+	return connector.BatchSize(columns, 65535, 0)
+}
+
 // Select executes a typed SELECT query.
 func (c *MySQLConnector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildSelect(req)
-	rows, err := c.db.QueryContext(ctx, query, args...)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("mysql: select failed: %w", err)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
+}
+
+// Aggregate executes a grouped aggregate query.
+func (c *MySQLConnector) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	query, args := c.qb.BuildAggregate(req)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: aggregate failed: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(ctx, rows, 0)
 }
 
-// Insert executes a typed INSERT statement.
+// Insert executes a typed INSERT statement. MySQL has no RETURNING clause,
+// so with req.Returning the inserted rows are selected by primary key
+// afterwards; see insertedRows. Updates and deletes cannot be looked up
+// that way and leave Returning empty.
 func (c *MySQLConnector) Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
 	if c.readOnly {
 		return nil, fmt.Errorf("mysql: insert denied — connection is read-only")
@@ -114,13 +135,119 @@ func (c *MySQLConnector) Insert(ctx context.Context, req connector.InsertRequest
 	}
 
 	query, args := c.qb.BuildInsert(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("mysql: insert failed: %w", err)
 	}
 
 	affected, _ := result.RowsAffected()
-	return &connector.MutationResult{RowsAffected: affected}, nil
+	res := &connector.MutationResult{RowsAffected: affected}
+	if req.Returning {
+		// The rows are written by now, so a failed lookup leaves Returning
+		// empty rather than failing the insert.
+		firstID, _ := result.LastInsertId()
+		if rs, err := c.insertedRows(ctx, req, firstID); err == nil && rs != nil {
+			res.Returning = rs.Rows
+			res.ReturningTruncated = rs.SizeLimited
+		}
+	}
+	return res, nil
+}
+
+// Upsert executes a typed INSERT ... ON DUPLICATE KEY UPDATE statement. With
+// req.Returning, the written rows are selected by their conflict columns
+// afterwards. RowsAffected is as MySQL counts it: one for each inserted row
+// and two for each updated one.
+func (c *MySQLConnector) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("mysql: upsert denied — connection is read-only")
+	}
+	if len(req.Rows) == 0 {
+		return &connector.MutationResult{RowsAffected: 0}, nil
+	}
+
+	query, args := c.qb.BuildUpsert(req)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: upsert failed: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	res := &connector.MutationResult{RowsAffected: affected}
+	if req.Returning {
+		// As for Insert, a failed lookup leaves Returning empty.
+		if rs, err := c.Select(ctx, req.KeySelect()); err == nil {
+			res.Returning = rs.Rows
+			res.ReturningTruncated = rs.SizeLimited
+		}
+	}
+	return res, nil
+}
+
+// insertedRows selects the rows inserted by req by primary key. Key values
+// the rows leave out are generated AUTO_INCREMENT values: MySQL assigns a
+// multi-row insert consecutive values starting at firstID, the
+// LAST_INSERT_ID of the insert. It returns nil if the table has no primary
+// key, or if the rows leave out part of a composite one.
+func (c *MySQLConnector) insertedRows(ctx context.Context, req connector.InsertRequest, firstID int64) (*connector.ResultSet, error) {
+	dbName, err := c.schemaName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := c.describePrimaryKey(ctx, dbName, req.Table)
+	if err != nil || len(pk) == 0 {
+		return nil, err
+	}
+
+	keys := make([][]any, len(req.Rows))
+	var generated []int // the rows whose key MySQL generated
+	for i, row := range req.Rows {
+		keys[i] = make([]any, len(pk))
+		for j, col := range pk {
+			if keys[i][j] = lookupFold(row, col); keys[i][j] != nil {
+				continue
+			}
+			if len(pk) > 1 || firstID == 0 {
+				return nil, nil
+			}
+			generated = append(generated, i)
+		}
+	}
+	step := int64(1)
+	if len(generated) > 1 {
+		// Servers may be set to generate keys in larger increments.
+		if err := c.db.QueryRowContext(ctx, "SELECT @@auto_increment_increment").Scan(&step); err != nil {
+			return nil, err
+		}
+	}
+	for n, i := range generated {
+		keys[i][0] = firstID + int64(n)*step
+	}
+
+	order := make([]connector.SortKey, len(pk))
+	for i, col := range pk {
+		order[i] = connector.SortKey{Column: col}
+	}
+	return c.Select(ctx, connector.SelectRequest{
+		Table:  req.Table,
+		Where:  connector.KeyIn(pk, keys),
+		Keyset: &connector.Keyset{Columns: order},
+		Limit:  len(keys),
+	})
+}
+
+// lookupFold returns the value of the column of row named col, matched
+// case-insensitively as MySQL matches column names.
+func lookupFold(row map[string]any, col string) any {
+	if v, ok := row[col]; ok {
+		return v
+	}
+	for name, v := range row {
+		if strings.EqualFold(name, col) {
+			return v
+		}
+	}
+	return nil
 }
 
 // Update executes a typed UPDATE statement.
@@ -133,7 +260,7 @@ func (c *MySQLConnector) Update(ctx context.Context, req connector.UpdateRequest
 	}
 
 	query, args := c.qb.BuildUpdate(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("mysql: update failed: %w", err)
 	}
@@ -152,7 +279,7 @@ func (c *MySQLConnector) Delete(ctx context.Context, req connector.DeleteRequest
 	}
 
 	query, args := c.qb.BuildDelete(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("mysql: delete failed: %w", err)
 	}
@@ -170,49 +297,69 @@ func (c *MySQLConnector) CallProcedure(ctx context.Context, req connector.Proced
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
 }
 
-// scanRows converts *sql.Rows into a ResultSet.
-func scanRows(rows *sql.Rows) (*connector.ResultSet, error) {
-	cols, err := rows.Columns()
+// QueryRaw executes a caller-written query in a read-only transaction.
+func (c *MySQLConnector) QueryRaw(ctx context.Context, req connector.RawQueryRequest) (*connector.ResultSet, error) {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	tx, err := c.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		return nil, fmt.Errorf("mysql: failed to get columns: %w", err)
+		return nil, fmt.Errorf("mysql: failed to begin read-only transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	result := &connector.ResultSet{
-		Columns: cols,
-		Rows:    make([]map[string]any, 0),
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: raw query failed: %w", err)
 	}
+	defer rows.Close()
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, fmt.Errorf("mysql: scan failed: %w", err)
-		}
+	return scanRows(ctx, rows, req.MaxRows)
+}
 
-		row := make(map[string]any, len(cols))
-		for i, col := range cols {
-			val := values[i]
-			// Convert []byte to string for JSON serialization friendliness.
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		result.Rows = append(result.Rows, row)
+// ExecRaw executes a caller-written statement.
+func (c *MySQLConnector) ExecRaw(ctx context.Context, query string) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("mysql: execute denied — connection is read-only")
+	}
+	result, err := c.db.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: execute failed: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("mysql: row iteration failed: %w", err)
+	affected, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: affected}, nil
+}
+
+// Begin starts a read-write transaction.
+func (c *MySQLConnector) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to begin transaction: %w", err)
 	}
+	return tx, nil
+}
 
-	return result, nil
+// Savepoint runs fn under a savepoint of the transaction carried by ctx, so
+// that a failure undoes all fn did, not only its failed statement.
+func (c *MySQLConnector) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return connector.RunSavepoint(ctx,
+		"SAVEPOINT conduit_write", "ROLLBACK TO SAVEPOINT conduit_write", "RELEASE SAVEPOINT conduit_write", fn)
+}
+
+// scanRows reads rows into a ResultSet within the scan limits carried by
+// ctx, stopping after maxRows rows when maxRows is positive.
+func scanRows(ctx context.Context, rows *sql.Rows, maxRows int) (*connector.ResultSet, error) {
+	rs, err := connector.ScanRows(ctx, rows, maxRows, connector.BytesToString)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: %w", err)
+	}
+	return rs, nil
 }
 
 // schemaName returns the current database name from the connection.
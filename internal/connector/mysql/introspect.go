@@ -134,6 +134,13 @@ func (c *MySQLConnector) DescribeTable(ctx context.Context, tableName string) (*
 	}
 	detail.Indexes = indexes
 
+	// Fetch unique keys.
+	uniqueKeys, err := c.describeUniqueKeys(ctx, dbName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	detail.UniqueKeys = uniqueKeys
+
 	return detail, nil
 }
 
@@ -271,6 +278,37 @@ func (c *MySQLConnector) describeIndexes(ctx context.Context, dbName, tableName
 	return indexes, rows.Err()
 }
 
+// describeUniqueKeys returns the unique indexes of a table other than its
+// primary key, with their columns in key order.
+func (c *MySQLConnector) describeUniqueKeys(ctx context.Context, dbName, tableName string) ([]schema.UniqueKey, error) {
+	query := `
+		SELECT INDEX_NAME, COLUMN_NAME
+		FROM information_schema.statistics
+		WHERE TABLE_SCHEMA = ?
+			AND TABLE_NAME = ?
+			AND NON_UNIQUE = 0
+			AND INDEX_NAME <> 'PRIMARY'
+			AND COLUMN_NAME IS NOT NULL
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, dbName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: describe unique keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []schema.UniqueKey
+	for rows.Next() {
+		var name, col string
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, fmt.Errorf("mysql: scan unique key: %w", err)
+		}
+		keys = schema.AppendUniqueColumn(keys, name, col)
+	}
+	return keys, rows.Err()
+}
+
 // ListProcedures returns stored procedures and functions from information_schema.routines.
 func (c *MySQLConnector) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
 	dbName, err := c.schemaName(ctx)
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -86,16 +87,19 @@ func TestBuildSelect(t *testing.T) {
 
 	t.Run("select with filter", func(t *testing.T) {
 		req := connector.SelectRequest{
-			Table:  "orders",
-			Filter: "status = 'active'",
+			Table: "orders",
+			Where: connector.Eq([]string{"status"}, []any{"active"}),
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := "SELECT * FROM `orders` WHERE status = 'active'"
+		wantQuery := "SELECT * FROM `orders` WHERE `status` = ?"
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
 		}
 	})
 
@@ -140,24 +144,48 @@ func TestBuildSelect(t *testing.T) {
 		req := connector.SelectRequest{
 			Table:   "orders",
 			Columns: []string{"id", "total"},
-			Filter:  "status = 'active'",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
 			OrderBy: "created_at DESC",
 			Limit:   50,
 			Offset:  10,
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := "SELECT `id`, `total` FROM `orders` WHERE status = 'active' ORDER BY created_at DESC LIMIT ? OFFSET ?"
+		wantQuery := "SELECT `id`, `total` FROM `orders` WHERE `status` = ? ORDER BY created_at DESC LIMIT ? OFFSET ?"
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
+		}
+		if args[1] != 50 {
+			t.Errorf("args[1] = %v, want 50", args[1])
+		}
+		if args[2] != 10 {
+			t.Errorf("args[2] = %v, want 10", args[2])
+		}
+	})
+
+	t.Run("keyset page replaces order by", func(t *testing.T) {
+		req := connector.SelectRequest{
+			Table:   "orders",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
+			OrderBy: "created_at DESC",
+			Limit:   21,
+			Keyset: &connector.Keyset{
+				Columns: []connector.SortKey{{Column: "created_at", Desc: true}, {Column: "id"}},
+				After:   []any{"2025-01-01", 7},
+			},
 		}
-		if args[0] != 50 {
-			t.Errorf("args[0] = %v, want 50", args[0])
+		query, args := qb.BuildSelect(req)
+		wantQuery := "SELECT * FROM `orders` WHERE (`status` = ?) AND ((`created_at` < ?) OR (`created_at` = ? AND `id` > ?)) ORDER BY `created_at` DESC, `id` LIMIT ?"
+		if query != wantQuery {
+			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if args[1] != 10 {
-			t.Errorf("args[1] = %v, want 10", args[1])
+		if want := []any{"active", "2025-01-01", "2025-01-01", 7, 21}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
 		}
 	})
 }
@@ -273,18 +301,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("basic update", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "users",
-			Set:    map[string]any{"email": "new@b.com", "name": "Bob"},
-			Filter: "id = 1",
+			Table: "users",
+			Set:   map[string]any{"email": "new@b.com", "name": "Bob"},
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns are sorted: email, name
-		wantQuery := "UPDATE `users` SET `email` = ?, `name` = ? WHERE id = 1"
+		wantQuery := "UPDATE `users` SET `email` = ?, `name` = ? WHERE `id` = ?"
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[2] != 1 {
+			t.Errorf("args[2] = %v, want 1", args[2])
 		}
 		if args[0] != "new@b.com" {
 			t.Errorf("args[0] = %v, want %q", args[0], "new@b.com")
@@ -293,18 +324,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("multiple columns sorted", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "products",
-			Set:    map[string]any{"stock": 100, "name": "Widget", "active": true},
-			Filter: "id = 5",
+			Table: "products",
+			Set:   map[string]any{"stock": 100, "name": "Widget", "active": true},
+			Where: connector.Eq([]string{"id"}, []any{5}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns sorted: active, name, stock
-		wantQuery := "UPDATE `products` SET `active` = ?, `name` = ?, `stock` = ? WHERE id = 5"
+		wantQuery := "UPDATE `products` SET `active` = ?, `name` = ?, `stock` = ? WHERE `id` = ?"
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 3 {
-			t.Fatalf("got %d args, want 3", len(args))
+		if len(args) != 4 {
+			t.Fatalf("got %d args, want 4", len(args))
+		}
+		if args[3] != 5 {
+			t.Errorf("args[3] = %v, want 5", args[3])
 		}
 		if args[0] != true {
 			t.Errorf("args[0] = %v, want true", args[0])
@@ -323,16 +357,19 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("with filter", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "users",
-			Filter: "id = 1",
+			Table: "users",
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := "DELETE FROM `users` WHERE id = 1"
+		wantQuery := "DELETE FROM `users` WHERE `id` = ?"
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != 1 {
+			t.Errorf("args[0] = %v, want 1", args[0])
 		}
 	})
 
@@ -348,6 +385,91 @@ func TestBuildDelete(t *testing.T) {
 	})
 }
 
+func TestBuildUpsert(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		req       connector.UpsertRequest
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			"update other columns",
+			connector.UpsertRequest{
+				Table:           "users",
+				Rows:            []map[string]any{{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob"}},
+				ConflictColumns: []string{"id"},
+			},
+			"INSERT INTO `users` (`id`, `name`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			[]any{1, "Ann", 2, "Bob"},
+		},
+		{
+			"key columns only",
+			connector.UpsertRequest{
+				Table:           "tags",
+				Rows:            []map[string]any{{"slug": "go"}},
+				ConflictColumns: []string{"slug"},
+			},
+			"INSERT INTO `tags` (`slug`) VALUES (?) ON DUPLICATE KEY UPDATE `slug` = VALUES(`slug`)",
+			[]any{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := qb.BuildUpsert(tt.req)
+			if query != tt.wantQuery {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildAggregate(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	t.Run("group by with having", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:   "orders",
+			GroupBy: []string{"status"},
+			Aggregates: []connector.Aggregate{
+				{Func: connector.AggCount, Alias: "n"},
+				{Func: connector.AggAvg, Column: "total", Alias: "avg_total"},
+			},
+			Where:   connector.Eq([]string{"region"}, []any{"eu"}),
+			Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(5),
+			OrderBy: "n DESC",
+			Limit:   10,
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := "SELECT `status`, COUNT(*) AS `n`, AVG(`total`) AS `avg_total` FROM `orders` WHERE `region` = ? GROUP BY `status` HAVING COUNT(*) > ? ORDER BY `n` DESC LIMIT ?"
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 3 || args[0] != "eu" || args[1] != 5 || args[2] != 10 {
+			t.Errorf("args = %v, want [eu 5 10]", args)
+		}
+	})
+
+	t.Run("count only", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := "SELECT COUNT(*) AS `count` FROM `orders`"
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 0 {
+			t.Errorf("got %d args, want 0", len(args))
+		}
+	})
+}
+
 func TestBuildProcedureCall(t *testing.T) {
 	qb := &QueryBuilder{}
 
@@ -10,7 +10,7 @@ import (
 
 	"github.com/conduitdb/conduit/internal/connector"
 
-	_ "github.com/sijms/go-ora/v2" // Oracle driver
+	go_ora "github.com/sijms/go-ora/v2" // Oracle driver
 )
 
 func init() {
@@ -103,19 +103,40 @@ func (c *OracleConnector) ParameterPlaceholder(index int) string {
 	return c.qb.ParameterPlaceholder(index)
 }
 
+// InsertBatchSize returns the most rows one INSERT can take, within the
+// 999 target columns Oracle allows across the INTO clauses of INSERT ALL.
+func (c *OracleConnector) InsertBatchSize(columns int) int {
+	return connector.BatchSize(columns, 999, 0)
+}
+
 // Select executes a typed SELECT query.
 func (c *OracleConnector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildSelect(req)
-	rows, err := c.db.QueryContext(ctx, query, args...)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("oracle: select failed: %w", err)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
+}
+
+// Aggregate executes a grouped aggregate query.
+func (c *OracleConnector) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	query, args := c.qb.BuildAggregate(req)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: aggregate failed: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(ctx, rows, 0)
 }
 
-// Insert executes a typed INSERT statement.
+// Insert executes a typed INSERT statement. With req.Returning, the
+// inserted rows are reported as insertReturning describes. RETURNING INTO
+// binds the values of a single row, so updates and deletes, which may
+// affect many, leave Returning empty.
 func (c *OracleConnector) Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
 	if c.readOnly {
 		return nil, fmt.Errorf("oracle: insert denied — connection is read-only")
@@ -123,9 +144,12 @@ func (c *OracleConnector) Insert(ctx context.Context, req connector.InsertReques
 	if len(req.Rows) == 0 {
 		return &connector.MutationResult{RowsAffected: 0}, nil
 	}
+	if req.Returning {
+		return c.insertReturning(ctx, req)
+	}
 
 	query, args := c.qb.BuildInsert(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("oracle: insert failed: %w", err)
 	}
@@ -134,6 +158,66 @@ func (c *OracleConnector) Insert(ctx context.Context, req connector.InsertReques
 	return &connector.MutationResult{RowsAffected: affected}, nil
 }
 
+// insertReturning inserts the rows of req one at a time, since INSERT ALL
+// takes no RETURNING clause, collecting the ROWID of each with RETURNING
+// INTO, and then selects the inserted rows by ROWID. The inserts run in one
+// transaction, as a single INSERT ALL would.
+func (c *OracleConnector) insertReturning(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
+	if _, ok := connector.Conn(ctx, c.db).(*sql.Tx); !ok {
+		tx, err := c.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		res, err := c.insertReturning(connector.WithTx(ctx, tx), req)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("oracle: failed to commit insert: %w", err)
+		}
+		return res, nil
+	}
+
+	conn := connector.Conn(ctx, c.db)
+	rowids := make([]any, len(req.Rows))
+	for i, row := range req.Rows {
+		query, args := c.qb.BuildInsert(connector.InsertRequest{Table: req.Table, Rows: []map[string]any{row}})
+		query += fmt.Sprintf(" RETURNING ROWIDTOCHAR(ROWID) INTO :%d", len(args)+1)
+		var rowid string
+		if _, err := conn.ExecContext(ctx, query, append(args, go_ora.Out{Dest: &rowid, Size: 64})...); err != nil {
+			return nil, fmt.Errorf("oracle: insert failed: %w", err)
+		}
+		rowids[i] = rowid
+	}
+
+	res := &connector.MutationResult{RowsAffected: int64(len(rowids))}
+	for start := 0; start < len(rowids) && !res.ReturningTruncated; start += maxInListSize {
+		rs, err := c.selectByRowID(ctx, req.Table, rowids[start:min(start+maxInListSize, len(rowids))])
+		if err != nil {
+			return nil, err
+		}
+		res.Returning = append(res.Returning, rs.Rows...)
+		res.ReturningTruncated = rs.SizeLimited
+	}
+	return res, nil
+}
+
+// maxInListSize is the most expressions Oracle allows in an IN list.
+const maxInListSize = 1000
+
+// selectByRowID selects the rows of table with the given ROWIDs.
+func (c *OracleConnector) selectByRowID(ctx context.Context, table string, rowids []any) (*connector.ResultSet, error) {
+	query, args := c.qb.BuildSelectByRowID(table, rowids)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: failed to select inserted rows: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(ctx, rows, 0)
+}
+
 // Update executes a typed UPDATE statement.
 func (c *OracleConnector) Update(ctx context.Context, req connector.UpdateRequest) (*connector.MutationResult, error) {
 	if c.readOnly {
@@ -144,7 +228,7 @@ func (c *OracleConnector) Update(ctx context.Context, req connector.UpdateReques
 	}
 
 	query, args := c.qb.BuildUpdate(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("oracle: update failed: %w", err)
 	}
@@ -163,7 +247,7 @@ func (c *OracleConnector) Delete(ctx context.Context, req connector.DeleteReques
 	}
 
 	query, args := c.qb.BuildDelete(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("oracle: delete failed: %w", err)
 	}
@@ -172,6 +256,47 @@ func (c *OracleConnector) Delete(ctx context.Context, req connector.DeleteReques
 	return &connector.MutationResult{RowsAffected: affected}, nil
 }
 
+// Upsert executes a typed MERGE statement. With req.Returning, the written
+// rows are selected by their conflict columns afterwards.
+func (c *OracleConnector) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("oracle: upsert denied — connection is read-only")
+	}
+	if len(req.Rows) == 0 {
+		return &connector.MutationResult{RowsAffected: 0}, nil
+	}
+
+	query, args := c.qb.BuildUpsert(req)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: upsert failed: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	res := &connector.MutationResult{RowsAffected: affected}
+	if !req.Returning {
+		return res, nil
+	}
+	// The rows are written by now, so a failed lookup leaves Returning
+	// empty rather than failing the upsert.
+	var written []map[string]any
+	for start := 0; start < len(req.Rows); start += maxInListSize {
+		chunk := req
+		chunk.Rows = req.Rows[start:min(start+maxInListSize, len(req.Rows))]
+		rs, err := c.Select(ctx, chunk.KeySelect())
+		if err != nil {
+			return res, nil
+		}
+		written = append(written, rs.Rows...)
+		if rs.SizeLimited {
+			res.ReturningTruncated = true
+			break
+		}
+	}
+	res.Returning = written
+	return res, nil
+}
+
 // CallProcedure executes a stored procedure using a PL/SQL anonymous block.
 func (c *OracleConnector) CallProcedure(ctx context.Context, req connector.ProcedureCallRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildProcedureCall(req)
@@ -181,49 +306,75 @@ func (c *OracleConnector) CallProcedure(ctx context.Context, req connector.Proce
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
 }
 
-// scanRows converts *sql.Rows into a ResultSet.
-func scanRows(rows *sql.Rows) (*connector.ResultSet, error) {
-	cols, err := rows.Columns()
+// QueryRaw executes a caller-written query in a read-only transaction.
+func (c *OracleConnector) QueryRaw(ctx context.Context, req connector.RawQueryRequest) (*connector.ResultSet, error) {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	// go-ora rejects sql.TxOptions.ReadOnly; SET TRANSACTION must be the
+	// first statement of the transaction instead.
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("oracle: failed to get columns: %w", err)
+		return nil, fmt.Errorf("oracle: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return nil, fmt.Errorf("oracle: failed to begin read-only transaction: %w", err)
 	}
 
-	result := &connector.ResultSet{
-		Columns: cols,
-		Rows:    make([]map[string]any, 0),
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: raw query failed: %w", err)
 	}
+	defer rows.Close()
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, fmt.Errorf("oracle: scan failed: %w", err)
-		}
+	return scanRows(ctx, rows, req.MaxRows)
+}
 
-		row := make(map[string]any, len(cols))
-		for i, col := range cols {
-			val := values[i]
-			// Convert []byte to string for JSON serialization friendliness.
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		result.Rows = append(result.Rows, row)
+// ExecRaw executes a caller-written statement.
+func (c *OracleConnector) ExecRaw(ctx context.Context, query string) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("oracle: execute denied — connection is read-only")
 	}
+	result, err := c.db.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: execute failed: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: affected}, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("oracle: row iteration failed: %w", err)
+// Begin starts a read-write transaction.
+func (c *OracleConnector) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: failed to begin transaction: %w", err)
 	}
+	return tx, nil
+}
 
-	return result, nil
+// Savepoint runs fn under a savepoint of the transaction carried by ctx, so
+// that a failure undoes all fn did. Oracle cannot release a savepoint; it
+// lasts until the transaction ends.
+func (c *OracleConnector) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return connector.RunSavepoint(ctx,
+		"SAVEPOINT conduit_write", "ROLLBACK TO SAVEPOINT conduit_write", "", fn)
+}
+
+// scanRows reads rows into a ResultSet within the scan limits carried by
+// ctx, stopping after maxRows rows when maxRows is positive.
+func scanRows(ctx context.Context, rows *sql.Rows, maxRows int) (*connector.ResultSet, error) {
+	rs, err := connector.ScanRows(ctx, rows, maxRows, connector.BytesToString)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: %w", err)
+	}
+	return rs, nil
 }
 
 // ownerFilter returns the schema owner(s) to use for introspection.
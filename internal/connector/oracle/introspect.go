@@ -134,6 +134,13 @@ func (c *OracleConnector) DescribeTable(ctx context.Context, tableName string) (
 	}
 	detail.Indexes = indexes
 
+	// Fetch unique keys.
+	uniqueKeys, err := c.describeUniqueKeys(ctx, ownerName, tblName)
+	if err != nil {
+		return nil, err
+	}
+	detail.UniqueKeys = uniqueKeys
+
 	return detail, nil
 }
 
@@ -284,6 +291,44 @@ func (c *OracleConnector) describeIndexes(ctx context.Context, owner, tableName
 	return indexes, rows.Err()
 }
 
+// describeUniqueKeys returns the unique indexes of a table other than its
+// primary key, with their columns in key order.
+// Function-based indexes are left out, since they do not hold plain
+// columns unique.
+func (c *OracleConnector) describeUniqueKeys(ctx context.Context, owner, tableName string) ([]schema.UniqueKey, error) {
+	query := `
+		SELECT ic.INDEX_NAME, ic.COLUMN_NAME
+		FROM ALL_INDEXES i
+		JOIN ALL_IND_COLUMNS ic
+			ON ic.INDEX_OWNER = i.OWNER AND ic.INDEX_NAME = i.INDEX_NAME
+		WHERE i.TABLE_OWNER = :1 AND i.TABLE_NAME = :2
+			AND i.UNIQUENESS = 'UNIQUE'
+			AND i.INDEX_TYPE = 'NORMAL'
+			AND NOT EXISTS (
+				SELECT 1 FROM ALL_CONSTRAINTS c
+				WHERE c.OWNER = i.TABLE_OWNER AND c.TABLE_NAME = i.TABLE_NAME
+					AND c.CONSTRAINT_TYPE = 'P' AND c.INDEX_NAME = i.INDEX_NAME
+			)
+		ORDER BY ic.INDEX_NAME, ic.COLUMN_POSITION
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, owner, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: describe unique keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []schema.UniqueKey
+	for rows.Next() {
+		var name, col string
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, fmt.Errorf("oracle: scan unique key: %w", err)
+		}
+		keys = schema.AppendUniqueColumn(keys, name, col)
+	}
+	return keys, rows.Err()
+}
+
 // ListProcedures returns stored procedures and functions from ALL_PROCEDURES.
 func (c *OracleConnector) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
 	ownerPlaceholders, ownerArgs := c.ownerPlaceholders(1)
@@ -51,16 +51,18 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	sb.WriteString(" FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
 
-	// WHERE (pass-through filter — the filter parser upstream handles safety)
-	if req.Filter != "" {
+	// WHERE (compiled filter; values are bound before LIMIT/OFFSET params)
+	if where := req.Condition(); !where.IsEmpty() {
+		clause, params := where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
 	}
 
 	// ORDER BY
-	if req.OrderBy != "" {
+	if orderBy := req.OrderByClause(qb.QuoteIdentifier); orderBy != "" {
 		sb.WriteString(" ORDER BY ")
-		sb.WriteString(req.OrderBy)
+		sb.WriteString(orderBy)
 	}
 
 	// OFFSET / FETCH FIRST (Oracle 12c+ row-limiting clause)
@@ -77,6 +79,54 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	return sb.String(), args
 }
 
+// BuildAggregate builds a grouped aggregate query from an AggregateRequest.
+// HAVING conditions on aliases are rendered as the aggregate expression,
+// since Oracle does not accept column aliases in HAVING.
+func (qb *QueryBuilder) BuildAggregate(req connector.AggregateRequest) (string, []any) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("SELECT ")
+	sb.WriteString(req.SelectList(qb.QuoteIdentifier, qb.aggregateExpr))
+	sb.WriteString(" FROM ")
+	sb.WriteString(qb.QuoteIdentifier(req.Table))
+
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" WHERE ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	if len(req.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(req.GroupByList(qb.QuoteIdentifier))
+	}
+
+	if !req.Having.IsEmpty() {
+		clause, params := req.Having.Render(req.HavingColumn(qb.QuoteIdentifier, qb.aggregateExpr), qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" HAVING ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	if req.OrderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(req.OrderByClause(qb.QuoteIdentifier))
+	}
+
+	if req.Limit > 0 {
+		args = append(args, req.Limit)
+		sb.WriteString(fmt.Sprintf(" FETCH FIRST :%d ROWS ONLY", len(args)))
+	}
+
+	return sb.String(), args
+}
+
+func (qb *QueryBuilder) aggregateExpr(a connector.Aggregate) string {
+	return a.SQL(qb.QuoteIdentifier)
+}
+
 // BuildInsert builds an INSERT statement for one or more rows.
 // For a single row, uses a standard INSERT INTO ... VALUES (...).
 // For multiple rows, uses INSERT ALL ... SELECT FROM DUAL (Oracle multi-row syntax).
@@ -164,8 +214,99 @@ func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any)
 	return sb.String(), args
 }
 
+// BuildSelectByRowID builds a SELECT of the rows of table with the given
+// ROWIDs, as returned by ROWIDTOCHAR.
+func (qb *QueryBuilder) BuildSelectByRowID(table string, rowids []any) (string, []any) {
+	var sb strings.Builder
+
+	sb.WriteString("SELECT * FROM ")
+	sb.WriteString(qb.QuoteIdentifier(table))
+	sb.WriteString(" WHERE ROWID IN (")
+	for i := range rowids {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("CHARTOROWID(:%d)", i+1))
+	}
+	sb.WriteString(")")
+
+	return sb.String(), rowids
+}
+
+// BuildUpsert builds a MERGE statement that inserts the rows, or updates the
+// other columns of the rows they conflict with. The rows are selected from
+// DUAL, since Oracle has no VALUES table constructor.
+func (qb *QueryBuilder) BuildUpsert(req connector.UpsertRequest) (string, []any) {
+	if len(req.Rows) == 0 {
+		return "", nil
+	}
+	cols := req.Columns()
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = qb.QuoteIdentifier(col)
+	}
+
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("MERGE INTO ")
+	sb.WriteString(qb.QuoteIdentifier(req.Table))
+	sb.WriteString(" target USING (")
+	for rowIdx, row := range req.Rows {
+		if rowIdx > 0 {
+			sb.WriteString(" UNION ALL ")
+		}
+		sb.WriteString("SELECT ")
+		for colIdx, col := range cols {
+			if colIdx > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, row[col])
+			sb.WriteString(fmt.Sprintf(":%d", len(args)))
+			if rowIdx == 0 {
+				// The first SELECT names the source columns.
+				sb.WriteString(" AS " + quoted[colIdx])
+			}
+		}
+		sb.WriteString(" FROM DUAL")
+	}
+	sb.WriteString(") source ON (")
+	for i, col := range req.ConflictColumns {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		q := qb.QuoteIdentifier(col)
+		sb.WriteString("target." + q + " = source." + q)
+	}
+	sb.WriteString(")")
+
+	if update := req.UpdateColumns(); len(update) > 0 {
+		sb.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		for i, col := range update {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			q := qb.QuoteIdentifier(col)
+			sb.WriteString("target." + q + " = source." + q)
+		}
+	}
+
+	sb.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	sb.WriteString(strings.Join(quoted, ", "))
+	sb.WriteString(") VALUES (")
+	for i, q := range quoted {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("source." + q)
+	}
+	sb.WriteString(")")
+
+	return sb.String(), args
+}
+
 // BuildUpdate builds an UPDATE statement.
-// SET columns are sorted deterministically. Filter is passed through.
+// SET columns are sorted deterministically. WHERE params follow the SET params.
 func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any) {
 	cols := make([]string, 0, len(req.Set))
 	for col := range req.Set {
@@ -188,27 +329,30 @@ func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any)
 		sb.WriteString(fmt.Sprintf("%s = :%d", qb.QuoteIdentifier(col), len(args)))
 	}
 
-	if req.Filter != "" {
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
 	}
 
 	return sb.String(), args
 }
 
-// BuildDelete builds a DELETE statement. Filter is required at the caller level.
+// BuildDelete builds a DELETE statement. A filter is required at the caller level.
 func (qb *QueryBuilder) BuildDelete(req connector.DeleteRequest) (string, []any) {
 	var sb strings.Builder
 
 	sb.WriteString("DELETE FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
 
-	if req.Filter != "" {
+	clause, args := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, 1)
+	if clause != "" {
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
 	}
 
-	return sb.String(), nil
+	return sb.String(), args
 }
 
 // BuildProcedureCall builds a PL/SQL anonymous block to call a stored procedure.
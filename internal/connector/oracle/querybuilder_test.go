@@ -1,6 +1,7 @@
 package oracle
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -85,16 +86,19 @@ func TestBuildSelect(t *testing.T) {
 
 	t.Run("select with filter", func(t *testing.T) {
 		req := connector.SelectRequest{
-			Table:  "ORDERS",
-			Filter: "STATUS = 'ACTIVE'",
+			Table: "ORDERS",
+			Where: connector.Eq([]string{"STATUS"}, []any{"ACTIVE"}),
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM "ORDERS" WHERE STATUS = 'ACTIVE'`
+		wantQuery := `SELECT * FROM "ORDERS" WHERE "STATUS" = :1`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != "ACTIVE" {
+			t.Errorf("args[0] = %v, want %q", args[0], "ACTIVE")
 		}
 	})
 
@@ -134,24 +138,27 @@ func TestBuildSelect(t *testing.T) {
 	t.Run("select with offset and limit", func(t *testing.T) {
 		req := connector.SelectRequest{
 			Table:   "ORDERS",
-			Filter:  "STATUS = 'ACTIVE'",
+			Where:   connector.Eq([]string{"STATUS"}, []any{"ACTIVE"}),
 			OrderBy: "CREATED_AT DESC",
 			Limit:   50,
 			Offset:  10,
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM "ORDERS" WHERE STATUS = 'ACTIVE' ORDER BY CREATED_AT DESC OFFSET :1 ROWS FETCH FIRST :2 ROWS ONLY`
+		wantQuery := `SELECT * FROM "ORDERS" WHERE "STATUS" = :1 ORDER BY CREATED_AT DESC OFFSET :2 ROWS FETCH FIRST :3 ROWS ONLY`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
 		}
-		if args[0] != 10 {
-			t.Errorf("args[0] = %v, want 10", args[0])
+		if args[0] != "ACTIVE" {
+			t.Errorf("args[0] = %v, want %q", args[0], "ACTIVE")
 		}
-		if args[1] != 50 {
-			t.Errorf("args[1] = %v, want 50", args[1])
+		if args[1] != 10 {
+			t.Errorf("args[1] = %v, want 10", args[1])
+		}
+		if args[2] != 50 {
+			t.Errorf("args[2] = %v, want 50", args[2])
 		}
 	})
 
@@ -172,6 +179,27 @@ func TestBuildSelect(t *testing.T) {
 			t.Errorf("args[0] = %v, want 5", args[0])
 		}
 	})
+
+	t.Run("keyset page replaces order by", func(t *testing.T) {
+		req := connector.SelectRequest{
+			Table:   "orders",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
+			OrderBy: "created_at DESC",
+			Limit:   21,
+			Keyset: &connector.Keyset{
+				Columns: []connector.SortKey{{Column: "created_at", Desc: true}, {Column: "id"}},
+				After:   []any{"2025-01-01", 7},
+			},
+		}
+		query, args := qb.BuildSelect(req)
+		wantQuery := `SELECT * FROM "orders" WHERE ("status" = :1) AND (("created_at" < :2) OR ("created_at" = :3 AND "id" > :4)) ORDER BY "created_at" DESC, "id" FETCH FIRST :5 ROWS ONLY`
+		if query != wantQuery {
+			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
+		}
+		if want := []any{"active", "2025-01-01", "2025-01-01", 7, 21}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
+		}
+	})
 }
 
 func TestBuildInsert(t *testing.T) {
@@ -292,23 +320,39 @@ func TestBuildInsert(t *testing.T) {
 	})
 }
 
+func TestBuildSelectByRowID(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	query, args := qb.BuildSelectByRowID("USERS", []any{"AAAR3sAAEAAAACXAAA", "AAAR3sAAEAAAACXAAB"})
+	wantQuery := `SELECT * FROM "USERS" WHERE ROWID IN (CHARTOROWID(:1), CHARTOROWID(:2))`
+	if query != wantQuery {
+		t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
+	}
+	if len(args) != 2 || args[1] != "AAAR3sAAEAAAACXAAB" {
+		t.Errorf("args = %v", args)
+	}
+}
+
 func TestBuildUpdate(t *testing.T) {
 	qb := &QueryBuilder{}
 
 	t.Run("basic update", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "EMPLOYEES",
-			Set:    map[string]any{"EMAIL": "new@corp.com", "FIRST_NAME": "Bob"},
-			Filter: "EMPLOYEE_ID = 1",
+			Table: "EMPLOYEES",
+			Set:   map[string]any{"EMAIL": "new@corp.com", "FIRST_NAME": "Bob"},
+			Where: connector.Eq([]string{"EMPLOYEE_ID"}, []any{1}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns are sorted: EMAIL, FIRST_NAME
-		wantQuery := `UPDATE "EMPLOYEES" SET "EMAIL" = :1, "FIRST_NAME" = :2 WHERE EMPLOYEE_ID = 1`
+		wantQuery := `UPDATE "EMPLOYEES" SET "EMAIL" = :1, "FIRST_NAME" = :2 WHERE "EMPLOYEE_ID" = :3`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[2] != 1 {
+			t.Errorf("args[2] = %v, want 1", args[2])
 		}
 		if args[0] != "new@corp.com" {
 			t.Errorf("args[0] = %v, want %q", args[0], "new@corp.com")
@@ -320,18 +364,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("multiple columns sorted", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "EMPLOYEES",
-			Set:    map[string]any{"SALARY": 50000, "DEPARTMENT_ID": 10, "COMMISSION": 0.1},
-			Filter: "EMPLOYEE_ID = 42",
+			Table: "EMPLOYEES",
+			Set:   map[string]any{"SALARY": 50000, "DEPARTMENT_ID": 10, "COMMISSION": 0.1},
+			Where: connector.Eq([]string{"EMPLOYEE_ID"}, []any{42}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// Sorted: COMMISSION, DEPARTMENT_ID, SALARY
-		wantQuery := `UPDATE "EMPLOYEES" SET "COMMISSION" = :1, "DEPARTMENT_ID" = :2, "SALARY" = :3 WHERE EMPLOYEE_ID = 42`
+		wantQuery := `UPDATE "EMPLOYEES" SET "COMMISSION" = :1, "DEPARTMENT_ID" = :2, "SALARY" = :3 WHERE "EMPLOYEE_ID" = :4`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 3 {
-			t.Fatalf("got %d args, want 3", len(args))
+		if len(args) != 4 {
+			t.Fatalf("got %d args, want 4", len(args))
+		}
+		if args[3] != 42 {
+			t.Errorf("args[3] = %v, want 42", args[3])
 		}
 		if args[0] != 0.1 {
 			t.Errorf("args[0] = %v, want 0.1", args[0])
@@ -362,16 +409,19 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("delete with filter", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "EMPLOYEES",
-			Filter: "EMPLOYEE_ID = 1",
+			Table: "EMPLOYEES",
+			Where: connector.Eq([]string{"EMPLOYEE_ID"}, []any{1}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := `DELETE FROM "EMPLOYEES" WHERE EMPLOYEE_ID = 1`
+		wantQuery := `DELETE FROM "EMPLOYEES" WHERE "EMPLOYEE_ID" = :1`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != 1 {
+			t.Errorf("args[0] = %v, want 1", args[0])
 		}
 	})
 
@@ -387,6 +437,94 @@ func TestBuildDelete(t *testing.T) {
 	})
 }
 
+func TestBuildUpsert(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		req       connector.UpsertRequest
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			"update other columns",
+			connector.UpsertRequest{
+				Table:           "USERS",
+				Rows:            []map[string]any{{"ID": 1, "NAME": "Ann"}, {"ID": 2, "NAME": "Bob"}},
+				ConflictColumns: []string{"ID"},
+			},
+			`MERGE INTO "USERS" target USING (SELECT :1 AS "ID", :2 AS "NAME" FROM DUAL UNION ALL SELECT :3, :4 FROM DUAL) source ` +
+				`ON (target."ID" = source."ID") WHEN MATCHED THEN UPDATE SET target."NAME" = source."NAME" ` +
+				`WHEN NOT MATCHED THEN INSERT ("ID", "NAME") VALUES (source."ID", source."NAME")`,
+			[]any{1, "Ann", 2, "Bob"},
+		},
+		{
+			"key columns only",
+			connector.UpsertRequest{
+				Table:           "TAGS",
+				Rows:            []map[string]any{{"SLUG": "go"}},
+				ConflictColumns: []string{"SLUG"},
+			},
+			`MERGE INTO "TAGS" target USING (SELECT :1 AS "SLUG" FROM DUAL) source ` +
+				`ON (target."SLUG" = source."SLUG") WHEN NOT MATCHED THEN INSERT ("SLUG") VALUES (source."SLUG")`,
+			[]any{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := qb.BuildUpsert(tt.req)
+			if query != tt.wantQuery {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildAggregate(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	t.Run("group by with having", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:   "orders",
+			GroupBy: []string{"status"},
+			Aggregates: []connector.Aggregate{
+				{Func: connector.AggCount, Alias: "n"},
+				{Func: connector.AggAvg, Column: "total", Alias: "avg_total"},
+			},
+			Where:   connector.Eq([]string{"region"}, []any{"eu"}),
+			Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(5),
+			OrderBy: "n DESC",
+			Limit:   10,
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT "status", COUNT(*) AS "n", AVG("total") AS "avg_total" FROM "orders" WHERE "region" = :1 GROUP BY "status" HAVING COUNT(*) > :2 ORDER BY "n" DESC FETCH FIRST :3 ROWS ONLY`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 3 || args[0] != "eu" || args[1] != 5 || args[2] != 10 {
+			t.Errorf("args = %v, want [eu 5 10]", args)
+		}
+	})
+
+	t.Run("count only", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT COUNT(*) AS "count" FROM "orders"`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 0 {
+			t.Errorf("got %d args, want 0", len(args))
+		}
+	})
+}
+
 func TestBuildProcedureCall(t *testing.T) {
 	qb := &QueryBuilder{}
 
@@ -87,16 +87,34 @@ func (c *PostgresConnector) ParameterPlaceholder(index int) string {
 	return c.qb.ParameterPlaceholder(index)
 }
 
+// InsertBatchSize returns the most rows one INSERT can take, within the
+// 65535 bind parameters Postgres allows a statement.
+func (c *PostgresConnector) InsertBatchSize(columns int) int {
+	return connector.BatchSize(columns, 65535, 0)
+}
+
 // Select executes a typed SELECT query.
 func (c *PostgresConnector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildSelect(req)
-	rows, err := c.db.QueryContext(ctx, query, args...)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("postgres: select failed: %w", err)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
+}
+
+// Aggregate executes a grouped aggregate query.
+func (c *PostgresConnector) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	query, args := c.qb.BuildAggregate(req)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: aggregate failed: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(ctx, rows, 0)
 }
 
 // Insert executes a typed INSERT statement.
@@ -109,13 +127,11 @@ func (c *PostgresConnector) Insert(ctx context.Context, req connector.InsertRequ
 	}
 
 	query, args := c.qb.BuildInsert(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	res, err := c.mutate(ctx, query, args, req.Returning)
 	if err != nil {
 		return nil, fmt.Errorf("postgres: insert failed: %w", err)
 	}
-
-	affected, _ := result.RowsAffected()
-	return &connector.MutationResult{RowsAffected: affected}, nil
+	return res, nil
 }
 
 // Update executes a typed UPDATE statement.
@@ -128,13 +144,11 @@ func (c *PostgresConnector) Update(ctx context.Context, req connector.UpdateRequ
 	}
 
 	query, args := c.qb.BuildUpdate(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	res, err := c.mutate(ctx, query, args, req.Returning)
 	if err != nil {
 		return nil, fmt.Errorf("postgres: update failed: %w", err)
 	}
-
-	affected, _ := result.RowsAffected()
-	return &connector.MutationResult{RowsAffected: affected}, nil
+	return res, nil
 }
 
 // Delete executes a typed DELETE statement.
@@ -147,11 +161,46 @@ func (c *PostgresConnector) Delete(ctx context.Context, req connector.DeleteRequ
 	}
 
 	query, args := c.qb.BuildDelete(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	res, err := c.mutate(ctx, query, args, req.Returning)
 	if err != nil {
 		return nil, fmt.Errorf("postgres: delete failed: %w", err)
 	}
+	return res, nil
+}
+
+// Upsert executes a typed INSERT ... ON CONFLICT statement.
+func (c *PostgresConnector) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("postgres: upsert denied — connection is read-only")
+	}
+	if len(req.Rows) == 0 {
+		return &connector.MutationResult{RowsAffected: 0}, nil
+	}
+
+	query, args := c.qb.BuildUpsert(req)
+	res, err := c.mutate(ctx, query, args, req.Returning)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: upsert failed: %w", err)
+	}
+	return res, nil
+}
 
+// mutate runs an INSERT, UPDATE, or DELETE statement, reading the rows it
+// reports when returning is set.
+func (c *PostgresConnector) mutate(ctx context.Context, query string, args []any, returning bool) (*connector.MutationResult, error) {
+	if returning {
+		rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		return connector.ScanReturning(ctx, rows, connector.BytesToString)
+	}
+
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	affected, _ := result.RowsAffected()
 	return &connector.MutationResult{RowsAffected: affected}, nil
 }
@@ -165,49 +214,70 @@ func (c *PostgresConnector) CallProcedure(ctx context.Context, req connector.Pro
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
 }
 
-// scanRows converts *sql.Rows into a ResultSet.
-func scanRows(rows *sql.Rows) (*connector.ResultSet, error) {
-	cols, err := rows.Columns()
+// QueryRaw executes a caller-written query in a read-only transaction.
+func (c *PostgresConnector) QueryRaw(ctx context.Context, req connector.RawQueryRequest) (*connector.ResultSet, error) {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	tx, err := c.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		return nil, fmt.Errorf("postgres: failed to get columns: %w", err)
+		return nil, fmt.Errorf("postgres: failed to begin read-only transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	result := &connector.ResultSet{
-		Columns: cols,
-		Rows:    make([]map[string]any, 0),
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: raw query failed: %w", err)
 	}
+	defer rows.Close()
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, fmt.Errorf("postgres: scan failed: %w", err)
-		}
+	return scanRows(ctx, rows, req.MaxRows)
+}
 
-		row := make(map[string]any, len(cols))
-		for i, col := range cols {
-			val := values[i]
-			// Convert []byte to string for JSON serialization friendliness.
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		result.Rows = append(result.Rows, row)
+// ExecRaw executes a caller-written statement.
+func (c *PostgresConnector) ExecRaw(ctx context.Context, query string) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("postgres: execute denied — connection is read-only")
 	}
+	result, err := c.db.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: execute failed: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: affected}, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("postgres: row iteration failed: %w", err)
+// Begin starts a read-write transaction.
+func (c *PostgresConnector) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to begin transaction: %w", err)
 	}
+	return tx, nil
+}
+
+// Savepoint runs fn under a savepoint of the transaction carried by ctx.
+// Postgres aborts a transaction once one of its statements fails, so a
+// statement that may fail must run under one for the transaction to go on.
+func (c *PostgresConnector) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return connector.RunSavepoint(ctx,
+		"SAVEPOINT conduit_write", "ROLLBACK TO SAVEPOINT conduit_write", "RELEASE SAVEPOINT conduit_write", fn)
+}
 
-	return result, nil
+// scanRows reads rows into a ResultSet within the scan limits carried by
+// ctx, stopping after maxRows rows when maxRows is positive.
+func scanRows(ctx context.Context, rows *sql.Rows, maxRows int) (*connector.ResultSet, error) {
+	rs, err := connector.ScanRows(ctx, rows, maxRows, connector.BytesToString)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	return rs, nil
 }
 
 // schemaFilter returns the list of schemas to include in introspection.
@@ -132,6 +132,13 @@ func (c *PostgresConnector) DescribeTable(ctx context.Context, tableName string)
 	}
 	detail.Indexes = indexes
 
+	// Fetch unique keys.
+	uniqueKeys, err := c.describeUniqueKeys(ctx, schemaName, tblName)
+	if err != nil {
+		return nil, err
+	}
+	detail.UniqueKeys = uniqueKeys
+
 	return detail, nil
 }
 
@@ -273,6 +280,42 @@ func (c *PostgresConnector) describeIndexes(ctx context.Context, schemaName, tab
 	return indexes, rows.Err()
 }
 
+// describeUniqueKeys returns the unique indexes of a table other than its
+// primary key, with their columns in key order.
+// Partial and expression indexes are left out, since a conflict target of
+// plain columns cannot name them.
+func (c *PostgresConnector) describeUniqueKeys(ctx context.Context, schemaName, tableName string) ([]schema.UniqueKey, error) {
+	query := `
+		SELECT i.relname, a.attname
+		FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN LATERAL unnest(x.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = $1 AND t.relname = $2
+			AND x.indisunique AND NOT x.indisprimary
+			AND x.indpred IS NULL AND x.indexprs IS NULL
+		ORDER BY i.relname, k.ord
+	`
+
+	rows, err := c.db.QueryContext(ctx, query, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: describe unique keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []schema.UniqueKey
+	for rows.Next() {
+		var name, col string
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, fmt.Errorf("postgres: scan unique key: %w", err)
+		}
+		keys = schema.AppendUniqueColumn(keys, name, col)
+	}
+	return keys, rows.Err()
+}
+
 // ListProcedures returns stored procedures and functions from pg_proc.
 func (c *PostgresConnector) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
 	schemaPlaceholders, schemaArgs := c.schemaPlaceholders(1)
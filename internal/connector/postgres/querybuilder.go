@@ -50,16 +50,18 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	sb.WriteString(" FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
 
-	// WHERE (pass-through filter — the filter parser upstream handles safety)
-	if req.Filter != "" {
+	// WHERE (compiled filter; values are bound before LIMIT/OFFSET params)
+	if where := req.Condition(); !where.IsEmpty() {
+		clause, params := where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
 	}
 
 	// ORDER BY
-	if req.OrderBy != "" {
+	if orderBy := req.OrderByClause(qb.QuoteIdentifier); orderBy != "" {
 		sb.WriteString(" ORDER BY ")
-		sb.WriteString(req.OrderBy)
+		sb.WriteString(orderBy)
 	}
 
 	// LIMIT
@@ -77,9 +79,58 @@ func (qb *QueryBuilder) BuildSelect(req connector.SelectRequest) (string, []any)
 	return sb.String(), args
 }
 
+// BuildAggregate builds a grouped aggregate query from an AggregateRequest.
+// HAVING conditions on aliases are rendered as the aggregate expression,
+// since PostgreSQL does not accept output column names in HAVING.
+func (qb *QueryBuilder) BuildAggregate(req connector.AggregateRequest) (string, []any) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString("SELECT ")
+	sb.WriteString(req.SelectList(qb.QuoteIdentifier, qb.aggregateExpr))
+	sb.WriteString(" FROM ")
+	sb.WriteString(qb.QuoteIdentifier(req.Table))
+
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" WHERE ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	if len(req.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(req.GroupByList(qb.QuoteIdentifier))
+	}
+
+	if !req.Having.IsEmpty() {
+		clause, params := req.Having.Render(req.HavingColumn(qb.QuoteIdentifier, qb.aggregateExpr), qb.ParameterPlaceholder, len(args)+1)
+		sb.WriteString(" HAVING ")
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+
+	if req.OrderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(req.OrderByClause(qb.QuoteIdentifier))
+	}
+
+	if req.Limit > 0 {
+		args = append(args, req.Limit)
+		sb.WriteString(fmt.Sprintf(" LIMIT $%d", len(args)))
+	}
+
+	return sb.String(), args
+}
+
+func (qb *QueryBuilder) aggregateExpr(a connector.Aggregate) string {
+	return a.SQL(qb.QuoteIdentifier)
+}
+
 // BuildInsert builds an INSERT statement for one or more rows.
 // Uses a single multi-row VALUES clause for efficiency.
-// Column order is deterministic (sorted).
+// Column order is deterministic (sorted). With req.Returning, the
+// statement returns the inserted rows; likewise for updates and deletes.
 func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any) {
 	if len(req.Rows) == 0 {
 		return "", nil
@@ -130,12 +181,55 @@ func (qb *QueryBuilder) BuildInsert(req connector.InsertRequest) (string, []any)
 		}
 		sb.WriteString(")")
 	}
+	if req.Returning {
+		sb.WriteString(" RETURNING *")
+	}
+
+	return sb.String(), args
+}
+
+// BuildUpsert builds an INSERT ... ON CONFLICT statement that updates the
+// conflicting row's other columns from EXCLUDED, or leaves it alone if the
+// rows only set their conflict columns.
+func (qb *QueryBuilder) BuildUpsert(req connector.UpsertRequest) (string, []any) {
+	query, args := qb.BuildInsert(connector.InsertRequest{Table: req.Table, Rows: req.Rows})
+	if query == "" {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(query)
+	sb.WriteString(" ON CONFLICT (")
+	for i, col := range req.ConflictColumns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(qb.QuoteIdentifier(col))
+	}
+	sb.WriteString(")")
+
+	update := req.UpdateColumns()
+	if len(update) == 0 {
+		sb.WriteString(" DO NOTHING")
+	} else {
+		sb.WriteString(" DO UPDATE SET ")
+		for i, col := range update {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			quoted := qb.QuoteIdentifier(col)
+			sb.WriteString(quoted + " = EXCLUDED." + quoted)
+		}
+	}
+	if req.Returning {
+		sb.WriteString(" RETURNING *")
+	}
 
 	return sb.String(), args
 }
 
 // BuildUpdate builds an UPDATE statement.
-// SET columns are sorted deterministically. Filter is passed through.
+// SET columns are sorted deterministically. WHERE params follow the SET params.
 func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any) {
 	cols := make([]string, 0, len(req.Set))
 	for col := range req.Set {
@@ -158,27 +252,36 @@ func (qb *QueryBuilder) BuildUpdate(req connector.UpdateRequest) (string, []any)
 		sb.WriteString(fmt.Sprintf("%s = $%d", qb.QuoteIdentifier(col), len(args)))
 	}
 
-	if req.Filter != "" {
+	if !req.Where.IsEmpty() {
+		clause, params := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, len(args)+1)
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+		args = append(args, params...)
+	}
+	if req.Returning {
+		sb.WriteString(" RETURNING *")
 	}
 
 	return sb.String(), args
 }
 
-// BuildDelete builds a DELETE statement. Filter is required at the caller level.
+// BuildDelete builds a DELETE statement. A filter is required at the caller level.
 func (qb *QueryBuilder) BuildDelete(req connector.DeleteRequest) (string, []any) {
 	var sb strings.Builder
 
 	sb.WriteString("DELETE FROM ")
 	sb.WriteString(qb.QuoteIdentifier(req.Table))
 
-	if req.Filter != "" {
+	clause, args := req.Where.Render(qb.QuoteIdentifier, qb.ParameterPlaceholder, 1)
+	if clause != "" {
 		sb.WriteString(" WHERE ")
-		sb.WriteString(req.Filter)
+		sb.WriteString(clause)
+	}
+	if req.Returning {
+		sb.WriteString(" RETURNING *")
 	}
 
-	return sb.String(), nil
+	return sb.String(), args
 }
 
 // BuildProcedureCall builds a SELECT * FROM function_name($1, $2, ...) call.
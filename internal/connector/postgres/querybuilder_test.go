@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -87,24 +88,48 @@ func TestBuildSelect(t *testing.T) {
 	t.Run("select with filter and limit", func(t *testing.T) {
 		req := connector.SelectRequest{
 			Table:   "orders",
-			Filter:  "status = 'active'",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
 			OrderBy: "created_at DESC",
 			Limit:   50,
 			Offset:  10,
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM "orders" WHERE status = 'active' ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+		wantQuery := `SELECT * FROM "orders" WHERE "status" = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
+		}
+		if args[1] != 50 {
+			t.Errorf("args[1] = %v, want 50", args[1])
+		}
+		if args[2] != 10 {
+			t.Errorf("args[2] = %v, want 10", args[2])
+		}
+	})
+
+	t.Run("keyset page replaces order by", func(t *testing.T) {
+		req := connector.SelectRequest{
+			Table:   "orders",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
+			OrderBy: "created_at DESC",
+			Limit:   21,
+			Keyset: &connector.Keyset{
+				Columns: []connector.SortKey{{Column: "created_at", Desc: true}, {Column: "id"}},
+				After:   []any{"2025-01-01", 7},
+			},
 		}
-		if args[0] != 50 {
-			t.Errorf("args[0] = %v, want 50", args[0])
+		query, args := qb.BuildSelect(req)
+		wantQuery := `SELECT * FROM "orders" WHERE ("status" = $1) AND (("created_at" < $2) OR ("created_at" = $3 AND "id" > $4)) ORDER BY "created_at" DESC, "id" LIMIT $5`
+		if query != wantQuery {
+			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if args[1] != 10 {
-			t.Errorf("args[1] = %v, want 10", args[1])
+		if want := []any{"active", "2025-01-01", "2025-01-01", 7, 21}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
 		}
 	})
 }
@@ -193,18 +218,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("basic update", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "users",
-			Set:    map[string]any{"email": "new@b.com", "name": "Bob"},
-			Filter: "id = 1",
+			Table: "users",
+			Set:   map[string]any{"email": "new@b.com", "name": "Bob"},
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns are sorted: email, name
-		wantQuery := `UPDATE "users" SET "email" = $1, "name" = $2 WHERE id = 1`
+		wantQuery := `UPDATE "users" SET "email" = $1, "name" = $2 WHERE "id" = $3`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[2] != 1 {
+			t.Errorf("args[2] = %v, want 1", args[2])
 		}
 		if args[0] != "new@b.com" {
 			t.Errorf("args[0] = %v, want %q", args[0], "new@b.com")
@@ -217,16 +245,19 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("basic delete", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "users",
-			Filter: "id = 1",
+			Table: "users",
+			Where: connector.Eq([]string{"id"}, []any{1}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := `DELETE FROM "users" WHERE id = 1`
+		wantQuery := `DELETE FROM "users" WHERE "id" = $1`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != 1 {
+			t.Errorf("args[0] = %v, want 1", args[0])
 		}
 	})
 
@@ -242,6 +273,141 @@ func TestBuildDelete(t *testing.T) {
 	})
 }
 
+func TestBuildReturning(t *testing.T) {
+	qb := &QueryBuilder{}
+	where := connector.Eq([]string{"id"}, []any{1})
+
+	tests := []struct {
+		name  string
+		build func() (string, []any)
+		want  string
+	}{
+		{
+			"insert",
+			func() (string, []any) {
+				return qb.BuildInsert(connector.InsertRequest{
+					Table:     "users",
+					Rows:      []map[string]any{{"name": "Alice"}, {"name": "Bob"}},
+					Returning: true,
+				})
+			},
+			`INSERT INTO "users" ("name") VALUES ($1), ($2) RETURNING *`,
+		},
+		{
+			"update",
+			func() (string, []any) {
+				return qb.BuildUpdate(connector.UpdateRequest{
+					Table:     "users",
+					Set:       map[string]any{"name": "Carol"},
+					Where:     where,
+					Returning: true,
+				})
+			},
+			`UPDATE "users" SET "name" = $1 WHERE "id" = $2 RETURNING *`,
+		},
+		{
+			"delete",
+			func() (string, []any) {
+				return qb.BuildDelete(connector.DeleteRequest{Table: "users", Where: where, Returning: true})
+			},
+			`DELETE FROM "users" WHERE "id" = $1 RETURNING *`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if query, _ := tt.build(); query != tt.want {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		req       connector.UpsertRequest
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			"update other columns",
+			connector.UpsertRequest{
+				Table:           "users",
+				Rows:            []map[string]any{{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob"}},
+				ConflictColumns: []string{"id"},
+				Returning:       true,
+			},
+			`INSERT INTO "users" ("id", "name") VALUES ($1, $2), ($3, $4) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING *`,
+			[]any{1, "Ann", 2, "Bob"},
+		},
+		{
+			"key columns only",
+			connector.UpsertRequest{
+				Table:           "tags",
+				Rows:            []map[string]any{{"slug": "go"}},
+				ConflictColumns: []string{"slug"},
+			},
+			`INSERT INTO "tags" ("slug") VALUES ($1) ON CONFLICT ("slug") DO NOTHING`,
+			[]any{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := qb.BuildUpsert(tt.req)
+			if query != tt.wantQuery {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildAggregate(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	t.Run("group by with having", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:   "orders",
+			GroupBy: []string{"status"},
+			Aggregates: []connector.Aggregate{
+				{Func: connector.AggCount, Alias: "n"},
+				{Func: connector.AggAvg, Column: "total", Alias: "avg_total"},
+			},
+			Where:   connector.Eq([]string{"region"}, []any{"eu"}),
+			Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(5),
+			OrderBy: "n DESC",
+			Limit:   10,
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT "status", COUNT(*) AS "n", AVG("total") AS "avg_total" FROM "orders" WHERE "region" = $1 GROUP BY "status" HAVING COUNT(*) > $2 ORDER BY "n" DESC LIMIT $3`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 3 || args[0] != "eu" || args[1] != 5 || args[2] != 10 {
+			t.Errorf("args = %v, want [eu 5 10]", args)
+		}
+	})
+
+	t.Run("count only", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT COUNT(*) AS "count" FROM "orders"`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 0 {
+			t.Errorf("got %d args, want 0", len(args))
+		}
+	})
+}
+
 func TestBuildProcedureCall(t *testing.T) {
 	qb := &QueryBuilder{}
 
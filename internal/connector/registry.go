@@ -54,7 +54,17 @@ func ParseDSN(dsn string) (driver string, err error) {
 		return "", fmt.Errorf("invalid DSN: %w", err)
 	}
 	scheme := strings.ToLower(u.Scheme)
-	switch scheme {
+	driver, err = NormalizeDriver(scheme)
+	if err != nil {
+		return "", fmt.Errorf("unknown database scheme: %q (supported: postgres, mysql, sqlserver, sqlite, oracle, snowflake)", scheme)
+	}
+	return driver, nil
+}
+
+// NormalizeDriver maps a driver name or alias (e.g. "postgresql", "sqlserver")
+// to the canonical driver name used by the registry.
+func NormalizeDriver(name string) (string, error) {
+	switch strings.ToLower(name) {
 	case "postgres", "postgresql":
 		return "postgres", nil
 	case "mysql":
@@ -68,7 +78,7 @@ func ParseDSN(dsn string) (driver string, err error) {
 	case "snowflake":
 		return "snowflake", nil
 	default:
-		return "", fmt.Errorf("unknown database scheme: %q (supported: postgres, mysql, sqlserver, sqlite, oracle, snowflake)", scheme)
+		return "", fmt.Errorf("unknown database driver: %q (supported: postgres, mysql, mssql, sqlite, oracle, snowflake)", name)
 	}
 }
 
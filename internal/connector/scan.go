@@ -0,0 +1,212 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TruncationMarker is appended to string values cut short at the cell size
+// limit.
+const TruncationMarker = "…[truncated]"
+
+// ScanLimits bounds the result ScanRows materializes. Zero fields are
+// unlimited.
+type ScanLimits struct {
+	// MaxBytes caps the approximate JSON size of the whole result. Rows
+	// past it are left unread.
+	MaxBytes int64
+	// MaxCellBytes caps each string or binary value; longer values are
+	// cut to this size.
+	MaxCellBytes int
+}
+
+type scanLimitsKey struct{}
+
+// WithScanLimits returns a context whose queries scan their results within
+// limits.
+func WithScanLimits(ctx context.Context, limits ScanLimits) context.Context {
+	return context.WithValue(ctx, scanLimitsKey{}, limits)
+}
+
+// ScanLimitsFrom returns the scan limits carried by ctx, or no limits.
+func ScanLimitsFrom(ctx context.Context) ScanLimits {
+	limits, _ := ctx.Value(scanLimitsKey{}).(ScanLimits)
+	return limits
+}
+
+// ScanRows reads rows into a ResultSet within the scan limits carried by
+// ctx, stopping after maxRows rows when maxRows is positive. convert, if
+// non-nil, is applied to each scanned value first, e.g. to turn driver
+// []byte values into strings.
+//
+// Rows are read one at a time, and the scan stops before the row that would
+// take the result past ScanLimits.MaxBytes, so an oversized result is never
+// held in memory in full.
+func ScanRows(ctx context.Context, rows *sql.Rows, maxRows int, convert func(any) any) (*ResultSet, error) {
+	limits := ScanLimitsFrom(ctx)
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	rs := &ResultSet{Columns: cols, Rows: make([]map[string]any, 0)}
+
+	size := int64(len(`{"columns":[],"rows":[]}`))
+	for _, col := range cols {
+		size += int64(len(col) + 3)
+	}
+	cutValues := 0
+	for (maxRows <= 0 || len(rs.Rows) < maxRows) && rows.Next() {
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+
+		row := make(map[string]any, len(cols))
+		rowSize := int64(3)
+		rowCut := 0
+		for i, col := range cols {
+			val := values[i]
+			if convert != nil {
+				val = convert(val)
+			}
+			if cut, ok := truncateValue(val, limits.MaxCellBytes); ok {
+				val = cut
+				rowCut++
+			}
+			row[col] = val
+			rowSize += int64(len(col)+4) + valueSize(val)
+		}
+		if limits.MaxBytes > 0 && size+rowSize > limits.MaxBytes {
+			rs.SetTruncation(cutValues, true)
+			return rs, nil
+		}
+		size += rowSize
+		cutValues += rowCut
+		rs.Rows = append(rs.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+	rs.SetTruncation(cutValues, false)
+	return rs, nil
+}
+
+// ScanReturning reads the rows reported by an INSERT, UPDATE, or DELETE
+// into a MutationResult, as ScanRows does within the scan limits carried by
+// ctx. Every row counts toward RowsAffected, including the rows left out of
+// Returning at the result size limit.
+func ScanReturning(ctx context.Context, rows *sql.Rows, convert func(any) any) (*MutationResult, error) {
+	rs, err := ScanRows(ctx, rows, 0, convert)
+	if err != nil {
+		return nil, err
+	}
+	res := &MutationResult{
+		RowsAffected:       int64(len(rs.Rows)),
+		Returning:          rs.Rows,
+		ReturningTruncated: rs.SizeLimited,
+	}
+	if rs.SizeLimited {
+		res.RowsAffected++ // the row that did not fit
+	}
+	for rows.Next() {
+		res.RowsAffected++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration failed: %w", err)
+	}
+	return res, nil
+}
+
+// BytesToString converts []byte values to strings, which encode to JSON as
+// text rather than base64.
+func BytesToString(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// SetTruncation records that cutValues values were cut short and, if
+// sizeLimited, that rows were left out at the result size limit, and
+// explains both in Truncated and TruncatedReason.
+func (rs *ResultSet) SetTruncation(cutValues int, sizeLimited bool) {
+	rs.CutValues = cutValues
+	rs.SizeLimited = sizeLimited
+	var reasons []string
+	if sizeLimited {
+		reasons = append(reasons, "rows were left out at the result size limit; select fewer columns, filter further, or continue with next_cursor")
+	}
+	if cutValues > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d oversized values were cut short and end in %q", cutValues, TruncationMarker))
+	}
+	rs.Truncated = len(reasons) > 0
+	rs.TruncatedReason = strings.Join(reasons, "; ")
+}
+
+// truncateValue cuts a string or []byte longer than max bytes, reporting
+// whether it did. Strings are cut at a rune boundary and marked with
+// TruncationMarker.
+func truncateValue(v any, max int) (any, bool) {
+	if max <= 0 {
+		return v, false
+	}
+	switch v := v.(type) {
+	case string:
+		if len(v) <= max {
+			return v, false
+		}
+		end := max
+		for end > 0 && !utf8.RuneStart(v[end]) {
+			end--
+		}
+		return v[:end] + TruncationMarker, true
+	case []byte:
+		if len(v) <= max {
+			return v, false
+		}
+		return v[:max], true
+	}
+	return v, false
+}
+
+// IsTruncated reports whether v, scanned with a MaxCellBytes of max, may have
+// been cut short by truncateValue. A cut string is longer than max only by
+// its TruncationMarker, and a cut []byte is exactly max bytes long, which an
+// intact one may also be.
+func IsTruncated(v any, max int) bool {
+	if max <= 0 {
+		return false
+	}
+	switch v := v.(type) {
+	case string:
+		return len(v) > max && strings.HasSuffix(v, TruncationMarker)
+	case []byte:
+		return len(v) >= max
+	}
+	return false
+}
+
+// valueSize estimates the JSON-encoded size of a scanned value.
+func valueSize(v any) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return int64(len(v) + 2)
+	case []byte:
+		return int64((len(v)+2)/3*4 + 2) // base64
+	case bool:
+		return 5
+	case time.Time:
+		return 37
+	}
+	return int64(len(fmt.Sprint(v)))
+}
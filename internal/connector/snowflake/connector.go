@@ -105,19 +105,40 @@ func (c *SnowflakeConnector) ParameterPlaceholder(index int) string {
 	return c.qb.ParameterPlaceholder(index)
 }
 
+// InsertBatchSize returns the most rows one INSERT can take: the 16384 rows
+// Snowflake allows a VALUES list, whatever their columns.
+func (c *SnowflakeConnector) InsertBatchSize(columns int) int {
+	return 16384
+}
+
 // Select executes a typed SELECT query.
 func (c *SnowflakeConnector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildSelect(req)
-	rows, err := c.db.QueryContext(ctx, query, args...)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("snowflake: select failed: %w", err)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
+}
+
+// Aggregate executes a grouped aggregate query.
+func (c *SnowflakeConnector) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	query, args := c.qb.BuildAggregate(req)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: aggregate failed: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(ctx, rows, 0)
 }
 
-// Insert executes a typed INSERT statement.
+// Insert executes a typed INSERT statement. Snowflake has no RETURNING
+// clause and no reliable way to find the rows a write affected afterwards,
+// so it ignores req.Returning, as do Update and Delete, and reports the
+// affected row count only.
 func (c *SnowflakeConnector) Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
 	if c.readOnly {
 		return nil, fmt.Errorf("snowflake: insert denied — connection is read-only")
@@ -127,7 +148,7 @@ func (c *SnowflakeConnector) Insert(ctx context.Context, req connector.InsertReq
 	}
 
 	query, args := c.qb.BuildInsert(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("snowflake: insert failed: %w", err)
 	}
@@ -146,7 +167,7 @@ func (c *SnowflakeConnector) Update(ctx context.Context, req connector.UpdateReq
 	}
 
 	query, args := c.qb.BuildUpdate(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("snowflake: update failed: %w", err)
 	}
@@ -165,7 +186,7 @@ func (c *SnowflakeConnector) Delete(ctx context.Context, req connector.DeleteReq
 	}
 
 	query, args := c.qb.BuildDelete(req)
-	result, err := c.db.ExecContext(ctx, query, args...)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("snowflake: delete failed: %w", err)
 	}
@@ -174,6 +195,27 @@ func (c *SnowflakeConnector) Delete(ctx context.Context, req connector.DeleteReq
 	return &connector.MutationResult{RowsAffected: affected}, nil
 }
 
+// Upsert executes a typed MERGE statement. Snowflake does not enforce
+// primary and unique keys, so the rows are only matched on their conflict
+// columns as written. As with Insert, req.Returning is ignored.
+func (c *SnowflakeConnector) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("snowflake: upsert denied — connection is read-only")
+	}
+	if len(req.Rows) == 0 {
+		return &connector.MutationResult{RowsAffected: 0}, nil
+	}
+
+	query, args := c.qb.BuildUpsert(req)
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: upsert failed: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: affected}, nil
+}
+
 // CallProcedure executes a stored procedure using CALL syntax.
 func (c *SnowflakeConnector) CallProcedure(ctx context.Context, req connector.ProcedureCallRequest) (*connector.ResultSet, error) {
 	query, args := c.qb.BuildProcedureCall(req)
@@ -183,47 +225,69 @@ func (c *SnowflakeConnector) CallProcedure(ctx context.Context, req connector.Pr
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanRows(ctx, rows, 0)
 }
 
-// scanRows converts *sql.Rows into a ResultSet.
-func scanRows(rows *sql.Rows) (*connector.ResultSet, error) {
-	cols, err := rows.Columns()
+// QueryRaw executes a caller-written query in a transaction that is always rolled back.
+func (c *SnowflakeConnector) QueryRaw(ctx context.Context, req connector.RawQueryRequest) (*connector.ResultSet, error) {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	// Snowflake has no read-only transactions; rolling back discards any DML
+	// the statement makes (DDL commits implicitly and is not undone).
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("snowflake: failed to get columns: %w", err)
+		return nil, fmt.Errorf("snowflake: failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	result := &connector.ResultSet{
-		Columns: cols,
-		Rows:    make([]map[string]any, 0),
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: raw query failed: %w", err)
 	}
+	defer rows.Close()
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, fmt.Errorf("snowflake: scan failed: %w", err)
-		}
+	return scanRows(ctx, rows, req.MaxRows)
+}
 
-		row := make(map[string]any, len(cols))
-		for i, col := range cols {
-			val := values[i]
-			// Convert []byte to string for JSON serialization friendliness.
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		result.Rows = append(result.Rows, row)
+// ExecRaw executes a caller-written statement.
+func (c *SnowflakeConnector) ExecRaw(ctx context.Context, query string) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("snowflake: execute denied — connection is read-only")
+	}
+	result, err := c.db.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: execute failed: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("snowflake: row iteration failed: %w", err)
+	affected, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: affected}, nil
+}
+
+// Begin starts a read-write transaction.
+func (c *SnowflakeConnector) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: failed to begin transaction: %w", err)
 	}
+	return tx, nil
+}
 
-	return result, nil
+// Savepoint runs fn. Snowflake has no savepoints, but a failed statement
+// does not abort the transaction it runs in, and the writes of the query
+// engine that run under Savepoint are single statements.
+func (c *SnowflakeConnector) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// scanRows reads rows into a ResultSet within the scan limits carried by
+// ctx, stopping after maxRows rows when maxRows is positive.
+func scanRows(ctx context.Context, rows *sql.Rows, maxRows int) (*connector.ResultSet, error) {
+	rs, err := connector.ScanRows(ctx, rows, maxRows, connector.BytesToString)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake: %w", err)
+	}
+	return rs, nil
 }
@@ -100,6 +100,13 @@ func (c *SnowflakeConnector) DescribeTable(ctx context.Context, tableName string
 	}
 	detail.PrimaryKey = pkCols
 
+	// Fetch unique keys.
+	uniqueKeys, err := c.describeUniqueKeys(ctx, schemaName, tblName)
+	if err != nil {
+		return nil, err
+	}
+	detail.UniqueKeys = uniqueKeys
+
 	// Mark PK columns in the column list.
 	pkSet := make(map[string]bool, len(pkCols))
 	for _, pk := range pkCols {
@@ -206,6 +213,40 @@ func (c *SnowflakeConnector) describePrimaryKey(ctx context.Context, schemaName,
 	return cols, rows.Err()
 }
 
+// describeUniqueKeys returns the unique constraints of a table. Snowflake
+// does not enforce them, but MERGE matches rows on them all the same.
+func (c *SnowflakeConnector) describeUniqueKeys(ctx context.Context, schemaName, tableName string) ([]schema.UniqueKey, error) {
+	query := fmt.Sprintf(`
+		SELECT tc.CONSTRAINT_NAME, kcu.COLUMN_NAME
+		FROM %s.INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+		JOIN %s.INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+			ON tc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
+			AND tc.TABLE_SCHEMA = kcu.TABLE_SCHEMA
+			AND tc.TABLE_NAME = kcu.TABLE_NAME
+		WHERE tc.TABLE_SCHEMA = ?
+			AND tc.TABLE_NAME = ?
+			AND tc.CONSTRAINT_TYPE = 'UNIQUE'
+		ORDER BY tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+	`, c.quoteDB(), c.quoteDB())
+
+	rows, err := c.db.QueryContext(ctx, query, schemaName, tableName)
+	if err != nil {
+		// As for primary keys, treat missing constraint metadata as non-fatal.
+		return nil, nil
+	}
+	defer rows.Close()
+
+	var keys []schema.UniqueKey
+	for rows.Next() {
+		var name, col string
+		if err := rows.Scan(&name, &col); err != nil {
+			return nil, fmt.Errorf("snowflake: scan unique key: %w", err)
+		}
+		keys = schema.AppendUniqueColumn(keys, name, col)
+	}
+	return keys, rows.Err()
+}
+
 // describeForeignKeys returns all foreign key (imported key) relationships for a table.
 func (c *SnowflakeConnector) describeForeignKeys(ctx context.Context, schemaName, tableName string) ([]schema.FKInfo, error) {
 	query := fmt.Sprintf(`
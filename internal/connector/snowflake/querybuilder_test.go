@@ -1,6 +1,7 @@
 package snowflake
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -109,16 +110,19 @@ func TestBuildSelect(t *testing.T) {
 
 	t.Run("select with filter", func(t *testing.T) {
 		req := connector.SelectRequest{
-			Table:  "ORDERS",
-			Filter: "STATUS = 'active'",
+			Table: "ORDERS",
+			Where: connector.Eq([]string{"STATUS"}, []any{"active"}),
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM "ORDERS" WHERE STATUS = 'active'`
+		wantQuery := `SELECT * FROM "ORDERS" WHERE "STATUS" = ?`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
 		}
 	})
 
@@ -140,24 +144,27 @@ func TestBuildSelect(t *testing.T) {
 	t.Run("select with limit and offset", func(t *testing.T) {
 		req := connector.SelectRequest{
 			Table:   "ORDERS",
-			Filter:  "STATUS = 'active'",
+			Where:   connector.Eq([]string{"STATUS"}, []any{"active"}),
 			OrderBy: "CREATED_AT DESC",
 			Limit:   50,
 			Offset:  10,
 		}
 		query, args := qb.BuildSelect(req)
-		wantQuery := `SELECT * FROM "ORDERS" WHERE STATUS = 'active' ORDER BY CREATED_AT DESC LIMIT ? OFFSET ?`
+		wantQuery := `SELECT * FROM "ORDERS" WHERE "STATUS" = ? ORDER BY CREATED_AT DESC LIMIT ? OFFSET ?`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[0] != "active" {
+			t.Errorf("args[0] = %v, want %q", args[0], "active")
 		}
-		if args[0] != 50 {
-			t.Errorf("args[0] = %v, want 50", args[0])
+		if args[1] != 50 {
+			t.Errorf("args[1] = %v, want 50", args[1])
 		}
-		if args[1] != 10 {
-			t.Errorf("args[1] = %v, want 10", args[1])
+		if args[2] != 10 {
+			t.Errorf("args[2] = %v, want 10", args[2])
 		}
 	})
 
@@ -178,6 +185,27 @@ func TestBuildSelect(t *testing.T) {
 			t.Errorf("args[0] = %v, want 100", args[0])
 		}
 	})
+
+	t.Run("keyset page replaces order by", func(t *testing.T) {
+		req := connector.SelectRequest{
+			Table:   "orders",
+			Where:   connector.Eq([]string{"status"}, []any{"active"}),
+			OrderBy: "created_at DESC",
+			Limit:   21,
+			Keyset: &connector.Keyset{
+				Columns: []connector.SortKey{{Column: "created_at", Desc: true}, {Column: "id"}},
+				After:   []any{"2025-01-01", 7},
+			},
+		}
+		query, args := qb.BuildSelect(req)
+		wantQuery := `SELECT * FROM "orders" WHERE ("status" = ?) AND (("created_at" < ?) OR ("created_at" = ? AND "id" > ?)) ORDER BY "created_at" DESC, "id" LIMIT ?`
+		if query != wantQuery {
+			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
+		}
+		if want := []any{"active", "2025-01-01", "2025-01-01", 7, 21}; !reflect.DeepEqual(args, want) {
+			t.Errorf("got args %v, want %v", args, want)
+		}
+	})
 }
 
 func TestBuildInsert(t *testing.T) {
@@ -291,18 +319,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("basic update", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "USERS",
-			Set:    map[string]any{"EMAIL": "new@b.com", "NAME": "Bob"},
-			Filter: "ID = 1",
+			Table: "USERS",
+			Set:   map[string]any{"EMAIL": "new@b.com", "NAME": "Bob"},
+			Where: connector.Eq([]string{"ID"}, []any{1}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns are sorted: EMAIL, NAME
-		wantQuery := `UPDATE "USERS" SET "EMAIL" = ?, "NAME" = ? WHERE ID = 1`
+		wantQuery := `UPDATE "USERS" SET "EMAIL" = ?, "NAME" = ? WHERE "ID" = ?`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 2 {
-			t.Fatalf("got %d args, want 2", len(args))
+		if len(args) != 3 {
+			t.Fatalf("got %d args, want 3", len(args))
+		}
+		if args[2] != 1 {
+			t.Errorf("args[2] = %v, want 1", args[2])
 		}
 		if args[0] != "new@b.com" {
 			t.Errorf("args[0] = %v, want %q", args[0], "new@b.com")
@@ -314,18 +345,21 @@ func TestBuildUpdate(t *testing.T) {
 
 	t.Run("multiple columns sorted", func(t *testing.T) {
 		req := connector.UpdateRequest{
-			Table:  "PRODUCTS",
-			Set:    map[string]any{"PRICE": 9.99, "CATEGORY": "electronics", "ACTIVE": true},
-			Filter: "ID = 42",
+			Table: "PRODUCTS",
+			Set:   map[string]any{"PRICE": 9.99, "CATEGORY": "electronics", "ACTIVE": true},
+			Where: connector.Eq([]string{"ID"}, []any{42}),
 		}
 		query, args := qb.BuildUpdate(req)
 		// SET columns sorted: ACTIVE, CATEGORY, PRICE
-		wantQuery := `UPDATE "PRODUCTS" SET "ACTIVE" = ?, "CATEGORY" = ?, "PRICE" = ? WHERE ID = 42`
+		wantQuery := `UPDATE "PRODUCTS" SET "ACTIVE" = ?, "CATEGORY" = ?, "PRICE" = ? WHERE "ID" = ?`
 		if query != wantQuery {
 			t.Errorf("got query:\n  %q\nwant:\n  %q", query, wantQuery)
 		}
-		if len(args) != 3 {
-			t.Fatalf("got %d args, want 3", len(args))
+		if len(args) != 4 {
+			t.Fatalf("got %d args, want 4", len(args))
+		}
+		if args[3] != 42 {
+			t.Errorf("args[3] = %v, want 42", args[3])
 		}
 		if args[0] != true {
 			t.Errorf("args[0] = %v, want true", args[0])
@@ -359,16 +393,19 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("with filter", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "USERS",
-			Filter: "ID = 1",
+			Table: "USERS",
+			Where: connector.Eq([]string{"ID"}, []any{1}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := `DELETE FROM "USERS" WHERE ID = 1`
+		wantQuery := `DELETE FROM "USERS" WHERE "ID" = ?`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
-		if len(args) != 0 {
-			t.Errorf("got %d args, want 0", len(args))
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != 1 {
+			t.Errorf("args[0] = %v, want 1", args[0])
 		}
 	})
 
@@ -385,11 +422,102 @@ func TestBuildDelete(t *testing.T) {
 
 	t.Run("schema-qualified table", func(t *testing.T) {
 		req := connector.DeleteRequest{
-			Table:  "PUBLIC.ORDERS",
-			Filter: "STATUS = 'cancelled'",
+			Table: "PUBLIC.ORDERS",
+			Where: connector.Eq([]string{"STATUS"}, []any{"cancelled"}),
 		}
 		query, args := qb.BuildDelete(req)
-		wantQuery := `DELETE FROM "PUBLIC"."ORDERS" WHERE STATUS = 'cancelled'`
+		wantQuery := `DELETE FROM "PUBLIC"."ORDERS" WHERE "STATUS" = ?`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 1 {
+			t.Fatalf("got %d args, want 1", len(args))
+		}
+		if args[0] != "cancelled" {
+			t.Errorf("args[0] = %v, want %q", args[0], "cancelled")
+		}
+	})
+}
+
+func TestBuildUpsert(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	tests := []struct {
+		name      string
+		req       connector.UpsertRequest
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			"update other columns",
+			connector.UpsertRequest{
+				Table:           "USERS",
+				Rows:            []map[string]any{{"ID": 1, "NAME": "Ann"}, {"ID": 2, "NAME": "Bob"}},
+				ConflictColumns: []string{"ID"},
+			},
+			`MERGE INTO "USERS" AS target USING (SELECT ? AS "ID", ? AS "NAME" UNION ALL SELECT ?, ?) AS source ` +
+				`ON target."ID" = source."ID" WHEN MATCHED THEN UPDATE SET target."NAME" = source."NAME" ` +
+				`WHEN NOT MATCHED THEN INSERT ("ID", "NAME") VALUES (source."ID", source."NAME")`,
+			[]any{1, "Ann", 2, "Bob"},
+		},
+		{
+			"key columns only",
+			connector.UpsertRequest{
+				Table:           "TAGS",
+				Rows:            []map[string]any{{"SLUG": "go"}},
+				ConflictColumns: []string{"SLUG"},
+			},
+			`MERGE INTO "TAGS" AS target USING (SELECT ? AS "SLUG") AS source ` +
+				`ON target."SLUG" = source."SLUG" WHEN NOT MATCHED THEN INSERT ("SLUG") VALUES (source."SLUG")`,
+			[]any{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := qb.BuildUpsert(tt.req)
+			if query != tt.wantQuery {
+				t.Errorf("got query:\n  %q\nwant:\n  %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildAggregate(t *testing.T) {
+	qb := &QueryBuilder{}
+
+	t.Run("group by with having", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:   "orders",
+			GroupBy: []string{"status"},
+			Aggregates: []connector.Aggregate{
+				{Func: connector.AggCount, Alias: "n"},
+				{Func: connector.AggAvg, Column: "total", Alias: "avg_total"},
+			},
+			Where:   connector.Eq([]string{"region"}, []any{"eu"}),
+			Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(5),
+			OrderBy: "n DESC",
+			Limit:   10,
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT "status", COUNT(*) AS "n", AVG("total") AS "avg_total" FROM "orders" WHERE "region" = ? GROUP BY "status" HAVING COUNT(*) > ? ORDER BY "n" DESC LIMIT ?`
+		if query != wantQuery {
+			t.Errorf("got query %q, want %q", query, wantQuery)
+		}
+		if len(args) != 3 || args[0] != "eu" || args[1] != 5 || args[2] != 10 {
+			t.Errorf("args = %v, want [eu 5 10]", args)
+		}
+	})
+
+	t.Run("count only", func(t *testing.T) {
+		req := connector.AggregateRequest{
+			Table:      "orders",
+			Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		}
+		query, args := qb.BuildAggregate(req)
+		wantQuery := `SELECT COUNT(*) AS "count" FROM "orders"`
 		if query != wantQuery {
 			t.Errorf("got query %q, want %q", query, wantQuery)
 		}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/conduitdb/conduit/internal/connector"
@@ -21,8 +22,9 @@ func init() {
 
 // Connector implements connector.Connector for SQLite.
 type Connector struct {
-	db     *sql.DB
-	dbPath string
+	db       *sql.DB
+	dbPath   string
+	readOnly bool
 }
 
 func (c *Connector) Open(ctx context.Context, cfg connector.ConnectionConfig) error {
@@ -38,6 +40,7 @@ func (c *Connector) Open(ctx context.Context, cfg connector.ConnectionConfig) er
 	db.SetMaxOpenConns(1) // SQLite only supports one writer
 	c.db = db
 	c.dbPath = dsn
+	c.readOnly = cfg.ReadOnly
 	return nil
 }
 
@@ -62,6 +65,12 @@ func (c *Connector) ParameterPlaceholder(index int) string {
 	return "?"
 }
 
+// InsertBatchSize returns the most rows one INSERT can take, within the
+// 32766 bind parameters SQLite allows a statement.
+func (c *Connector) InsertBatchSize(columns int) int {
+	return connector.BatchSize(columns, 32766, 0)
+}
+
 func (c *Connector) ListTables(ctx context.Context) ([]schema.TableSummary, error) {
 	rows, err := c.db.QueryContext(ctx, `
 		SELECT name FROM sqlite_master
@@ -163,6 +172,7 @@ func (c *Connector) DescribeTable(ctx context.Context, tableName string) (*schem
 	}
 
 	// Get indexes.
+	var uniqueIndexes []string
 	idxRows, err := c.db.QueryContext(ctx,
 		fmt.Sprintf("PRAGMA index_list(%s)", c.QuoteIdentifier(tableName)))
 	if err == nil {
@@ -175,6 +185,18 @@ func (c *Connector) DescribeTable(ctx context.Context, tableName string) (*schem
 				continue
 			}
 			detail.Indexes = append(detail.Indexes, name)
+			if unique == 1 && origin != "pk" && partial == 0 {
+				uniqueIndexes = append(uniqueIndexes, name)
+			}
+		}
+		idxRows.Close()
+	}
+
+	// Get the columns of unique indexes, leaving out expression indexes.
+	// The index list is read in full first: the connection is not shared.
+	for _, name := range uniqueIndexes {
+		if key := c.indexColumns(ctx, name); key != nil {
+			detail.UniqueKeys = append(detail.UniqueKeys, schema.UniqueKey{Name: name, Columns: key})
 		}
 	}
 
@@ -187,6 +209,29 @@ func (c *Connector) DescribeTable(ctx context.Context, tableName string) (*schem
 	return detail, nil
 }
 
+// indexColumns returns the columns of index in key order, or nil if it
+// indexes an expression or cannot be read.
+func (c *Connector) indexColumns(ctx context.Context, index string) []string {
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf("PRAGMA index_info(%s)", c.QuoteIdentifier(index)))
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var seqno, cid int
+		var name sql.NullString
+		if err := rows.Scan(&seqno, &cid, &name); err != nil || !name.Valid {
+			return nil
+		}
+		cols = append(cols, name.String)
+	}
+	if rows.Err() != nil {
+		return nil
+	}
+	return cols
+}
+
 func (c *Connector) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
 	return nil, nil // SQLite doesn't have stored procedures
 }
@@ -197,70 +242,203 @@ func (c *Connector) DescribeProcedure(ctx context.Context, name string) (*schema
 
 func (c *Connector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	query, args := c.buildSelect(req)
-	rows, err := c.db.QueryContext(ctx, query, args...)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("select: %w", err)
 	}
 	defer rows.Close()
-	return scanResultSet(rows)
+	return scanResultSet(ctx, rows, 0)
 }
 
+func (c *Connector) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	query, args := c.buildAggregate(req)
+	rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate: %w", err)
+	}
+	defer rows.Close()
+	return scanResultSet(ctx, rows, 0)
+}
+
+// Insert inserts the rows with one multi-row INSERT for each run of rows
+// that set the same columns, since SQLite has no DEFAULT in VALUES lists to
+// stand in for the columns a row leaves out.
 func (c *Connector) Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("sqlite: insert denied — connection is read-only")
+	}
 	if len(req.Rows) == 0 {
 		return &connector.MutationResult{}, nil
 	}
-	var totalAffected int64
-	for _, row := range req.Rows {
-		cols := make([]string, 0, len(row))
-		vals := make([]any, 0, len(row))
-		placeholders := make([]string, 0, len(row))
-		i := 0
-		for col, val := range row {
-			cols = append(cols, c.QuoteIdentifier(col))
-			vals = append(vals, val)
-			placeholders = append(placeholders, "?")
-			i++
+	res := &connector.MutationResult{}
+	for start := 0; start < len(req.Rows); {
+		cols := make([]string, 0, len(req.Rows[start]))
+		for col := range req.Rows[start] {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		size := c.InsertBatchSize(len(cols))
+		if len(cols) == 0 {
+			size = 1 // DEFAULT VALUES inserts a single row
+		}
+		end := start + 1
+		for end < len(req.Rows) && end-start < size && setsColumns(req.Rows[end], cols) {
+			end++
+		}
+
+		quoted := make([]string, len(cols))
+		for i, col := range cols {
+			quoted[i] = c.QuoteIdentifier(col)
+		}
+		placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+		values := make([]string, 0, end-start)
+		args := make([]any, 0, (end-start)*len(cols))
+		for _, row := range req.Rows[start:end] {
+			values = append(values, placeholders)
+			for _, col := range cols {
+				args = append(args, row[col])
+			}
 		}
-		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
 			c.QuoteIdentifier(req.Table),
-			strings.Join(cols, ", "),
-			strings.Join(placeholders, ", "))
-		result, err := c.db.ExecContext(ctx, query, vals...)
+			strings.Join(quoted, ", "),
+			strings.Join(values, ", "))
+		if len(cols) == 0 {
+			query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", c.QuoteIdentifier(req.Table))
+		}
+		r, err := c.mutate(ctx, query, args, req.Returning)
 		if err != nil {
 			return nil, fmt.Errorf("insert: %w", err)
 		}
-		n, _ := result.RowsAffected()
-		totalAffected += n
+		res.RowsAffected += r.RowsAffected
+		res.Returning = append(res.Returning, r.Returning...)
+		res.ReturningTruncated = res.ReturningTruncated || r.ReturningTruncated
+		start = end
+	}
+	return res, nil
+}
+
+// setsColumns reports whether row sets exactly cols.
+func setsColumns(row map[string]any, cols []string) bool {
+	if len(row) != len(cols) {
+		return false
+	}
+	for _, col := range cols {
+		if _, ok := row[col]; !ok {
+			return false
+		}
 	}
-	return &connector.MutationResult{RowsAffected: totalAffected}, nil
+	return true
 }
 
 func (c *Connector) Update(ctx context.Context, req connector.UpdateRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("sqlite: update denied — connection is read-only")
+	}
+	if req.Where.IsEmpty() {
+		return nil, fmt.Errorf("sqlite: update requires a filter")
+	}
 	setClauses := make([]string, 0, len(req.Set))
 	args := make([]any, 0, len(req.Set)+1)
 	for col, val := range req.Set {
 		setClauses = append(setClauses, fmt.Sprintf("%s = ?", c.QuoteIdentifier(col)))
 		args = append(args, val)
 	}
+	where, params := req.Where.Render(c.QuoteIdentifier, c.ParameterPlaceholder, len(args)+1)
+	args = append(args, params...)
 	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
 		c.QuoteIdentifier(req.Table),
 		strings.Join(setClauses, ", "),
-		req.Filter)
-	result, err := c.db.ExecContext(ctx, query, args...)
+		where)
+	res, err := c.mutate(ctx, query, args, req.Returning)
 	if err != nil {
 		return nil, fmt.Errorf("update: %w", err)
 	}
-	n, _ := result.RowsAffected()
-	return &connector.MutationResult{RowsAffected: n}, nil
+	return res, nil
 }
 
 func (c *Connector) Delete(ctx context.Context, req connector.DeleteRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("sqlite: delete denied — connection is read-only")
+	}
+	if req.Where.IsEmpty() {
+		return nil, fmt.Errorf("sqlite: delete requires a filter")
+	}
+	where, args := req.Where.Render(c.QuoteIdentifier, c.ParameterPlaceholder, 1)
 	query := fmt.Sprintf("DELETE FROM %s WHERE %s",
-		c.QuoteIdentifier(req.Table), req.Filter)
-	result, err := c.db.ExecContext(ctx, query)
+		c.QuoteIdentifier(req.Table), where)
+	res, err := c.mutate(ctx, query, args, req.Returning)
 	if err != nil {
 		return nil, fmt.Errorf("delete: %w", err)
 	}
+	return res, nil
+}
+
+// Upsert runs an INSERT ... ON CONFLICT statement that updates the
+// conflicting row's other columns from excluded, or leaves it alone if the
+// rows only set their conflict columns.
+func (c *Connector) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("sqlite: upsert denied — connection is read-only")
+	}
+	if len(req.Rows) == 0 {
+		return &connector.MutationResult{}, nil
+	}
+	cols := req.Columns()
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = c.QuoteIdentifier(col)
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+	values := make([]string, len(req.Rows))
+	args := make([]any, 0, len(req.Rows)*len(cols))
+	for i, row := range req.Rows {
+		values[i] = placeholders
+		for _, col := range cols {
+			args = append(args, row[col])
+		}
+	}
+	conflict := make([]string, len(req.ConflictColumns))
+	for i, col := range req.ConflictColumns {
+		conflict[i] = c.QuoteIdentifier(col)
+	}
+	action := "DO NOTHING"
+	if update := req.UpdateColumns(); len(update) > 0 {
+		setClauses := make([]string, len(update))
+		for i, col := range update {
+			setClauses[i] = fmt.Sprintf("%s = excluded.%[1]s", c.QuoteIdentifier(col))
+		}
+		action = "DO UPDATE SET " + strings.Join(setClauses, ", ")
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) %s",
+		c.QuoteIdentifier(req.Table),
+		strings.Join(quoted, ", "),
+		strings.Join(values, ", "),
+		strings.Join(conflict, ", "),
+		action)
+	res, err := c.mutate(ctx, query, args, req.Returning)
+	if err != nil {
+		return nil, fmt.Errorf("upsert: %w", err)
+	}
+	return res, nil
+}
+
+// mutate runs an INSERT, UPDATE, or DELETE statement. When returning is
+// set, it appends a RETURNING clause and reads the rows it reports.
+func (c *Connector) mutate(ctx context.Context, query string, args []any, returning bool) (*connector.MutationResult, error) {
+	if returning {
+		rows, err := connector.Conn(ctx, c.db).QueryContext(ctx, query+" RETURNING *", args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		return connector.ScanReturning(ctx, rows, nil)
+	}
+
+	result, err := connector.Conn(ctx, c.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	n, _ := result.RowsAffected()
 	return &connector.MutationResult{RowsAffected: n}, nil
 }
@@ -269,6 +447,69 @@ func (c *Connector) CallProcedure(ctx context.Context, req connector.ProcedureCa
 	return nil, fmt.Errorf("SQLite does not support stored procedures")
 }
 
+// QueryRaw executes a caller-written query in a read-only transaction.
+// SQLite ignores the read-only transaction option, so the connection is put
+// in query_only mode for the duration of the query.
+func (c *Connector) QueryRaw(ctx context.Context, req connector.RawQueryRequest) (*connector.ResultSet, error) {
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("raw query: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, fmt.Errorf("raw query: %w", err)
+	}
+	// Reset even when ctx has expired, before the connection returns to the
+	// pool.
+	defer conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("raw query: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("raw query: %w", err)
+	}
+	defer rows.Close()
+	return scanResultSet(ctx, rows, req.MaxRows)
+}
+
+// ExecRaw executes a caller-written statement.
+func (c *Connector) ExecRaw(ctx context.Context, query string) (*connector.MutationResult, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("sqlite: execute denied — connection is read-only")
+	}
+	result, err := c.db.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("execute: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return &connector.MutationResult{RowsAffected: n}, nil
+}
+
+func (c *Connector) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	return tx, nil
+}
+
+// Savepoint runs fn under a savepoint of the transaction carried by ctx, so
+// that a failure undoes all fn did, not only its failed statement.
+func (c *Connector) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	return connector.RunSavepoint(ctx,
+		"SAVEPOINT conduit_write", "ROLLBACK TO SAVEPOINT conduit_write", "RELEASE SAVEPOINT conduit_write", fn)
+}
+
 func (c *Connector) buildSelect(req connector.SelectRequest) (string, []any) {
 	cols := "*"
 	if len(req.Columns) > 0 {
@@ -280,11 +521,13 @@ func (c *Connector) buildSelect(req connector.SelectRequest) (string, []any) {
 	}
 	query := fmt.Sprintf("SELECT %s FROM %s", cols, c.QuoteIdentifier(req.Table))
 	var args []any
-	if req.Filter != "" {
-		query += " WHERE " + req.Filter
+	if cond := req.Condition(); !cond.IsEmpty() {
+		var where string
+		where, args = cond.Render(c.QuoteIdentifier, c.ParameterPlaceholder, 1)
+		query += " WHERE " + where
 	}
-	if req.OrderBy != "" {
-		query += " ORDER BY " + req.OrderBy
+	if orderBy := req.OrderByClause(c.QuoteIdentifier); orderBy != "" {
+		query += " ORDER BY " + orderBy
 	}
 	limit := req.Limit
 	if limit <= 0 {
@@ -297,28 +540,37 @@ func (c *Connector) buildSelect(req connector.SelectRequest) (string, []any) {
 	return query, args
 }
 
-func scanResultSet(rows *sql.Rows) (*connector.ResultSet, error) {
-	cols, err := rows.Columns()
-	if err != nil {
-		return nil, err
+func (c *Connector) buildAggregate(req connector.AggregateRequest) (string, []any) {
+	expr := func(a connector.Aggregate) string { return a.SQL(c.QuoteIdentifier) }
+	query := fmt.Sprintf("SELECT %s FROM %s",
+		req.SelectList(c.QuoteIdentifier, expr), c.QuoteIdentifier(req.Table))
+	var args []any
+	if !req.Where.IsEmpty() {
+		where, params := req.Where.Render(c.QuoteIdentifier, c.ParameterPlaceholder, 1)
+		query += " WHERE " + where
+		args = append(args, params...)
 	}
-	rs := &connector.ResultSet{Columns: cols}
-	for rows.Next() {
-		values := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, err
-		}
-		row := make(map[string]any, len(cols))
-		for i, col := range cols {
-			row[col] = values[i]
-		}
-		rs.Rows = append(rs.Rows, row)
+	if len(req.GroupBy) > 0 {
+		query += " GROUP BY " + req.GroupByList(c.QuoteIdentifier)
+	}
+	if !req.Having.IsEmpty() {
+		having, params := req.Having.Render(req.HavingColumn(c.QuoteIdentifier, expr), c.ParameterPlaceholder, len(args)+1)
+		query += " HAVING " + having
+		args = append(args, params...)
 	}
-	return rs, rows.Err()
+	if req.OrderBy != "" {
+		query += " ORDER BY " + req.OrderByClause(c.QuoteIdentifier)
+	}
+	if req.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+	return query, args
+}
+
+// scanResultSet reads rows into a ResultSet within the scan limits carried
+// by ctx, stopping after maxRows rows when maxRows is positive.
+func scanResultSet(ctx context.Context, rows *sql.Rows, maxRows int) (*connector.ResultSet, error) {
+	return connector.ScanRows(ctx, rows, maxRows, nil)
 }
 
 func mapSQLiteType(sqlType string) string {
@@ -2,7 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduitdb/conduit/internal/connector"
 	"github.com/conduitdb/conduit/internal/demo"
@@ -87,6 +90,11 @@ func TestDescribeTable(t *testing.T) {
 	if len(detail.PrimaryKey) == 0 {
 		t.Error("expected primary key, got none")
 	}
+
+	// Check the unique email constraint.
+	if len(detail.UniqueKeys) != 1 || !reflect.DeepEqual(detail.UniqueKeys[0].Columns, []string{"email"}) {
+		t.Errorf("expected a unique key on email, got %+v", detail.UniqueKeys)
+	}
 }
 
 func TestSelect(t *testing.T) {
@@ -149,6 +157,60 @@ func TestSelectWithOrderBy(t *testing.T) {
 	}
 }
 
+func TestSelectScanLimits(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	req := connector.SelectRequest{Table: "products", Columns: []string{"id", "description"}, OrderBy: "id", Limit: 10}
+	ctx := connector.WithScanLimits(context.Background(), connector.ScanLimits{MaxCellBytes: 10})
+	rs, err := c.Select(ctx, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	desc, _ := rs.Rows[0]["description"].(string)
+	if !strings.HasSuffix(desc, connector.TruncationMarker) || len(desc) > 10+len(connector.TruncationMarker) {
+		t.Errorf("expected a cut description, got %q", desc)
+	}
+	if len(rs.Rows) != 10 || !rs.Truncated || rs.SizeLimited || !strings.Contains(rs.TruncatedReason, "cut short") {
+		t.Errorf("rows = %d, truncated = %v (%s)", len(rs.Rows), rs.Truncated, rs.TruncatedReason)
+	}
+
+	ctx = connector.WithScanLimits(context.Background(), connector.ScanLimits{MaxBytes: 400})
+	rs, err = c.Select(ctx, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(rs.Rows) == 0 || len(rs.Rows) >= 10 || !rs.SizeLimited || !strings.Contains(rs.TruncatedReason, "size limit") {
+		t.Errorf("rows = %d, truncated = %v (%s)", len(rs.Rows), rs.Truncated, rs.TruncatedReason)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rs, err := c.Aggregate(context.Background(), connector.AggregateRequest{
+		Table:   "orders",
+		GroupBy: []string{"status"},
+		Aggregates: []connector.Aggregate{
+			{Func: connector.AggCount, Alias: "n"},
+			{Func: connector.AggSum, Column: "total", Alias: "revenue"},
+		},
+		Having:  (&connector.Filter{}).Column("n").SQL(" > ").Param(1),
+		OrderBy: "n DESC",
+		Limit:   10,
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	if len(rs.Rows) != 3 {
+		t.Fatalf("expected 3 statuses with more than one order, got %v", rs.Rows)
+	}
+	if rs.Rows[0]["status"] != "delivered" || rs.Rows[0]["n"] != int64(5) {
+		t.Errorf("unexpected first group: %v", rs.Rows[0])
+	}
+}
+
 func TestInsertUpdateDelete(t *testing.T) {
 	c, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -175,9 +237,9 @@ func TestInsertUpdateDelete(t *testing.T) {
 
 	// Update.
 	updateResult, err := c.Update(ctx, connector.UpdateRequest{
-		Table:  "customers",
-		Filter: "email = 'test@example.com'",
-		Set:    map[string]any{"first_name": "Updated"},
+		Table: "customers",
+		Where: connector.Eq([]string{"email"}, []any{"test@example.com"}),
+		Set:   map[string]any{"first_name": "Updated"},
 	})
 	if err != nil {
 		t.Fatalf("Update: %v", err)
@@ -188,8 +250,8 @@ func TestInsertUpdateDelete(t *testing.T) {
 
 	// Delete.
 	deleteResult, err := c.Delete(ctx, connector.DeleteRequest{
-		Table:  "customers",
-		Filter: "email = 'test@example.com'",
+		Table: "customers",
+		Where: connector.Eq([]string{"email"}, []any{"test@example.com"}),
 	})
 	if err != nil {
 		t.Fatalf("Delete: %v", err)
@@ -199,6 +261,171 @@ func TestInsertUpdateDelete(t *testing.T) {
 	}
 }
 
+func TestReturning(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	ins, err := c.Insert(ctx, connector.InsertRequest{
+		Table: "customers",
+		Rows: []map[string]any{
+			{"first_name": "Ann", "last_name": "Lee", "email": "ann@example.com"},
+			{"first_name": "Ben", "last_name": "Lee", "email": "ben@example.com"},
+		},
+		Returning: true,
+	})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if ins.RowsAffected != 2 || len(ins.Returning) != 2 {
+		t.Fatalf("expected 2 rows returned, got %d (%v)", ins.RowsAffected, ins.Returning)
+	}
+	if id, ok := ins.Returning[0]["id"].(int64); !ok || id == 0 {
+		t.Errorf("expected a generated id, got %v", ins.Returning[0])
+	}
+	if ins.Returning[1]["email"] != "ben@example.com" {
+		t.Errorf("unexpected second row: %v", ins.Returning[1])
+	}
+
+	where := connector.Eq([]string{"last_name"}, []any{"Lee"})
+	upd, err := c.Update(ctx, connector.UpdateRequest{
+		Table:     "customers",
+		Where:     where,
+		Set:       map[string]any{"last_name": "Li"},
+		Returning: true,
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if upd.RowsAffected != 2 || len(upd.Returning) != 2 || upd.Returning[0]["last_name"] != "Li" {
+		t.Errorf("unexpected update result: %d %v", upd.RowsAffected, upd.Returning)
+	}
+
+	// Rows past the result size limit are counted but not returned.
+	limited := connector.WithScanLimits(ctx, connector.ScanLimits{MaxBytes: 400})
+	del, err := c.Delete(limited, connector.DeleteRequest{
+		Table:     "customers",
+		Where:     connector.Eq([]string{"last_name"}, []any{"Li"}),
+		Returning: true,
+	})
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if del.RowsAffected != 2 || len(del.Returning) != 1 || !del.ReturningTruncated {
+		t.Errorf("unexpected delete result: %d %v truncated=%v", del.RowsAffected, del.Returning, del.ReturningTruncated)
+	}
+}
+
+func TestInsertColumnRuns(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Rows that set different columns go in separate statements, in order.
+	res, err := c.Insert(ctx, connector.InsertRequest{
+		Table: "customers",
+		Rows: []map[string]any{
+			{"first_name": "Ann", "last_name": "Lee", "email": "ann@example.com"},
+			{"first_name": "Ben", "last_name": "Lee", "email": "ben@example.com"},
+			{"first_name": "Cy", "last_name": "Lee", "email": "cy@example.com", "country": "CA"},
+		},
+		Returning: true,
+	})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if res.RowsAffected != 3 || len(res.Returning) != 3 {
+		t.Fatalf("expected 3 rows returned, got %d (%v)", res.RowsAffected, res.Returning)
+	}
+	for i, want := range []string{"US", "US", "CA"} {
+		if got := res.Returning[i]["country"]; got != want {
+			t.Errorf("row %d: expected country %s, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	res, err := c.Upsert(ctx, connector.UpsertRequest{
+		Table: "customers",
+		Rows: []map[string]any{
+			{"first_name": "Alice", "last_name": "Jones", "email": "alice@example.com"},
+			{"first_name": "Dana", "last_name": "Park", "email": "dana@example.com"},
+		},
+		ConflictColumns: []string{"email"},
+		Returning:       true,
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if res.RowsAffected != 2 || len(res.Returning) != 2 {
+		t.Fatalf("expected 2 rows returned, got %d (%v)", res.RowsAffected, res.Returning)
+	}
+	if res.Returning[0]["id"] != int64(1) || res.Returning[0]["last_name"] != "Jones" {
+		t.Errorf("expected customer 1 to be updated, got %v", res.Returning[0])
+	}
+	if res.Returning[0]["city"] != "San Francisco" {
+		t.Errorf("expected columns the upsert leaves out to be kept, got %v", res.Returning[0])
+	}
+	if res.Returning[1]["email"] != "dana@example.com" {
+		t.Errorf("unexpected inserted row: %v", res.Returning[1])
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	c, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	rs, err := c.QueryRaw(ctx, connector.RawQueryRequest{
+		SQL:     "SELECT id, email FROM customers ORDER BY id",
+		MaxRows: 3,
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("QueryRaw: %v", err)
+	}
+	if len(rs.Rows) != 3 || len(rs.Columns) != 2 {
+		t.Errorf("expected 3 rows of 2 columns, got %d rows of %v", len(rs.Rows), rs.Columns)
+	}
+
+	if _, err := c.QueryRaw(ctx, connector.RawQueryRequest{SQL: "DELETE FROM reviews"}); err == nil {
+		t.Error("expected writes to fail in QueryRaw")
+	}
+	// The connection must leave query_only mode afterwards.
+	res, err := c.ExecRaw(ctx, "DELETE FROM reviews WHERE id = 1")
+	if err != nil {
+		t.Fatalf("ExecRaw after QueryRaw: %v", err)
+	}
+	if res.RowsAffected != 1 {
+		t.Errorf("expected 1 row affected, got %d", res.RowsAffected)
+	}
+}
+
+func TestExecRaw_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dsn, cleanup, err := demo.CreateDemoDB(ctx)
+	if err != nil {
+		t.Fatalf("failed to create demo db: %v", err)
+	}
+	defer cleanup()
+	c := &Connector{}
+	if err := c.Open(ctx, connector.ConnectionConfig{DSN: dsn, ReadOnly: true}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.ExecRaw(ctx, "DELETE FROM reviews"); err == nil {
+		t.Error("expected ExecRaw to fail on a read-only connection")
+	}
+}
+
 func TestDriverName(t *testing.T) {
 	c := &Connector{}
 	if c.DriverName() != "sqlite" {
@@ -0,0 +1,108 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// TableAllowed reports whether a table is exposed under the IncludeTables and
+// ExcludeTables settings. Patterns use path.Match glob syntax and are matched
+// against both the full (possibly schema-qualified) name and the bare table
+// name. An empty IncludeTables list includes every table.
+func (c ConnectionConfig) TableAllowed(table string) bool {
+	if len(c.IncludeTables) > 0 && !matchAny(c.IncludeTables, table) {
+		return false
+	}
+	return !matchAny(c.ExcludeTables, table)
+}
+
+func matchAny(patterns []string, table string) bool {
+	bare := table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		bare = table[i+1:]
+	}
+	for _, p := range patterns {
+		for _, name := range []string{table, bare} {
+			if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(name)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithTableFilter wraps conn so that tables hidden by the IncludeTables and
+// ExcludeTables settings in cfg are neither listed nor accessible. If cfg has
+// no table filters, conn is returned unchanged.
+func WithTableFilter(conn Connector, cfg ConnectionConfig) Connector {
+	if len(cfg.IncludeTables) == 0 && len(cfg.ExcludeTables) == 0 {
+		return conn
+	}
+	return &tableFilter{Connector: conn, cfg: cfg}
+}
+
+// tableFilter is a Connector decorator enforcing include/exclude table lists.
+type tableFilter struct {
+	Connector
+	cfg ConnectionConfig
+}
+
+func (f *tableFilter) check(table string) error {
+	if !f.cfg.TableAllowed(table) {
+		return fmt.Errorf("table %q is not exposed by this source", table)
+	}
+	return nil
+}
+
+func (f *tableFilter) ListTables(ctx context.Context) ([]schema.TableSummary, error) {
+	tables, err := f.Connector.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filtered := tables[:0]
+	for _, t := range tables {
+		if f.cfg.TableAllowed(t.Name) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+func (f *tableFilter) DescribeTable(ctx context.Context, tableName string) (*schema.TableDetail, error) {
+	if err := f.check(tableName); err != nil {
+		return nil, err
+	}
+	return f.Connector.DescribeTable(ctx, tableName)
+}
+
+func (f *tableFilter) Select(ctx context.Context, req SelectRequest) (*ResultSet, error) {
+	if err := f.check(req.Table); err != nil {
+		return nil, err
+	}
+	return f.Connector.Select(ctx, req)
+}
+
+func (f *tableFilter) Insert(ctx context.Context, req InsertRequest) (*MutationResult, error) {
+	if err := f.check(req.Table); err != nil {
+		return nil, err
+	}
+	return f.Connector.Insert(ctx, req)
+}
+
+func (f *tableFilter) Update(ctx context.Context, req UpdateRequest) (*MutationResult, error) {
+	if err := f.check(req.Table); err != nil {
+		return nil, err
+	}
+	return f.Connector.Update(ctx, req)
+}
+
+func (f *tableFilter) Delete(ctx context.Context, req DeleteRequest) (*MutationResult, error) {
+	if err := f.check(req.Table); err != nil {
+		return nil, err
+	}
+	return f.Connector.Delete(ctx, req)
+}
@@ -0,0 +1,28 @@
+package connector
+
+import "testing"
+
+func TestConnectionConfig_TableAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ConnectionConfig
+		table   string
+		allowed bool
+	}{
+		{"no filters", ConnectionConfig{}, "users", true},
+		{"excluded", ConnectionConfig{ExcludeTables: []string{"_migrations"}}, "_migrations", false},
+		{"excluded qualified", ConnectionConfig{ExcludeTables: []string{"_migrations"}}, "public._migrations", false},
+		{"excluded glob", ConnectionConfig{ExcludeTables: []string{"audit_*"}}, "audit_log", false},
+		{"case insensitive", ConnectionConfig{ExcludeTables: []string{"Users"}}, "users", false},
+		{"included", ConnectionConfig{IncludeTables: []string{"orders", "users"}}, "users", true},
+		{"not included", ConnectionConfig{IncludeTables: []string{"orders"}}, "users", false},
+		{"include and exclude", ConnectionConfig{IncludeTables: []string{"*"}, ExcludeTables: []string{"secrets"}}, "secrets", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.TableAllowed(tt.table); got != tt.allowed {
+				t.Errorf("TableAllowed(%q) = %v, want %v", tt.table, got, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Execer runs statements. Both *sql.DB and *sql.Tx implement it.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type txKey struct{}
+
+// WithTx returns a context whose CRUD and aggregate statements run in tx
+// rather than on their own pooled connections.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Conn returns the transaction carried by ctx, or db if there is none.
+// Connectors run their CRUD and aggregate statements on it.
+func Conn(ctx context.Context, db *sql.DB) Execer {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// RunSavepoint runs fn under a savepoint of the transaction carried by ctx,
+// for connectors to implement Savepoint with their dialect's statements:
+// save sets the savepoint, rollback returns to it if fn fails, and release,
+// if not empty, discards it once fn succeeds. Outside a transaction, each
+// statement fn runs stands alone, so fn runs as is.
+func RunSavepoint(ctx context.Context, save, rollback, release string, fn func(ctx context.Context) error) error {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	if !ok {
+		return fn(ctx)
+	}
+	if _, err := tx.ExecContext(ctx, save); err != nil {
+		return fmt.Errorf("failed to set savepoint: %w", err)
+	}
+	if err := fn(ctx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, rollback); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back to savepoint: %w", rbErr))
+		}
+		return err
+	}
+	if release != "" {
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package connector
+
+import (
+	"sort"
+	"strings"
+)
+
+// Columns returns the columns set by the rows of r, sorted.
+func (r UpsertRequest) Columns() []string {
+	if len(r.Rows) == 0 {
+		return nil
+	}
+	cols := make([]string, 0, len(r.Rows[0]))
+	for col := range r.Rows[0] {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// UpdateColumns returns the columns set in a row that conflicts with one of
+// r's rows: the columns r sets other than its conflict columns. It is empty
+// if r only sets its conflict columns, in which case conflicting rows are
+// left as they are.
+func (r UpsertRequest) UpdateColumns() []string {
+	var cols []string
+	for _, col := range r.Columns() {
+		conflict := false
+		for _, key := range r.ConflictColumns {
+			if strings.EqualFold(col, key) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// KeySelect returns a request for the rows r writes, selected by their
+// conflict column values and ordered by them. Connectors whose database
+// cannot report the rows a MERGE or upsert wrote use it for Returning.
+func (r UpsertRequest) KeySelect() SelectRequest {
+	keys := make([][]any, len(r.Rows))
+	for i, row := range r.Rows {
+		keys[i] = make([]any, len(r.ConflictColumns))
+		for j, col := range r.ConflictColumns {
+			keys[i][j] = row[col]
+		}
+	}
+	order := make([]SortKey, len(r.ConflictColumns))
+	for i, col := range r.ConflictColumns {
+		order[i] = SortKey{Column: col}
+	}
+	return SelectRequest{
+		Table:  r.Table,
+		Where:  KeyIn(r.ConflictColumns, keys),
+		Keyset: &Keyset{Columns: order},
+		Limit:  len(keys),
+	}
+}
@@ -0,0 +1,41 @@
+package connector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpsertRequest(t *testing.T) {
+	req := UpsertRequest{
+		Table: "users",
+		Rows: []map[string]any{
+			{"id": 1, "name": "Ann", "email": "ann@example.com"},
+			{"id": 2, "name": "Bob", "email": "bob@example.com"},
+		},
+		ConflictColumns: []string{"id"},
+	}
+
+	if got, want := req.Columns(), []string{"email", "id", "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+	if got, want := req.UpdateColumns(), []string{"email", "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateColumns() = %v, want %v", got, want)
+	}
+
+	sel := req.KeySelect()
+	clause, params := sel.Where.Render(quote, dollar, 1)
+	if want := `"id" IN ($1, $2)`; clause != want {
+		t.Errorf("KeySelect().Where = %q, want %q", clause, want)
+	}
+	if !reflect.DeepEqual(params, []any{1, 2}) {
+		t.Errorf("KeySelect() params = %v, want [1 2]", params)
+	}
+	if sel.Limit != 2 || sel.Keyset == nil || sel.Keyset.Columns[0].Column != "id" {
+		t.Errorf("KeySelect() = %+v, want a keyset on id limited to 2 rows", sel)
+	}
+
+	keyOnly := UpsertRequest{Rows: []map[string]any{{"ID": 1}}, ConflictColumns: []string{"id"}}
+	if got := keyOnly.UpdateColumns(); len(got) != 0 {
+		t.Errorf("UpdateColumns() of key-only rows = %v, want none", got)
+	}
+}
@@ -0,0 +1,44 @@
+// Package format names the encodings query tools return result sets in, so
+// that the config file and the tools agree on them.
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format selects how query tools encode their results.
+type Format string
+
+const (
+	// JSON returns rows as objects keyed by column name.
+	JSON Format = "json"
+	// Arrays returns rows as arrays of values in column order, so column
+	// names are not repeated in every row.
+	Arrays Format = "arrays"
+	// CSV returns a CSV document with a header row.
+	CSV Format = "csv"
+	// Markdown returns a markdown table.
+	Markdown Format = "markdown"
+)
+
+// All lists the supported result formats.
+var All = []Format{JSON, Arrays, CSV, Markdown}
+
+// Parse returns the format named s, compared case-insensitively, or JSON if
+// s is empty.
+func Parse(s string) (Format, error) {
+	if s == "" {
+		return JSON, nil
+	}
+	for _, f := range All {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(All))
+	for i, f := range All {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unsupported format %q (expected %s)", s, strings.Join(names, ", "))
+}
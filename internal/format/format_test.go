@@ -0,0 +1,14 @@
+package format
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Format{"": JSON, "CSV": CSV, "arrays": Arrays} {
+		if got, err := Parse(in); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Parse("xml"); err == nil {
+		t.Error("Parse(\"xml\") succeeded")
+	}
+}
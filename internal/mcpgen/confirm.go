@@ -0,0 +1,104 @@
+package mcpgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// withConfirmProperties adds the two-phase write arguments of
+// update_{table} and delete_{table} to properties and returns it.
+func withConfirmProperties(properties map[string]any, op string) map[string]any {
+	properties["preview"] = map[string]any{
+		"type":        "boolean",
+		"description": fmt.Sprintf("Only report the rows the %s would affect, with a sample and a confirm_token", op),
+		"default":     false,
+	}
+	properties["confirm_token"] = map[string]any{
+		"type":        "string",
+		"description": fmt.Sprintf("Token from a preview of this %s, passed with the same arguments. The %s runs only if the affected row count still matches.", op, op),
+	}
+	return properties
+}
+
+// confirmedWrite runs an update or delete with the caller's confirmation
+// token, if any, handling confirmation. When the caller asks for a
+// preview, it is returned. When the engine requires confirmation, the user
+// is asked through elicitation if the client supports it, and the write
+// runs with the preview's token once they accept. Otherwise the preview is
+// returned, and the caller confirms by calling the tool again with its
+// confirm_token.
+func confirmedWrite(ctx context.Context, req *mcp.CallToolRequest, preview bool, token string,
+	previewFn func() (*connector.WritePreview, error),
+	run func(token string) (*connector.MutationResult, error)) (*connector.MutationResult, error) {
+	if !preview {
+		mr, err := run(token)
+		if !errors.Is(err, connector.ErrConfirmationRequired) {
+			return mr, err
+		}
+	}
+
+	p, err := previewFn()
+	if err != nil {
+		return nil, err
+	}
+	cut := 0
+	for _, row := range p.Sample {
+		normalizeRow(row, &cut)
+	}
+	if preview || !supportsElicitation(req.Session) {
+		return &connector.MutationResult{Preview: p}, nil
+	}
+
+	res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+		Message: previewMessage(p),
+		RequestedSchema: objectSchema(map[string]any{
+			"confirm": map[string]any{
+				"type":        "boolean",
+				"title":       "Confirm",
+				"description": fmt.Sprintf("Apply the %s", p.Op),
+			},
+		}, []string{"confirm"}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("confirmation request failed: %w", err)
+	}
+	if confirmed, _ := res.Content["confirm"].(bool); res.Action != "accept" || !confirmed {
+		return nil, fmt.Errorf("the %s was not confirmed by the user", p.Op)
+	}
+	return run(p.ConfirmToken)
+}
+
+// supportsElicitation reports whether the client of session can be asked
+// for form input.
+func supportsElicitation(session *mcp.ServerSession) bool {
+	if session == nil {
+		return false
+	}
+	params := session.InitializeParams()
+	if params == nil || params.Capabilities == nil || params.Capabilities.Elicitation == nil {
+		return false
+	}
+	caps := params.Capabilities.Elicitation
+	return caps.Form != nil || caps.URL == nil
+}
+
+// previewMessage describes a write preview to the user asked to confirm it.
+func previewMessage(p *connector.WritePreview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Confirm %s of %d rows in %s?", p.Op, p.RowsAffected, p.Table)
+	if len(p.Sample) > 0 {
+		fmt.Fprintf(&b, "\n\nFirst %d of them:", len(p.Sample))
+		for _, row := range p.Sample {
+			data, _ := json.Marshal(row)
+			b.WriteString("\n")
+			b.Write(data)
+		}
+	}
+	return b.String()
+}
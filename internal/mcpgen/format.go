@@ -0,0 +1,261 @@
+package mcpgen
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/format"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxBinaryBytes caps the bytes of a binary value that are base64-encoded
+// into a result. Longer values are cut and end in connector.TruncationMarker.
+const maxBinaryBytes = 1024
+
+// resultFormat returns the format requested by a tool's format argument,
+// falling back to the server default.
+func (g *Generator) resultFormat(s string) (format.Format, error) {
+	if s == "" {
+		return g.config.OutputFormat, nil
+	}
+	return format.Parse(s)
+}
+
+// formatProperty returns the input schema of the format argument of the
+// tools returning result sets.
+func (g *Generator) formatProperty() map[string]any {
+	enum := make([]any, len(format.All))
+	for i, f := range format.All {
+		enum[i] = string(f)
+	}
+	return map[string]any{
+		"type":        "string",
+		"enum":        enum,
+		"default":     string(g.config.OutputFormat),
+		"description": "Result encoding: json (rows as objects), arrays (rows as value arrays in column order; most compact), csv, or markdown (a table)",
+	}
+}
+
+// resultMeta is the part of a result set besides its columns and rows.
+type resultMeta struct {
+	Total           int64  `json:"total,omitempty"`
+	HasMore         bool   `json:"has_more,omitempty"`
+	NextCursor      string `json:"next_cursor,omitempty"`
+	Truncated       bool   `json:"truncated,omitempty"`
+	TruncatedReason string `json:"truncated_reason,omitempty"`
+}
+
+// arraysResult is a result set in format.Arrays.
+type arraysResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	resultMeta
+}
+
+// mutationContent encodes mr as a tool result, with the values of its
+// returned rows normalized by normalizeValue.
+func mutationContent(mr *connector.MutationResult) *mcp.CallToolResult {
+	cut := 0
+	for _, row := range mr.Returning {
+		normalizeRow(row, &cut)
+	}
+	return structuredResult(mr)
+}
+
+// resultContent encodes rs in f as a tool result. Values are first
+// normalized by normalizeValue. The csv and markdown encodings cannot carry
+// the pagination and truncation fields, so when any is set they follow the
+// table as a second, JSON, content item. Whatever the format, rs itself is
+// the structured content, matching resultSetOutputSchema.
+func resultContent(rs *connector.ResultSet, f format.Format) *mcp.CallToolResult {
+	cut := 0
+	for _, row := range rs.Rows {
+		normalizeRow(row, &cut)
+	}
+	if cut > 0 {
+		rs.SetTruncation(rs.CutValues+cut, rs.SizeLimited)
+	}
+	meta := resultMeta{
+		Total:           rs.Total,
+		HasMore:         rs.HasMore,
+		NextCursor:      rs.NextCursor,
+		Truncated:       rs.Truncated,
+		TruncatedReason: rs.TruncatedReason,
+	}
+
+	var text string
+	switch f {
+	case format.Arrays:
+		out := arraysResult{Columns: rs.Columns, Rows: make([][]any, len(rs.Rows)), resultMeta: meta}
+		for i, row := range rs.Rows {
+			out.Rows[i] = make([]any, len(rs.Columns))
+			for j, col := range rs.Columns {
+				out.Rows[i][j] = row[col]
+			}
+		}
+		data, _ := json.Marshal(out)
+		text = string(data)
+	case format.CSV:
+		text = encodeCSV(rs)
+	case format.Markdown:
+		text = encodeMarkdown(rs)
+	default:
+		data, _ := json.Marshal(rs)
+		text = string(data)
+	}
+
+	result := &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: text}},
+		StructuredContent: rs,
+	}
+	if (f == format.CSV || f == format.Markdown) && meta != (resultMeta{}) {
+		data, _ := json.Marshal(meta)
+		result.Content = append(result.Content, &mcp.TextContent{Text: string(data)})
+	}
+	return result
+}
+
+// encodeCSV renders rs as CSV with a header row. NULL is an empty field.
+func encodeCSV(rs *connector.ResultSet) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(rs.Columns)
+	record := make([]string, len(rs.Columns))
+	for _, row := range rs.Rows {
+		for i, col := range rs.Columns {
+			record[i] = cellText(row[col])
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// encodeMarkdown renders rs as a markdown table. NULL is an empty cell.
+func encodeMarkdown(rs *connector.ResultSet) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" ")
+			b.WriteString(markdownEscaper.Replace(c))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(rs.Columns)
+	b.WriteString("|")
+	b.WriteString(strings.Repeat(" --- |", len(rs.Columns)))
+	b.WriteString("\n")
+	cells := make([]string, len(rs.Columns))
+	for _, row := range rs.Rows {
+		for i, col := range rs.Columns {
+			cells[i] = cellText(row[col])
+		}
+		writeRow(cells)
+	}
+	return b.String()
+}
+
+// markdownEscaper keeps cell text on one line and inside its cell.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// cellText renders a normalized value as the text of a CSV field or table
+// cell. Nested values, such as expanded rows, are rendered as JSON.
+func cellText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case map[string]any, []map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// normalizeRow normalizes the values of row in place, adding the number of
+// binary values cut short to *cut.
+func normalizeRow(row map[string]any, cut *int) {
+	for col, v := range row {
+		row[col] = normalizeValue(v, cut)
+	}
+}
+
+// normalizeValue converts a scanned value to its representation in every
+// result format, so that a value reads the same whichever format is asked
+// for:
+//
+//   - times are RFC 3339 strings;
+//   - binary values, and strings that are not valid UTF-8, are base64
+//     strings of at most maxBinaryBytes bytes;
+//   - exact decimals, which drivers return as text or as types with a
+//     String method, are strings, so they keep their precision;
+//   - NaN and infinite floats, which JSON cannot represent, are strings.
+//
+// Nested rows added by expand are normalized recursively.
+func normalizeValue(v any, cut *int) any {
+	switch v := v.(type) {
+	case nil, bool:
+		return v
+	case string:
+		if utf8.ValidString(v) {
+			return v
+		}
+		return encodeBinary([]byte(v), cut)
+	case []byte:
+		return encodeBinary(v, cut)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case float32:
+		return normalizeValue(float64(v), cut)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case map[string]any:
+		normalizeRow(v, cut)
+		return v
+	case []map[string]any:
+		for _, row := range v {
+			normalizeRow(row, cut)
+		}
+		return v
+	case driver.Valuer:
+		if dv, err := v.Value(); err == nil {
+			return normalizeValue(dv, cut)
+		}
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// encodeBinary returns b base64-encoded, cut to maxBinaryBytes.
+func encodeBinary(b []byte, cut *int) string {
+	if len(b) <= maxBinaryBytes {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	*cut++
+	return base64.StdEncoding.EncodeToString(b[:maxBinaryBytes]) + connector.TruncationMarker
+}
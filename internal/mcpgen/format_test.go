@@ -0,0 +1,73 @@
+package mcpgen
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/format"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestResultContent(t *testing.T) {
+	newResultSet := func() *connector.ResultSet {
+		return &connector.ResultSet{
+			Columns: []string{"id", "note", "at", "price", "data"},
+			Rows: []map[string]any{
+				{"id": int64(1), "note": "a|b\nc", "at": time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), "price": "19.99", "data": []byte("hi")},
+				{"id": int64(2), "note": nil, "at": nil, "price": math.Inf(1), "data": nil},
+			},
+			HasMore:    true,
+			NextCursor: "abc",
+		}
+	}
+	tests := []struct {
+		format format.Format
+		want   []string
+	}{
+		{format.JSON, []string{
+			`{"columns":["id","note","at","price","data"],"rows":[{"at":"2024-03-01T12:00:00Z","data":"aGk=","id":1,"note":"a|b\nc","price":"19.99"},{"at":null,"data":null,"id":2,"note":null,"price":"+Inf"}],"has_more":true,"next_cursor":"abc"}`,
+		}},
+		{format.Arrays, []string{
+			`{"columns":["id","note","at","price","data"],"rows":[[1,"a|b\nc","2024-03-01T12:00:00Z","19.99","aGk="],[2,null,null,"+Inf",null]],"has_more":true,"next_cursor":"abc"}`,
+		}},
+		{format.CSV, []string{
+			"id,note,at,price,data\n1,\"a|b\nc\",2024-03-01T12:00:00Z,19.99,aGk=\n2,,,+Inf,\n",
+			`{"has_more":true,"next_cursor":"abc"}`,
+		}},
+		{format.Markdown, []string{
+			"| id | note | at | price | data |\n| --- | --- | --- | --- | --- |\n| 1 | a\\|b<br>c | 2024-03-01T12:00:00Z | 19.99 | aGk= |\n| 2 |  |  | +Inf |  |\n",
+			`{"has_more":true,"next_cursor":"abc"}`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			result := resultContent(newResultSet(), tt.format)
+			if len(result.Content) != len(tt.want) {
+				t.Fatalf("got %d content items, want %d", len(result.Content), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := result.Content[i].(*mcp.TextContent).Text; got != want {
+					t.Errorf("content[%d] =\n%s\nwant\n%s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResultContent_CapsBinary(t *testing.T) {
+	rs := &connector.ResultSet{
+		Columns: []string{"blob"},
+		Rows:    []map[string]any{{"blob": make([]byte, maxBinaryBytes+1)}},
+	}
+	resultContent(rs, format.JSON)
+	blob := rs.Rows[0]["blob"].(string)
+	if !strings.HasSuffix(blob, connector.TruncationMarker) {
+		t.Errorf("blob not marked as cut: %q", blob[len(blob)-20:])
+	}
+	if !rs.Truncated || rs.CutValues != 1 {
+		t.Errorf("truncated = %v, cut values = %d; want true, 1", rs.Truncated, rs.CutValues)
+	}
+}
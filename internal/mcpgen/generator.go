@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/conduitdb/conduit/internal/access"
 	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/format"
 	"github.com/conduitdb/conduit/internal/schema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -23,12 +25,29 @@ type GeneratorConfig struct {
 	AllowRawSQL bool
 	MaskPII     bool
 	MaxRows     int
+
+	// OutputFormat is the result format of query tools whose caller does
+	// not pass one. Defaults to format.JSON.
+	OutputFormat format.Format
+
+	// Source names the database source when several are served from one
+	// process. When set, Tier 2 tool names and resource URIs are prefixed
+	// with it so they do not collide across sources.
+	Source string
 }
 
 // ToolDef bundles a Tool definition with its handler for registration.
 type ToolDef struct {
 	Tool    *mcp.Tool
 	Handler mcp.ToolHandler
+
+	// Table and Verb record the table a Tier 2 tool operates on and the
+	// access it needs, so the server can hide it from roles without that
+	// access. Tools that need more than one verb list the others in
+	// MoreVerbs. Core tools leave them empty.
+	Table     string
+	Verb      access.Verb
+	MoreVerbs []access.Verb
 }
 
 // ResourceDef bundles a Resource definition with its handler.
@@ -51,11 +70,38 @@ type PromptDef struct {
 
 // ToolRegistrar is a callback for registering dynamically generated tools
 // on the MCP server. It is called by the enable_table_tools handler.
-type ToolRegistrar func(tool *mcp.Tool, handler mcp.ToolHandler)
+type ToolRegistrar func(def ToolDef)
+
+// Engine is the enforcement layer that every generated tool, resource, and
+// prompt goes through. *query.Engine implements it, applying validation,
+// filter sanitization, query timeouts, and PII masking before anything
+// reaches the connector.
+type Engine interface {
+	ListTables(ctx context.Context) ([]schema.TableSummary, error)
+	DescribeTable(ctx context.Context, table string) (*schema.TableDetail, error)
+	RefreshSchema(ctx context.Context) error
+	ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error)
+
+	Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error)
+	Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error)
+	Update(ctx context.Context, req connector.UpdateRequest) (*connector.MutationResult, error)
+	Delete(ctx context.Context, req connector.DeleteRequest) (*connector.MutationResult, error)
+	Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error)
+	PreviewUpdate(ctx context.Context, req connector.UpdateRequest) (*connector.WritePreview, error)
+	PreviewDelete(ctx context.Context, req connector.DeleteRequest) (*connector.WritePreview, error)
+	Transaction(ctx context.Context, req connector.TransactionRequest) (*connector.TransactionResult, error)
+	Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error)
+	CallProcedure(ctx context.Context, req connector.ProcedureCallRequest) (*connector.ResultSet, error)
+
+	QueryRaw(ctx context.Context, sql string) (*connector.ResultSet, error)
+	ExecRaw(ctx context.Context, sql string) (*connector.MutationResult, error)
+
+	DriverName() string
+}
 
 // Generator produces MCP tools, resources, and prompts from database schema.
 type Generator struct {
-	conn   connector.Connector
+	engine Engine
 	config GeneratorConfig
 
 	// OnRegisterTool is called to register dynamically generated tools on the
@@ -63,22 +109,24 @@ type Generator struct {
 	// handler is invoked.
 	OnRegisterTool ToolRegistrar
 
-	mu             sync.RWMutex
-	enabledTables  map[string]bool // currently enabled tables for Tier 2 tools
-	schemaCache    map[string]*schema.TableDetail
-	tableSummaries []schema.TableSummary
+	mu            sync.RWMutex
+	enabledTables map[string]bool // currently enabled tables for Tier 2 tools
+	keyedTables   map[string]bool // enabled tables given an upsert tool
 }
 
-// NewGenerator creates a generator backed by the given connector.
-func NewGenerator(conn connector.Connector, cfg GeneratorConfig) *Generator {
+// NewGenerator creates a generator backed by the given engine.
+func NewGenerator(engine Engine, cfg GeneratorConfig) *Generator {
 	if cfg.MaxRows <= 0 {
 		cfg.MaxRows = 1000
 	}
+	if cfg.OutputFormat == "" {
+		cfg.OutputFormat = format.JSON
+	}
 	return &Generator{
-		conn:          conn,
+		engine:        engine,
 		config:        cfg,
 		enabledTables: make(map[string]bool),
-		schemaCache:   make(map[string]*schema.TableDetail),
+		keyedTables:   make(map[string]bool),
 	}
 }
 
@@ -108,8 +156,8 @@ func (g *Generator) DynamicToolsForTables(ctx context.Context, tables []string)
 
 	var allTools []ToolDef
 	for _, tableName := range tables {
-		// Get table detail (cached).
-		detail, err := g.getTableDetail(ctx, tableName)
+		// Get table detail (cached by the engine).
+		detail, err := g.engine.DescribeTable(ctx, tableName)
 		if err != nil {
 			return nil, fmt.Errorf("table %q: %w", tableName, err)
 		}
@@ -117,6 +165,7 @@ func (g *Generator) DynamicToolsForTables(ctx context.Context, tables []string)
 		tools := g.buildDynamicTools(detail)
 		allTools = append(allTools, tools...)
 		g.enabledTables[tableName] = true
+		g.keyedTables[tableName] = len(detail.PrimaryKey) > 0 || len(detail.UniqueKeys) > 0
 	}
 
 	return allTools, nil
@@ -130,8 +179,9 @@ func (g *Generator) DynamicToolNamesForTables(tables []string) []string {
 
 	var names []string
 	for _, t := range tables {
-		names = append(names, dynamicToolNames(t, g.config.AllowWrites)...)
+		names = append(names, g.dynamicToolNames(t)...)
 		delete(g.enabledTables, t)
+		delete(g.keyedTables, t)
 	}
 	return names
 }
@@ -162,64 +212,41 @@ func (g *Generator) Prompts() []PromptDef {
 	return g.buildPrompts()
 }
 
-// InvalidateSchema clears the cached schema, forcing a refresh on next access.
-func (g *Generator) InvalidateSchema() {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	g.schemaCache = make(map[string]*schema.TableDetail)
-	g.tableSummaries = nil
-}
-
-// getTableDetail returns the detail for a table, using the cache if available.
-// Caller must hold g.mu.
-func (g *Generator) getTableDetail(ctx context.Context, tableName string) (*schema.TableDetail, error) {
-	if detail, ok := g.schemaCache[tableName]; ok {
-		return detail, nil
+// dynamicToolNames returns the Tier 2 tool names that would be generated for a table.
+func (g *Generator) dynamicToolNames(table string) []string {
+	names := []string{
+		g.toolName("query_" + table),
+		g.toolName("aggregate_" + table),
+		g.toolName("get_" + table + "_by_id"),
 	}
-	detail, err := g.conn.DescribeTable(ctx, tableName)
-	if err != nil {
-		return nil, err
+	if g.config.AllowWrites {
+		names = append(names,
+			g.toolName("insert_"+table),
+			g.toolName("update_"+table),
+			g.toolName("delete_"+table),
+		)
+		if g.keyedTables[table] {
+			names = append(names, g.toolName("upsert_"+table))
+		}
 	}
-	g.schemaCache[tableName] = detail
-	return detail, nil
+	return names
 }
 
-// getTableSummaries returns the list of tables, using the cache if available.
-func (g *Generator) getTableSummaries(ctx context.Context) ([]schema.TableSummary, error) {
-	g.mu.RLock()
-	if g.tableSummaries != nil {
-		cached := g.tableSummaries
-		g.mu.RUnlock()
-		return cached, nil
-	}
-	g.mu.RUnlock()
-
-	summaries, err := g.conn.ListTables(ctx)
-	if err != nil {
-		return nil, err
+// toolName prefixes a Tier 2 tool name with the source name, if any.
+func (g *Generator) toolName(name string) string {
+	if g.config.Source == "" {
+		return name
 	}
-
-	g.mu.Lock()
-	g.tableSummaries = summaries
-	g.mu.Unlock()
-
-	return summaries, nil
+	return g.config.Source + "_" + name
 }
 
-// dynamicToolNames returns the Tier 2 tool names that would be generated for a table.
-func dynamicToolNames(table string, allowWrites bool) []string {
-	names := []string{
-		"query_" + table,
-		"get_" + table + "_by_id",
-	}
-	if allowWrites {
-		names = append(names,
-			"insert_"+table,
-			"update_"+table,
-			"delete_"+table,
-		)
+// resourceURI builds a resource URI, inserting the source name as the first
+// path segment when one is set (e.g. "schema://analytics/tables").
+func (g *Generator) resourceURI(scheme, path string) string {
+	if g.config.Source == "" {
+		return scheme + "://" + path
 	}
-	return names
+	return scheme + "://" + g.config.Source + "/" + path
 }
 
 // Helper to create a bool pointer for use in ToolAnnotations.
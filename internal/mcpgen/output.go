@@ -0,0 +1,202 @@
+package mcpgen
+
+import (
+	"encoding/json"
+
+	"github.com/conduitdb/conduit/internal/schema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// structuredResult returns a tool result carrying v as structured content,
+// with its JSON encoding as the text fallback for clients that only read
+// text content.
+func structuredResult(v any) *mcp.CallToolResult {
+	data, _ := json.Marshal(v)
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		StructuredContent: v,
+	}
+}
+
+// objectSchema returns the JSON schema of an object with the given
+// properties.
+func objectSchema(properties map[string]any, required []string) map[string]any {
+	s := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// toolOutputSchema marshals an output schema for use with Server.AddTool.
+func toolOutputSchema(s map[string]any) json.RawMessage {
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// rowOutputSchema returns the schema of a row of detail's table as returned
+// by the query tools: an object with a typed property per column. No
+// property is required, since callers choose the columns, and expand adds
+// properties of its own.
+func (g *Generator) rowOutputSchema(detail *schema.TableDetail) map[string]any {
+	var pii *schema.PIIDetector
+	if g.config.MaskPII {
+		pii = schema.NewPIIDetector()
+	}
+	properties := make(map[string]any, len(detail.Columns))
+	for _, col := range detail.Columns {
+		masked := pii != nil && pii.DetectColumn(col.Name).Action == schema.PIIActionMask
+		properties[col.Name] = columnOutputSchema(col, masked)
+	}
+	return objectSchema(properties, nil)
+}
+
+// columnOutputSchema returns the schema of col's values after
+// normalizeValue. Exact decimals may arrive as strings, and booleans as 0
+// or 1 from databases without a boolean type. Masked values are strings
+// whatever the column type.
+func columnOutputSchema(col schema.ColumnInfo, masked bool) map[string]any {
+	var types []any
+	switch col.Type {
+	case "json":
+		// Any JSON value, or its text.
+		return map[string]any{}
+	case "integer":
+		types = []any{"integer"}
+	case "decimal":
+		types = []any{"number", "string"}
+	case "boolean":
+		types = []any{"boolean", "integer"}
+	default:
+		types = []any{"string"}
+	}
+	if masked && types[0] != "string" {
+		types = append(types, "string")
+	}
+	if col.Nullable {
+		types = append(types, "null")
+	}
+	if len(types) == 1 {
+		return map[string]any{"type": types[0]}
+	}
+	return map[string]any{"type": types}
+}
+
+// resultSetOutputSchema returns the schema of a connector.ResultSet whose
+// rows match row.
+func resultSetOutputSchema(row map[string]any) map[string]any {
+	return objectSchema(map[string]any{
+		"columns":          map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"rows":             map[string]any{"type": "array", "items": row},
+		"total":            map[string]any{"type": "integer"},
+		"has_more":         map[string]any{"type": "boolean"},
+		"next_cursor":      map[string]any{"type": "string"},
+		"truncated":        map[string]any{"type": "boolean"},
+		"truncated_reason": map[string]any{"type": "string"},
+	}, []string{"columns", "rows"})
+}
+
+// mutationOutputSchema returns the schema of a connector.MutationResult
+// whose returned rows match row.
+func mutationOutputSchema(row map[string]any) map[string]any {
+	return objectSchema(map[string]any{
+		"rows_affected":       map[string]any{"type": "integer"},
+		"returning":           map[string]any{"type": "array", "items": row},
+		"returning_truncated": map[string]any{"type": "boolean"},
+		"row_errors": map[string]any{
+			"type": "array",
+			"items": objectSchema(map[string]any{
+				"row":   map[string]any{"type": "integer"},
+				"error": map[string]any{"type": "string"},
+			}, []string{"row", "error"}),
+		},
+	}, []string{"rows_affected"})
+}
+
+// confirmableOutputSchema returns the schema of the result of an update or
+// delete tool: a connector.MutationResult that, when the write awaits
+// confirmation, carries the connector.WritePreview whose sample rows match
+// row.
+func confirmableOutputSchema(row map[string]any) map[string]any {
+	s := mutationOutputSchema(row)
+	s["properties"].(map[string]any)["preview"] = objectSchema(map[string]any{
+		"op":            map[string]any{"type": "string", "enum": []any{"update", "delete"}},
+		"table":         map[string]any{"type": "string"},
+		"rows_affected": map[string]any{"type": "integer"},
+		"sample":        map[string]any{"type": "array", "items": row},
+		"confirm_token": map[string]any{"type": "string"},
+		"expires_at":    map[string]any{"type": "string", "format": "date-time"},
+	}, []string{"op", "table", "rows_affected", "sample", "confirm_token", "expires_at"})
+	return s
+}
+
+// anyRowSchema is the schema of a row whose columns are not known up front.
+var anyRowSchema = map[string]any{"type": "object"}
+
+// listTablesOutputSchema is the output schema of list_tables.
+var listTablesOutputSchema = objectSchema(map[string]any{
+	"tables": map[string]any{
+		"type": "array",
+		"items": objectSchema(map[string]any{
+			"name": map[string]any{"type": "string"},
+			"rows": map[string]any{"type": "integer"},
+			"type": map[string]any{"type": "string", "enum": []any{"table", "view", "materialized_view"}},
+		}, []string{"name", "rows"}),
+	},
+}, []string{"tables"})
+
+// describeTableOutputSchema is the output schema of describe_table.
+var describeTableOutputSchema = objectSchema(map[string]any{
+	"name":   map[string]any{"type": "string"},
+	"schema": map[string]any{"type": "string"},
+	"columns": map[string]any{
+		"type": "array",
+		"items": objectSchema(map[string]any{
+			"name": map[string]any{"type": "string"},
+			"type": map[string]any{
+				"type": "string",
+				"enum": []any{"string", "integer", "decimal", "boolean", "datetime", "binary", "json"},
+			},
+			"nullable": map[string]any{"type": "boolean"},
+			"pk":       map[string]any{"type": "boolean"},
+			"fk":       map[string]any{"type": "string"},
+			"default":  map[string]any{"type": "string"},
+		}, []string{"name", "type"}),
+	},
+	"pk": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	"fks": map[string]any{
+		"type": "array",
+		"items": objectSchema(map[string]any{
+			"col":       map[string]any{"type": "string"},
+			"ref_table": map[string]any{"type": "string"},
+			"ref_col":   map[string]any{"type": "string"},
+		}, []string{"col", "ref_table", "ref_col"}),
+	},
+	"indexes":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	"rows":        map[string]any{"type": "integer"},
+	"description": map[string]any{"type": "string"},
+	"unique": map[string]any{
+		"type": "array",
+		"items": objectSchema(map[string]any{
+			"name":    map[string]any{"type": "string"},
+			"columns": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		}, []string{"name", "columns"}),
+	},
+}, []string{"name", "columns", "rows"})
+
+// transactionOutputSchema is the output schema of transaction.
+var transactionOutputSchema = objectSchema(map[string]any{
+	"steps": map[string]any{
+		"type": "array",
+		"items": objectSchema(map[string]any{
+			"op":            map[string]any{"type": "string", "enum": []any{"insert", "update", "delete"}},
+			"table":         map[string]any{"type": "string"},
+			"rows_affected": map[string]any{"type": "integer"},
+		}, []string{"op", "table", "rows_affected"}),
+	},
+	"committed": map[string]any{"type": "boolean"},
+	"dry_run":   map[string]any{"type": "boolean"},
+}, []string{"steps", "committed"})
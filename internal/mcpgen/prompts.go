@@ -96,6 +96,10 @@ func (g *Generator) analyzeTablePrompt() PromptDef {
 			if tableName == "" {
 				return nil, fmt.Errorf("table name is required")
 			}
+			// Confirm the table exists and is visible to this session.
+			if _, err := g.engine.DescribeTable(ctx, tableName); err != nil {
+				return nil, fmt.Errorf("table %q: %w", tableName, err)
+			}
 
 			systemMessage := fmt.Sprintf(`You are a database analysis assistant. Perform a comprehensive analysis of the "%s" table.
 
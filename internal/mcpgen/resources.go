@@ -28,13 +28,13 @@ func (g *Generator) buildResourceTemplates() []ResourceTemplateDef {
 func (g *Generator) schemaTablesResource() ResourceDef {
 	return ResourceDef{
 		Resource: &mcp.Resource{
-			URI:         "schema://tables",
+			URI:         g.resourceURI("schema", "tables"),
 			Name:        "Database Schema",
 			Description: "Complete database schema listing all tables, their columns, types, primary keys, foreign keys, and indexes as JSON.",
 			MIMEType:    "application/json",
 		},
 		Handler: func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-			summaries, err := g.conn.ListTables(ctx)
+			summaries, err := g.engine.ListTables(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list tables: %w", err)
 			}
@@ -46,7 +46,7 @@ func (g *Generator) schemaTablesResource() ResourceDef {
 
 			var tables []any
 			for _, s := range summaries {
-				detail, err := g.conn.DescribeTable(ctx, s.Name)
+				detail, err := g.engine.DescribeTable(ctx, s.Name)
 				if err != nil {
 					// Include the summary even if detail fails.
 					tables = append(tables, s)
@@ -75,7 +75,7 @@ func (g *Generator) schemaTablesResource() ResourceDef {
 func (g *Generator) schemaTableTemplate() ResourceTemplateDef {
 	return ResourceTemplateDef{
 		Template: &mcp.ResourceTemplate{
-			URITemplate: "schema://{table}",
+			URITemplate: g.resourceURI("schema", "{table}"),
 			Name:        "Table Schema",
 			Description: "Detailed schema for a specific table including columns, types, primary keys, foreign keys, and indexes.",
 			MIMEType:    "application/json",
@@ -83,9 +83,10 @@ func (g *Generator) schemaTableTemplate() ResourceTemplateDef {
 		Handler: func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 			// Extract table name from URI: "schema://tablename"
 			uri := req.Params.URI
+			prefix := g.resourceURI("schema", "")
 			tableName := ""
-			if len(uri) > len("schema://") {
-				tableName = uri[len("schema://"):]
+			if len(uri) > len(prefix) {
+				tableName = uri[len(prefix):]
 			}
 
 			if tableName == "" || tableName == "tables" {
@@ -93,7 +94,7 @@ func (g *Generator) schemaTableTemplate() ResourceTemplateDef {
 				return nil, mcp.ResourceNotFoundError(uri)
 			}
 
-			detail, err := g.conn.DescribeTable(ctx, tableName)
+			detail, err := g.engine.DescribeTable(ctx, tableName)
 			if err != nil {
 				return nil, fmt.Errorf("failed to describe table %q: %w", tableName, err)
 			}
@@ -118,13 +119,13 @@ func (g *Generator) schemaTableTemplate() ResourceTemplateDef {
 func (g *Generator) statsOverviewResource() ResourceDef {
 	return ResourceDef{
 		Resource: &mcp.Resource{
-			URI:         "stats://overview",
+			URI:         g.resourceURI("stats", "overview"),
 			Name:        "Database Statistics",
 			Description: "Overview statistics including table counts, total rows, and database driver information.",
 			MIMEType:    "application/json",
 		},
 		Handler: func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-			summaries, err := g.conn.ListTables(ctx)
+			summaries, err := g.engine.ListTables(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list tables: %w", err)
 			}
@@ -146,12 +147,15 @@ func (g *Generator) statsOverviewResource() ResourceDef {
 			}
 
 			overview := map[string]any{
-				"driver":       g.conn.DriverName(),
+				"driver":       g.engine.DriverName(),
 				"total_tables": len(summaries),
 				"total_rows":   totalRows,
 				"by_type":      tableCounts,
 				"enabled_dynamic_tables": g.EnabledTables(),
 			}
+			if g.config.Source != "" {
+				overview["source"] = g.config.Source
+			}
 
 			data, _ := json.MarshalIndent(overview, "", "  ")
 
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -16,12 +17,16 @@ func (g *Generator) buildCoreTools() []ToolDef {
 		g.listTablesTool(),
 		g.describeTableTool(),
 		g.queryTool(),
+		g.aggregateTool(),
 		g.enableTableToolsTool(),
 		g.refreshSchemaTool(),
 		g.listProceduresTool(),
 		g.callProcedureTool(),
 	}
 
+	if g.config.AllowWrites {
+		tools = append(tools, g.transactionTool())
+	}
 	if g.config.AllowRawSQL {
 		tools = append(tools, g.rawSQLTool())
 		if g.config.AllowWrites {
@@ -37,26 +42,33 @@ func (g *Generator) buildCoreTools() []ToolDef {
 func (g *Generator) listTablesTool() ToolDef {
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "list_tables",
-			Description: "List all tables and views in the database with row counts. Use this first to discover available data.",
-			InputSchema: toolInputSchema(map[string]any{}, nil),
+			Name:         "list_tables",
+			Description:  "List all tables and views in the database with row counts. Use this first to discover available data.",
+			InputSchema:  toolInputSchema(map[string]any{}, nil),
+			OutputSchema: toolOutputSchema(listTablesOutputSchema),
 			Annotations: &mcp.ToolAnnotations{
-				ReadOnlyHint:    true,
-				OpenWorldHint:   boolPtr(false),
-				IdempotentHint:  true,
+				ReadOnlyHint:   true,
+				OpenWorldHint:  boolPtr(false),
+				IdempotentHint: true,
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			summaries, err := g.conn.ListTables(ctx)
+			summaries, err := g.engine.ListTables(ctx)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("failed to list tables: %w", err))
 				return result, nil
 			}
 
+			if summaries == nil {
+				summaries = []schema.TableSummary{}
+			}
+			// The text keeps the bare array clients have always read;
+			// structured content must be an object.
 			data, _ := json.Marshal(summaries)
 			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+				Content:           []mcp.Content{&mcp.TextContent{Text: string(data)}},
+				StructuredContent: map[string]any{"tables": summaries},
 			}, nil
 		},
 	}
@@ -75,10 +87,11 @@ func (g *Generator) describeTableTool() ToolDef {
 					"description": "Name of the table to describe",
 				},
 			}, []string{"table"}),
+			OutputSchema: toolOutputSchema(describeTableOutputSchema),
 			Annotations: &mcp.ToolAnnotations{
-				ReadOnlyHint:    true,
-				OpenWorldHint:   boolPtr(false),
-				IdempotentHint:  true,
+				ReadOnlyHint:   true,
+				OpenWorldHint:  boolPtr(false),
+				IdempotentHint: true,
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -97,17 +110,14 @@ func (g *Generator) describeTableTool() ToolDef {
 				return result, nil
 			}
 
-			detail, err := g.conn.DescribeTable(ctx, args.Table)
+			detail, err := g.engine.DescribeTable(ctx, args.Table)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("failed to describe table %q: %w", args.Table, err))
 				return result, nil
 			}
 
-			data, _ := json.Marshal(detail)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-			}, nil
+			return structuredResult(detail), nil
 		},
 	}
 }
@@ -118,7 +128,7 @@ func (g *Generator) queryTool() ToolDef {
 	return ToolDef{
 		Tool: &mcp.Tool{
 			Name:        "query",
-			Description: "Query any table with optional filtering, ordering, and pagination. Returns rows as JSON.",
+			Description: "Query any table with optional filtering, ordering, and pagination. Returns rows as JSON, with has_more and a next_cursor for the following page.",
 			InputSchema: toolInputSchema(map[string]any{
 				"table": map[string]any{
 					"type":        "string",
@@ -147,21 +157,30 @@ func (g *Generator) queryTool() ToolDef {
 					"description": "Number of rows to skip",
 					"default":     0,
 				},
+				"cursor": cursorProperty(),
+				"count":  countProperty(),
+				"expand": expandProperty(),
+				"format": g.formatProperty(),
 			}, []string{"table"}),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(anyRowSchema)),
 			Annotations: &mcp.ToolAnnotations{
-				ReadOnlyHint:    true,
-				OpenWorldHint:   boolPtr(false),
-				IdempotentHint:  true,
+				ReadOnlyHint:   true,
+				OpenWorldHint:  boolPtr(false),
+				IdempotentHint: true,
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var args struct {
-				Table   string   `json:"table"`
-				Columns []string `json:"columns"`
-				Filter  string   `json:"filter"`
-				OrderBy string   `json:"order_by"`
-				Limit   int      `json:"limit"`
-				Offset  int      `json:"offset"`
+				Table   string      `json:"table"`
+				Columns []string    `json:"columns"`
+				Filter  string      `json:"filter"`
+				OrderBy string      `json:"order_by"`
+				Limit   int         `json:"limit"`
+				Offset  int         `json:"offset"`
+				Cursor  string      `json:"cursor"`
+				Count   bool        `json:"count"`
+				Expand  []expandArg `json:"expand"`
+				Format  string      `json:"format"`
 			}
 			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 				result := &mcp.CallToolResult{}
@@ -175,6 +194,13 @@ func (g *Generator) queryTool() ToolDef {
 				return result, nil
 			}
 
+			format, err := g.resultFormat(args.Format)
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(err)
+				return result, nil
+			}
+
 			// Apply max rows limit.
 			limit := args.Limit
 			if limit <= 0 {
@@ -184,13 +210,16 @@ func (g *Generator) queryTool() ToolDef {
 				limit = g.config.MaxRows
 			}
 
-			rs, err := g.conn.Select(ctx, connector.SelectRequest{
+			rs, err := g.engine.Select(ctx, connector.SelectRequest{
 				Table:   args.Table,
 				Columns: args.Columns,
 				Filter:  args.Filter,
 				OrderBy: args.OrderBy,
 				Limit:   limit,
 				Offset:  args.Offset,
+				Cursor:  args.Cursor,
+				Count:   args.Count,
+				Expand:  expandRequests(args.Expand),
 			})
 			if err != nil {
 				result := &mcp.CallToolResult{}
@@ -198,10 +227,249 @@ func (g *Generator) queryTool() ToolDef {
 				return result, nil
 			}
 
-			data, _ := json.Marshal(rs)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-			}, nil
+			return resultContent(rs, format), nil
+		},
+	}
+}
+
+// cursorProperty returns the input schema of the cursor argument of query
+// and query_{table}.
+func cursorProperty() map[string]any {
+	return map[string]any{
+		"type":        "string",
+		"description": "next_cursor from the previous page of the same query; continues after its last row (use instead of offset)",
+	}
+}
+
+// countProperty returns the input schema of the count argument of query and
+// query_{table}.
+func countProperty() map[string]any {
+	return map[string]any{
+		"type":        "boolean",
+		"description": "Also return the total number of matching rows",
+	}
+}
+
+// expandArg is one relationship in the expand argument of query and
+// query_{table}.
+type expandArg struct {
+	Table   string   `json:"table"`
+	Via     string   `json:"via"`
+	As      string   `json:"as"`
+	Columns []string `json:"columns"`
+	Limit   int      `json:"limit"`
+	Flatten bool     `json:"flatten"`
+}
+
+// expandRequests converts expand tool arguments into connector expansions.
+func expandRequests(args []expandArg) []connector.Expand {
+	var expands []connector.Expand
+	for _, a := range args {
+		expands = append(expands, connector.Expand{
+			Table:   a.Table,
+			Via:     a.Via,
+			As:      a.As,
+			Columns: a.Columns,
+			Limit:   a.Limit,
+			Flatten: a.Flatten,
+		})
+	}
+	return expands
+}
+
+// expandProperty returns the input schema of the expand argument.
+func expandProperty() map[string]any {
+	return map[string]any{
+		"type": "array",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"table": map[string]any{
+					"type":        "string",
+					"description": "Related table: one this table's foreign key references (many-to-one), or one with a foreign key referencing this table (one-to-many)",
+				},
+				"via": map[string]any{
+					"type":        "string",
+					"description": "Foreign key column, when more than one relates the tables",
+				},
+				"as": map[string]any{
+					"type":        "string",
+					"description": "Result key for the related rows (default: the table name)",
+				},
+				"columns": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Columns of the related table (omit for all)",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum related rows per row for one-to-many relationships",
+					"default":     10,
+				},
+				"flatten": map[string]any{
+					"type":        "boolean",
+					"description": "For many-to-one relationships, add the related columns to each row as \"<as>.<column>\" instead of nesting the related row",
+				},
+			},
+			"required": []string{"table"},
+		},
+		"description": "Foreign key relationships to follow, returning related rows with each row",
+	}
+}
+
+// --- aggregate ---
+
+func (g *Generator) aggregateTool() ToolDef {
+	properties := aggregateProperties(nil)
+	properties["table"] = map[string]any{
+		"type":        "string",
+		"description": "Name of the table to aggregate",
+	}
+	properties["format"] = g.formatProperty()
+	return ToolDef{
+		Tool: &mcp.Tool{
+			Name:         "aggregate",
+			Description:  "Group rows of any table and compute count, sum, avg, min, or max per group (e.g. orders per status). Returns one row per group as JSON.",
+			InputSchema:  toolInputSchema(properties, []string{"table"}),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(anyRowSchema)),
+			Annotations: &mcp.ToolAnnotations{
+				ReadOnlyHint:   true,
+				OpenWorldHint:  boolPtr(false),
+				IdempotentHint: true,
+			},
+		},
+		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args aggregateArgs
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("invalid arguments: %w", err))
+				return result, nil
+			}
+
+			if args.Table == "" {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("table name is required"))
+				return result, nil
+			}
+
+			format, err := g.resultFormat(args.Format)
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(err)
+				return result, nil
+			}
+
+			rs, err := g.engine.Aggregate(ctx, g.aggregateRequest(args.Table, args))
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("aggregate failed: %w", err))
+				return result, nil
+			}
+
+			return resultContent(rs, format), nil
+		},
+	}
+}
+
+// aggregateArgs are the arguments of aggregate and aggregate_{table}.
+type aggregateArgs struct {
+	Table      string   `json:"table"`
+	GroupBy    []string `json:"group_by"`
+	Aggregates []struct {
+		Function string `json:"function"`
+		Column   string `json:"column"`
+		Alias    string `json:"alias"`
+	} `json:"aggregates"`
+	Filter  string `json:"filter"`
+	Having  string `json:"having"`
+	OrderBy string `json:"order_by"`
+	Limit   int    `json:"limit"`
+	Format  string `json:"format"`
+}
+
+// aggregateRequest converts aggregate tool arguments into a request on
+// table, applying the default and maximum row limits.
+func (g *Generator) aggregateRequest(table string, args aggregateArgs) connector.AggregateRequest {
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > g.config.MaxRows {
+		limit = g.config.MaxRows
+	}
+	aggs := make([]connector.Aggregate, len(args.Aggregates))
+	for i, a := range args.Aggregates {
+		aggs[i] = connector.Aggregate{
+			Func:   connector.AggregateFunc(strings.ToUpper(a.Function)),
+			Column: a.Column,
+			Alias:  a.Alias,
+		}
+	}
+	return connector.AggregateRequest{
+		Table:        table,
+		GroupBy:      args.GroupBy,
+		Aggregates:   aggs,
+		Filter:       args.Filter,
+		HavingFilter: args.Having,
+		OrderBy:      args.OrderBy,
+		Limit:        limit,
+	}
+}
+
+// aggregateProperties returns the input schema properties shared by the
+// aggregate tools. When columns is non-nil, column names are restricted to
+// it.
+func aggregateProperties(columns []any) map[string]any {
+	column := func() map[string]any {
+		prop := map[string]any{"type": "string"}
+		if columns != nil {
+			prop["enum"] = columns
+		}
+		return prop
+	}
+	aggColumn := column()
+	aggColumn["description"] = "Column to aggregate (omit to count rows)"
+	return map[string]any{
+		"group_by": map[string]any{
+			"type":        "array",
+			"items":       column(),
+			"description": "Columns to group by (omit for a single summary row)",
+		},
+		"aggregates": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"function": map[string]any{
+						"type": "string",
+						"enum": []any{"count", "sum", "avg", "min", "max"},
+					},
+					"column": aggColumn,
+					"alias": map[string]any{
+						"type":        "string",
+						"description": "Result column name (default e.g. sum_total)",
+					},
+				},
+				"required": []string{"function"},
+			},
+			"description": "Aggregates to compute (default: count of rows)",
+		},
+		"filter": map[string]any{
+			"type":        "string",
+			"description": "SQL WHERE clause condition applied before grouping",
+		},
+		"having": map[string]any{
+			"type":        "string",
+			"description": "Condition on group-by columns and aggregate aliases (e.g., \"count > 10\")",
+		},
+		"order_by": map[string]any{
+			"type":        "string",
+			"description": "Group-by columns or aggregate aliases to sort by (e.g., \"count DESC\")",
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Maximum number of groups to return",
+			"default":     100,
 		},
 	}
 }
@@ -212,7 +480,7 @@ func (g *Generator) enableTableToolsTool() ToolDef {
 	return ToolDef{
 		Tool: &mcp.Tool{
 			Name:        "enable_table_tools",
-			Description: fmt.Sprintf("Load typed, per-table CRUD tools for the specified tables. Max %d tables at once. After calling this, you'll get query_{table}, get_{table}_by_id, and (if writes are enabled) insert/update/delete tools.", MaxDynamicTables),
+			Description: fmt.Sprintf("Load typed, per-table CRUD tools for the specified tables. Max %d tables at once. After calling this, you'll get query_{table}, aggregate_{table}, get_{table}_by_id, and (if writes are enabled) insert/update/delete tools.", MaxDynamicTables),
 			InputSchema: toolInputSchema(map[string]any{
 				"tables": map[string]any{
 					"type":        "array",
@@ -254,7 +522,7 @@ func (g *Generator) enableTableToolsTool() ToolDef {
 			// when AddTool is called.
 			if g.OnRegisterTool != nil {
 				for _, td := range toolDefs {
-					g.OnRegisterTool(td.Tool, td.Handler)
+					g.OnRegisterTool(td)
 				}
 			}
 
@@ -290,10 +558,14 @@ func (g *Generator) refreshSchemaTool() ToolDef {
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			g.InvalidateSchema()
+			if err := g.engine.RefreshSchema(ctx); err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("schema refresh failed: %w", err))
+				return result, nil
+			}
 
 			// Re-fetch summaries to verify the refresh worked.
-			summaries, err := g.getTableSummaries(ctx)
+			summaries, err := g.engine.ListTables(ctx)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("schema refresh failed: %w", err))
@@ -327,7 +599,7 @@ func (g *Generator) listProceduresTool() ToolDef {
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			procs, err := g.conn.ListProcedures(ctx)
+			procs, err := g.engine.ListProcedures(ctx)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("failed to list procedures: %w", err))
@@ -358,16 +630,19 @@ func (g *Generator) callProcedureTool() ToolDef {
 					"type":        "object",
 					"description": "Key-value pairs of parameter names and values",
 				},
+				"format": g.formatProperty(),
 			}, []string{"name"}),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(anyRowSchema)),
 			Annotations: &mcp.ToolAnnotations{
-				ReadOnlyHint:   false,
-				OpenWorldHint:  boolPtr(false),
+				ReadOnlyHint:  false,
+				OpenWorldHint: boolPtr(false),
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var args struct {
 				Name   string         `json:"name"`
 				Params map[string]any `json:"params"`
+				Format string         `json:"format"`
 			}
 			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 				result := &mcp.CallToolResult{}
@@ -381,7 +656,14 @@ func (g *Generator) callProcedureTool() ToolDef {
 				return result, nil
 			}
 
-			rs, err := g.conn.CallProcedure(ctx, connector.ProcedureCallRequest{
+			format, err := g.resultFormat(args.Format)
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(err)
+				return result, nil
+			}
+
+			rs, err := g.engine.CallProcedure(ctx, connector.ProcedureCallRequest{
 				Name:   args.Name,
 				Params: args.Params,
 			})
@@ -391,10 +673,7 @@ func (g *Generator) callProcedureTool() ToolDef {
 				return result, nil
 			}
 
-			data, _ := json.Marshal(rs)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-			}, nil
+			return resultContent(rs, format), nil
 		},
 	}
 }
@@ -411,15 +690,18 @@ func (g *Generator) rawSQLTool() ToolDef {
 					"type":        "string",
 					"description": "SQL SELECT query to execute",
 				},
+				"format": g.formatProperty(),
 			}, []string{"sql"}),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(anyRowSchema)),
 			Annotations: &mcp.ToolAnnotations{
-				ReadOnlyHint:    true,
-				OpenWorldHint:   boolPtr(false),
+				ReadOnlyHint:  true,
+				OpenWorldHint: boolPtr(false),
 			},
 		},
 		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var args struct {
-				SQL string `json:"sql"`
+				SQL    string `json:"sql"`
+				Format string `json:"format"`
 			}
 			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 				result := &mcp.CallToolResult{}
@@ -433,35 +715,23 @@ func (g *Generator) rawSQLTool() ToolDef {
 				return result, nil
 			}
 
-			// Basic validation: only allow SELECT-like statements.
-			upper := strings.TrimSpace(strings.ToUpper(args.SQL))
-			if !strings.HasPrefix(upper, "SELECT") &&
-				!strings.HasPrefix(upper, "WITH") &&
-				!strings.HasPrefix(upper, "EXPLAIN") &&
-				!strings.HasPrefix(upper, "SHOW") {
+			format, err := g.resultFormat(args.Format)
+			if err != nil {
 				result := &mcp.CallToolResult{}
-				result.SetError(fmt.Errorf("raw_sql only allows SELECT, WITH, EXPLAIN, and SHOW statements"))
+				result.SetError(err)
 				return result, nil
 			}
 
-			// Use Select with the raw SQL passed as the table (the connector
-			// will need to support raw SQL mode). For now we use a special
-			// convention: if Table starts with "__raw__:", the connector treats
-			// it as raw SQL.
-			rs, err := g.conn.Select(ctx, connector.SelectRequest{
-				Table: "__raw__:" + args.SQL,
-				Limit: g.config.MaxRows,
-			})
+			// The engine parses the statement and rejects anything that
+			// is not a single read-only query.
+			rs, err := g.engine.QueryRaw(ctx, args.SQL)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("raw SQL query failed: %w", err))
 				return result, nil
 			}
 
-			data, _ := json.Marshal(rs)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-			}, nil
+			return resultContent(rs, format), nil
 		},
 	}
 }
@@ -479,6 +749,7 @@ func (g *Generator) executeSQLTool() ToolDef {
 					"description": "SQL statement to execute",
 				},
 			}, []string{"sql"}),
+			OutputSchema: toolOutputSchema(mutationOutputSchema(anyRowSchema)),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:    false,
 				DestructiveHint: boolPtr(true),
@@ -501,21 +772,115 @@ func (g *Generator) executeSQLTool() ToolDef {
 				return result, nil
 			}
 
-			// Use the same raw SQL convention with a mutation flag.
-			rs, err := g.conn.Select(ctx, connector.SelectRequest{
-				Table: "__exec__:" + args.SQL,
-				Limit: g.config.MaxRows,
-			})
+			mr, err := g.engine.ExecRaw(ctx, args.SQL)
 			if err != nil {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("SQL execution failed: %w", err))
 				return result, nil
 			}
 
-			data, _ := json.Marshal(rs)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-			}, nil
+			return structuredResult(mr), nil
+		},
+	}
+}
+
+// --- transaction ---
+
+func (g *Generator) transactionTool() ToolDef {
+	return ToolDef{
+		Tool: &mcp.Tool{
+			Name:        "transaction",
+			Description: "Run an ordered list of insert, update, and delete steps in a single database transaction: either every step is applied or none is. Each step is validated like the matching insert_/update_/delete_ tool. With dry_run, every step runs and the transaction is then rolled back, reporting the rows each step would affect.",
+			InputSchema: toolInputSchema(map[string]any{
+				"steps": map[string]any{
+					"type":        "array",
+					"description": "Steps to run, in order",
+					"minItems":    1,
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"op": map[string]any{
+								"type": "string",
+								"enum": []any{"insert", "update", "delete"},
+							},
+							"table": map[string]any{
+								"type":        "string",
+								"description": "Table to write",
+							},
+							"rows": map[string]any{
+								"type":        "array",
+								"description": "Row objects to insert (insert only)",
+								"items":       map[string]any{"type": "object"},
+							},
+							"set": map[string]any{
+								"type":        "object",
+								"description": "Column-value pairs to update (update only)",
+							},
+							"filter": map[string]any{
+								"type":        "string",
+								"description": "SQL WHERE clause selecting the rows to update or delete (required for update and delete)",
+							},
+						},
+						"required": []string{"op", "table"},
+					},
+				},
+				"dry_run": map[string]any{
+					"type":        "boolean",
+					"description": "Run every step, then roll back instead of committing",
+					"default":     false,
+				},
+			}, []string{"steps"}),
+			OutputSchema: toolOutputSchema(transactionOutputSchema),
+			Annotations: &mcp.ToolAnnotations{
+				ReadOnlyHint:    false,
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args struct {
+				Steps []struct {
+					Op     string           `json:"op"`
+					Table  string           `json:"table"`
+					Rows   []map[string]any `json:"rows"`
+					Set    map[string]any   `json:"set"`
+					Filter string           `json:"filter"`
+				} `json:"steps"`
+				DryRun bool `json:"dry_run"`
+			}
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("invalid arguments: %w", err))
+				return result, nil
+			}
+
+			txReq := connector.TransactionRequest{
+				Steps:  make([]connector.WriteStep, len(args.Steps)),
+				DryRun: args.DryRun,
+			}
+			for i, step := range args.Steps {
+				switch step.Op {
+				case "insert":
+					txReq.Steps[i].Insert = &connector.InsertRequest{Table: step.Table, Rows: step.Rows}
+				case "update":
+					txReq.Steps[i].Update = &connector.UpdateRequest{Table: step.Table, Filter: step.Filter, Set: step.Set}
+				case "delete":
+					txReq.Steps[i].Delete = &connector.DeleteRequest{Table: step.Table, Filter: step.Filter}
+				default:
+					result := &mcp.CallToolResult{}
+					result.SetError(fmt.Errorf("step %d: unsupported op %q (expected insert, update, or delete)", i+1, step.Op))
+					return result, nil
+				}
+			}
+
+			tr, err := g.engine.Transaction(ctx, txReq)
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("transaction failed: %w", err))
+				return result, nil
+			}
+
+			return structuredResult(tr), nil
 		},
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/conduitdb/conduit/internal/access"
 	"github.com/conduitdb/conduit/internal/connector"
 	"github.com/conduitdb/conduit/internal/schema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -15,6 +16,7 @@ import (
 func (g *Generator) buildDynamicTools(detail *schema.TableDetail) []ToolDef {
 	tools := []ToolDef{
 		g.queryTableTool(detail),
+		g.aggregateTableTool(detail),
 	}
 
 	// Only generate get_by_id if the table has a primary key.
@@ -28,6 +30,10 @@ func (g *Generator) buildDynamicTools(detail *schema.TableDetail) []ToolDef {
 			g.updateTableTool(detail),
 			g.deleteTableTool(detail),
 		)
+		// Upserts need a key to find the rows they update.
+		if len(detail.PrimaryKey) > 0 || len(detail.UniqueKeys) > 0 {
+			tools = append(tools, g.upsertTableTool(detail))
+		}
 	}
 
 	return tools
@@ -60,7 +66,7 @@ func (g *Generator) queryTableTool(detail *schema.TableDetail) ToolDef {
 
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "query_" + detail.Name,
+			Name:        g.toolName("query_" + detail.Name),
 			Description: fmt.Sprintf("Query the %s table. Columns: %s", detail.Name, strings.Join(colDescs, ", ")),
 			InputSchema: toolInputSchema(map[string]any{
 				"columns": map[string]any{
@@ -68,9 +74,10 @@ func (g *Generator) queryTableTool(detail *schema.TableDetail) ToolDef {
 					"items":       map[string]any{"type": "string", "enum": columnNames},
 					"description": "Columns to select (omit for all)",
 				},
+				"where": whereProperty(detail),
 				"filter": map[string]any{
 					"type":        "string",
-					"description": "SQL WHERE clause condition",
+					"description": "SQL WHERE clause condition (alternative to where)",
 				},
 				"order_by": map[string]any{
 					"type":        "string",
@@ -84,7 +91,12 @@ func (g *Generator) queryTableTool(detail *schema.TableDetail) ToolDef {
 					"type":    "integer",
 					"default": 0,
 				},
+				"cursor": cursorProperty(),
+				"count":  countProperty(),
+				"expand": expandProperty(),
+				"format": g.formatProperty(),
 			}, nil),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(g.rowOutputSchema(detail))),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:   true,
 				OpenWorldHint:  boolPtr(false),
@@ -92,6 +104,8 @@ func (g *Generator) queryTableTool(detail *schema.TableDetail) ToolDef {
 			},
 		},
 		Handler: g.makeQueryHandler(detail.Name),
+		Table:   detail.Name,
+		Verb:    access.VerbSelect,
 	}
 }
 
@@ -99,11 +113,16 @@ func (g *Generator) queryTableTool(detail *schema.TableDetail) ToolDef {
 func (g *Generator) makeQueryHandler(tableName string) mcp.ToolHandler {
 	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var args struct {
-			Columns []string `json:"columns"`
-			Filter  string   `json:"filter"`
-			OrderBy string   `json:"order_by"`
-			Limit   int      `json:"limit"`
-			Offset  int      `json:"offset"`
+			Columns []string        `json:"columns"`
+			Where   json.RawMessage `json:"where"`
+			Filter  string          `json:"filter"`
+			OrderBy string          `json:"order_by"`
+			Limit   int             `json:"limit"`
+			Offset  int             `json:"offset"`
+			Cursor  string          `json:"cursor"`
+			Count   bool            `json:"count"`
+			Expand  []expandArg     `json:"expand"`
+			Format  string          `json:"format"`
 		}
 		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 			result := &mcp.CallToolResult{}
@@ -111,6 +130,25 @@ func (g *Generator) makeQueryHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
+		format, err := g.resultFormat(args.Format)
+		if err != nil {
+			result := &mcp.CallToolResult{}
+			result.SetError(err)
+			return result, nil
+		}
+
+		// The engine compiles a JSON object filter into the same
+		// parameterized conditions as a string filter.
+		filter := args.Filter
+		if len(args.Where) > 0 && string(args.Where) != "null" {
+			if filter != "" {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("pass either where or filter, not both"))
+				return result, nil
+			}
+			filter = string(args.Where)
+		}
+
 		limit := args.Limit
 		if limit <= 0 {
 			limit = 100
@@ -119,13 +157,16 @@ func (g *Generator) makeQueryHandler(tableName string) mcp.ToolHandler {
 			limit = g.config.MaxRows
 		}
 
-		rs, err := g.conn.Select(ctx, connector.SelectRequest{
+		rs, err := g.engine.Select(ctx, connector.SelectRequest{
 			Table:   tableName,
 			Columns: args.Columns,
-			Filter:  args.Filter,
+			Filter:  filter,
 			OrderBy: args.OrderBy,
 			Limit:   limit,
 			Offset:  args.Offset,
+			Cursor:  args.Cursor,
+			Count:   args.Count,
+			Expand:  expandRequests(args.Expand),
 		})
 		if err != nil {
 			result := &mcp.CallToolResult{}
@@ -133,10 +174,68 @@ func (g *Generator) makeQueryHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
-		data, _ := json.Marshal(rs)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-		}, nil
+		return resultContent(rs, format), nil
+	}
+}
+
+// --- aggregate_{table} ---
+
+func (g *Generator) aggregateTableTool(detail *schema.TableDetail) ToolDef {
+	columnNames := make([]any, len(detail.Columns))
+	var numeric []string
+	for i, col := range detail.Columns {
+		columnNames[i] = col.Name
+		if col.Type == "integer" || col.Type == "decimal" {
+			numeric = append(numeric, col.Name)
+		}
+	}
+	desc := fmt.Sprintf("Group rows of the %s table and compute count, sum, avg, min, or max per group.", detail.Name)
+	if len(numeric) > 0 {
+		desc += " Numeric columns for sum/avg: " + strings.Join(numeric, ", ")
+	}
+
+	properties := aggregateProperties(columnNames)
+	properties["format"] = g.formatProperty()
+
+	tableName := detail.Name
+	return ToolDef{
+		Tool: &mcp.Tool{
+			Name:         g.toolName("aggregate_" + detail.Name),
+			Description:  desc,
+			InputSchema:  toolInputSchema(properties, nil),
+			OutputSchema: toolOutputSchema(resultSetOutputSchema(anyRowSchema)),
+			Annotations: &mcp.ToolAnnotations{
+				ReadOnlyHint:   true,
+				OpenWorldHint:  boolPtr(false),
+				IdempotentHint: true,
+			},
+		},
+		Handler: func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args aggregateArgs
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("invalid arguments: %w", err))
+				return result, nil
+			}
+
+			format, err := g.resultFormat(args.Format)
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(err)
+				return result, nil
+			}
+
+			rs, err := g.engine.Aggregate(ctx, g.aggregateRequest(tableName, args))
+			if err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("aggregate %s failed: %w", tableName, err))
+				return result, nil
+			}
+
+			return resultContent(rs, format), nil
+		},
+		Table: detail.Name,
+		Verb:  access.VerbSelect,
 	}
 }
 
@@ -165,9 +264,10 @@ func (g *Generator) getByIDTool(detail *schema.TableDetail) ToolDef {
 
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "get_" + detail.Name + "_by_id",
-			Description: fmt.Sprintf("Get a single %s record by primary key (%s).", detail.Name, pkDesc),
-			InputSchema: toolInputSchema(properties, required),
+			Name:         g.toolName("get_" + detail.Name + "_by_id"),
+			Description:  fmt.Sprintf("Get a single %s record by primary key (%s).", detail.Name, pkDesc),
+			InputSchema:  toolInputSchema(properties, required),
+			OutputSchema: toolOutputSchema(g.rowOutputSchema(detail)),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:   true,
 				OpenWorldHint:  boolPtr(false),
@@ -175,6 +275,8 @@ func (g *Generator) getByIDTool(detail *schema.TableDetail) ToolDef {
 			},
 		},
 		Handler: g.makeGetByIDHandler(detail),
+		Table:   detail.Name,
+		Verb:    access.VerbSelect,
 	}
 }
 
@@ -190,30 +292,24 @@ func (g *Generator) makeGetByIDHandler(detail *schema.TableDetail) mcp.ToolHandl
 			return result, nil
 		}
 
-		// Build a filter from PK columns.
-		var conditions []string
-		for _, pk := range pkCols {
+		// Build a parameterized filter from PK columns.
+		values := make([]any, len(pkCols))
+		conditions := make([]string, len(pkCols))
+		for i, pk := range pkCols {
 			val, ok := args[pk]
 			if !ok {
 				result := &mcp.CallToolResult{}
 				result.SetError(fmt.Errorf("missing primary key value for %q", pk))
 				return result, nil
 			}
-			// Format the value for the filter string.
-			switch v := val.(type) {
-			case string:
-				conditions = append(conditions, fmt.Sprintf("%s = '%s'", pk, v))
-			default:
-				conditions = append(conditions, fmt.Sprintf("%s = %v", pk, v))
-			}
+			values[i] = val
+			conditions[i] = fmt.Sprintf("%s = %v", pk, val)
 		}
 
-		filter := strings.Join(conditions, " AND ")
-
-		rs, err := g.conn.Select(ctx, connector.SelectRequest{
-			Table:  tableName,
-			Filter: filter,
-			Limit:  1,
+		rs, err := g.engine.Select(ctx, connector.SelectRequest{
+			Table: tableName,
+			Where: connector.Eq(pkCols, values),
+			Limit: 1,
 		})
 		if err != nil {
 			result := &mcp.CallToolResult{}
@@ -223,14 +319,13 @@ func (g *Generator) makeGetByIDHandler(detail *schema.TableDetail) mcp.ToolHandl
 
 		if len(rs.Rows) == 0 {
 			result := &mcp.CallToolResult{}
-			result.SetError(fmt.Errorf("no %s record found with %s", tableName, filter))
+			result.SetError(fmt.Errorf("no %s record found with %s", tableName, strings.Join(conditions, " AND ")))
 			return result, nil
 		}
 
-		data, _ := json.Marshal(rs.Rows[0])
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-		}, nil
+		var cut int
+		normalizeRow(rs.Rows[0], &cut)
+		return structuredResult(rs.Rows[0]), nil
 	}
 }
 
@@ -247,8 +342,8 @@ func (g *Generator) insertTableTool(detail *schema.TableDetail) ToolDef {
 
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "insert_" + detail.Name,
-			Description: fmt.Sprintf("Insert one or more rows into the %s table.", detail.Name),
+			Name:        g.toolName("insert_" + detail.Name),
+			Description: fmt.Sprintf("Insert one or more rows into the %s table. Returns the inserted rows, with generated keys and defaults filled in, where the database can report them. If any row fails, none are inserted, unless continue_on_error is set.", detail.Name),
 			InputSchema: toolInputSchema(map[string]any{
 				"rows": map[string]any{
 					"type":        "array",
@@ -258,7 +353,13 @@ func (g *Generator) insertTableTool(detail *schema.TableDetail) ToolDef {
 						"properties": properties,
 					},
 				},
+				"continue_on_error": map[string]any{
+					"type":        "boolean",
+					"description": "Insert the rows that can be inserted, and report each row that fails, by its position from 1, in row_errors",
+					"default":     false,
+				},
 			}, []string{"rows"}),
+			OutputSchema: toolOutputSchema(mutationOutputSchema(g.rowOutputSchema(detail))),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:   false,
 				OpenWorldHint:  boolPtr(false),
@@ -266,13 +367,16 @@ func (g *Generator) insertTableTool(detail *schema.TableDetail) ToolDef {
 			},
 		},
 		Handler: g.makeInsertHandler(detail.Name),
+		Table:   detail.Name,
+		Verb:    access.VerbInsert,
 	}
 }
 
 func (g *Generator) makeInsertHandler(tableName string) mcp.ToolHandler {
 	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var args struct {
-			Rows []map[string]any `json:"rows"`
+			Rows            []map[string]any `json:"rows"`
+			ContinueOnError bool             `json:"continue_on_error"`
 		}
 		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 			result := &mcp.CallToolResult{}
@@ -286,9 +390,11 @@ func (g *Generator) makeInsertHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
-		mr, err := g.conn.Insert(ctx, connector.InsertRequest{
-			Table: tableName,
-			Rows:  args.Rows,
+		mr, err := g.engine.Insert(ctx, connector.InsertRequest{
+			Table:           tableName,
+			Rows:            args.Rows,
+			Returning:       true,
+			ContinueOnError: args.ContinueOnError,
 		})
 		if err != nil {
 			result := &mcp.CallToolResult{}
@@ -296,10 +402,7 @@ func (g *Generator) makeInsertHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
-		data, _ := json.Marshal(mr)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-		}, nil
+		return mutationContent(mr), nil
 	}
 }
 
@@ -316,9 +419,9 @@ func (g *Generator) updateTableTool(detail *schema.TableDetail) ToolDef {
 
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "update_" + detail.Name,
+			Name:        g.toolName("update_" + detail.Name),
 			Description: fmt.Sprintf("Update rows in the %s table matching a filter condition.", detail.Name),
-			InputSchema: toolInputSchema(map[string]any{
+			InputSchema: toolInputSchema(withConfirmProperties(map[string]any{
 				"filter": map[string]any{
 					"type":        "string",
 					"description": "SQL WHERE clause to identify rows to update (REQUIRED to prevent accidental full-table updates)",
@@ -328,7 +431,9 @@ func (g *Generator) updateTableTool(detail *schema.TableDetail) ToolDef {
 					"description": "Column-value pairs to update",
 					"properties":  properties,
 				},
-			}, []string{"filter", "set"}),
+				"returning": returningProperty("updated"),
+			}, "update"), []string{"filter", "set"}),
+			OutputSchema: toolOutputSchema(confirmableOutputSchema(g.rowOutputSchema(detail))),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:    false,
 				DestructiveHint: boolPtr(true),
@@ -337,14 +442,19 @@ func (g *Generator) updateTableTool(detail *schema.TableDetail) ToolDef {
 			},
 		},
 		Handler: g.makeUpdateHandler(detail.Name),
+		Table:   detail.Name,
+		Verb:    access.VerbUpdate,
 	}
 }
 
 func (g *Generator) makeUpdateHandler(tableName string) mcp.ToolHandler {
 	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var args struct {
-			Filter string         `json:"filter"`
-			Set    map[string]any `json:"set"`
+			Filter       string         `json:"filter"`
+			Set          map[string]any `json:"set"`
+			Returning    bool           `json:"returning"`
+			Preview      bool           `json:"preview"`
+			ConfirmToken string         `json:"confirm_token"`
 		}
 		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 			result := &mcp.CallToolResult{}
@@ -364,21 +474,25 @@ func (g *Generator) makeUpdateHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
-		mr, err := g.conn.Update(ctx, connector.UpdateRequest{
-			Table:  tableName,
-			Filter: args.Filter,
-			Set:    args.Set,
-		})
+		upd := connector.UpdateRequest{
+			Table:     tableName,
+			Filter:    args.Filter,
+			Set:       args.Set,
+			Returning: args.Returning,
+		}
+		mr, err := confirmedWrite(ctx, req, args.Preview, args.ConfirmToken,
+			func() (*connector.WritePreview, error) { return g.engine.PreviewUpdate(ctx, upd) },
+			func(token string) (*connector.MutationResult, error) {
+				upd.ConfirmToken = token
+				return g.engine.Update(ctx, upd)
+			})
 		if err != nil {
 			result := &mcp.CallToolResult{}
 			result.SetError(fmt.Errorf("update %s failed: %w", tableName, err))
 			return result, nil
 		}
 
-		data, _ := json.Marshal(mr)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-		}, nil
+		return mutationContent(mr), nil
 	}
 }
 
@@ -387,14 +501,16 @@ func (g *Generator) makeUpdateHandler(tableName string) mcp.ToolHandler {
 func (g *Generator) deleteTableTool(detail *schema.TableDetail) ToolDef {
 	return ToolDef{
 		Tool: &mcp.Tool{
-			Name:        "delete_" + detail.Name,
+			Name:        g.toolName("delete_" + detail.Name),
 			Description: fmt.Sprintf("Delete rows from the %s table matching a filter condition.", detail.Name),
-			InputSchema: toolInputSchema(map[string]any{
+			InputSchema: toolInputSchema(withConfirmProperties(map[string]any{
 				"filter": map[string]any{
 					"type":        "string",
 					"description": "SQL WHERE clause to identify rows to delete (REQUIRED to prevent accidental full-table deletes)",
 				},
-			}, []string{"filter"}),
+				"returning": returningProperty("deleted"),
+			}, "delete"), []string{"filter"}),
+			OutputSchema: toolOutputSchema(confirmableOutputSchema(g.rowOutputSchema(detail))),
 			Annotations: &mcp.ToolAnnotations{
 				ReadOnlyHint:    false,
 				DestructiveHint: boolPtr(true),
@@ -402,13 +518,18 @@ func (g *Generator) deleteTableTool(detail *schema.TableDetail) ToolDef {
 			},
 		},
 		Handler: g.makeDeleteHandler(detail.Name),
+		Table:   detail.Name,
+		Verb:    access.VerbDelete,
 	}
 }
 
 func (g *Generator) makeDeleteHandler(tableName string) mcp.ToolHandler {
 	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var args struct {
-			Filter string `json:"filter"`
+			Filter       string `json:"filter"`
+			Returning    bool   `json:"returning"`
+			Preview      bool   `json:"preview"`
+			ConfirmToken string `json:"confirm_token"`
 		}
 		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 			result := &mcp.CallToolResult{}
@@ -422,25 +543,149 @@ func (g *Generator) makeDeleteHandler(tableName string) mcp.ToolHandler {
 			return result, nil
 		}
 
-		mr, err := g.conn.Delete(ctx, connector.DeleteRequest{
-			Table:  tableName,
-			Filter: args.Filter,
-		})
+		del := connector.DeleteRequest{
+			Table:     tableName,
+			Filter:    args.Filter,
+			Returning: args.Returning,
+		}
+		mr, err := confirmedWrite(ctx, req, args.Preview, args.ConfirmToken,
+			func() (*connector.WritePreview, error) { return g.engine.PreviewDelete(ctx, del) },
+			func(token string) (*connector.MutationResult, error) {
+				del.ConfirmToken = token
+				return g.engine.Delete(ctx, del)
+			})
 		if err != nil {
 			result := &mcp.CallToolResult{}
 			result.SetError(fmt.Errorf("delete from %s failed: %w", tableName, err))
 			return result, nil
 		}
 
-		data, _ := json.Marshal(mr)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
-		}, nil
+		return mutationContent(mr), nil
+	}
+}
+
+// --- upsert_{table} ---
+
+func (g *Generator) upsertTableTool(detail *schema.TableDetail) ToolDef {
+	properties := make(map[string]any)
+	for _, col := range detail.Columns {
+		properties[col.Name] = map[string]any{
+			"description": columnDescription(col),
+		}
+	}
+
+	// The conflict columns must form the primary key or a unique key.
+	var keys [][]string
+	if len(detail.PrimaryKey) > 0 {
+		keys = append(keys, detail.PrimaryKey)
+	}
+	for _, uk := range detail.UniqueKeys {
+		keys = append(keys, uk.Columns)
+	}
+	var keyColumns []any
+	var keyNames []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		keyNames = append(keyNames, "["+strings.Join(key, ", ")+"]")
+		for _, col := range key {
+			if !seen[col] {
+				seen[col] = true
+				keyColumns = append(keyColumns, col)
+			}
+		}
+	}
+	conflict := map[string]any{
+		"type":        "array",
+		"items":       map[string]any{"type": "string", "enum": keyColumns},
+		"minItems":    1,
+		"description": "Columns of the key that identifies existing rows, one of: " + strings.Join(keyNames, ", "),
+	}
+	required := []string{"rows"}
+	if len(detail.PrimaryKey) > 0 {
+		conflict["description"] = conflict["description"].(string) + ". Defaults to the primary key."
+		conflict["default"] = detail.PrimaryKey
+	} else {
+		required = append(required, "conflict_columns")
+	}
+
+	return ToolDef{
+		Tool: &mcp.Tool{
+			Name: g.toolName("upsert_" + detail.Name),
+			Description: fmt.Sprintf("Insert rows into the %s table, or update the existing rows with the same key. "+
+				"Every row must set the same columns, including the key columns; the other columns of existing rows are updated from them. "+
+				"Returns the written rows where the database can report them.", detail.Name),
+			InputSchema: toolInputSchema(map[string]any{
+				"rows": map[string]any{
+					"type":        "array",
+					"description": "Array of row objects to insert or update",
+					"items": map[string]any{
+						"type":       "object",
+						"properties": properties,
+					},
+				},
+				"conflict_columns": conflict,
+			}, required),
+			OutputSchema: toolOutputSchema(mutationOutputSchema(g.rowOutputSchema(detail))),
+			Annotations: &mcp.ToolAnnotations{
+				ReadOnlyHint:    false,
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+				IdempotentHint:  true,
+			},
+		},
+		Handler:   g.makeUpsertHandler(detail.Name),
+		Table:     detail.Name,
+		Verb:      access.VerbInsert,
+		MoreVerbs: []access.Verb{access.VerbUpdate},
+	}
+}
+
+func (g *Generator) makeUpsertHandler(tableName string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args struct {
+			Rows            []map[string]any `json:"rows"`
+			ConflictColumns []string         `json:"conflict_columns"`
+		}
+		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+			result := &mcp.CallToolResult{}
+			result.SetError(fmt.Errorf("invalid arguments: %w", err))
+			return result, nil
+		}
+
+		if len(args.Rows) == 0 {
+			result := &mcp.CallToolResult{}
+			result.SetError(fmt.Errorf("at least one row is required"))
+			return result, nil
+		}
+
+		mr, err := g.engine.Upsert(ctx, connector.UpsertRequest{
+			Table:           tableName,
+			Rows:            args.Rows,
+			ConflictColumns: args.ConflictColumns,
+			Returning:       true,
+		})
+		if err != nil {
+			result := &mcp.CallToolResult{}
+			result.SetError(fmt.Errorf("upsert into %s failed: %w", tableName, err))
+			return result, nil
+		}
+
+		return mutationContent(mr), nil
 	}
 }
 
 // --- helpers ---
 
+// returningProperty is the schema of the returning argument of the update
+// and delete tools, which asks for the rows they changed.
+func returningProperty(verb string) map[string]any {
+	return map[string]any{
+		"type":        "boolean",
+		"description": fmt.Sprintf("Return the %s rows, where the database can report them", verb),
+		"default":     false,
+	}
+}
+
 // schemaTypeToJSON maps Conduit simplified types to JSON Schema types.
 func schemaTypeToJSON(t string) string {
 	switch t {
@@ -455,6 +700,76 @@ func schemaTypeToJSON(t string) string {
 	}
 }
 
+// whereProperty returns the input schema of the where argument of
+// query_{table}: conditions on the table's columns, typed from its schema,
+// that the engine compiles like a JSON filter.
+func whereProperty(detail *schema.TableDetail) map[string]any {
+	properties := make(map[string]any, len(detail.Columns)+2)
+	for _, col := range detail.Columns {
+		properties[col.Name] = columnConditionSchema(col)
+	}
+	// $and and $or nest further where objects.
+	group := func(desc string) map[string]any {
+		return map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"$ref": "#/properties/where"},
+			"minItems":    1,
+			"description": desc,
+		}
+	}
+	properties["$and"] = group("Conditions that must all hold")
+	properties["$or"] = group("Conditions of which at least one must hold")
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+		"description": `Conditions on columns, all of which must hold: a value for equality ({"status": "shipped"}, null for IS NULL), ` +
+			`or an operator object such as {"total": {"$gte": 100}}, {"id": {"$in": [1, 2]}}, or {"name": {"$like": "A%"}}. ` +
+			`Combine alternatives with {"$or": [{...}, {...}]}.`,
+	}
+}
+
+// columnConditionSchema returns the schema of a where condition on col:
+// a value to compare for equality, or an object of operators.
+func columnConditionSchema(col schema.ColumnInfo) map[string]any {
+	jsonType := schemaTypeToJSON(col.Type)
+	value := map[string]any{"type": jsonType}
+	nullable := value
+	if col.Nullable {
+		nullable = map[string]any{"type": []any{jsonType, "null"}}
+	}
+	list := map[string]any{"type": "array", "items": value}
+
+	ops := map[string]any{
+		"$eq":  nullable,
+		"$ne":  nullable,
+		"$in":  list,
+		"$nin": list,
+	}
+	if jsonType != "boolean" {
+		for _, op := range []string{"$gt", "$gte", "$lt", "$lte"} {
+			ops[op] = value
+		}
+	}
+	if col.Type == "string" {
+		ops["$like"] = map[string]any{
+			"type":        "string",
+			"description": "SQL LIKE pattern: % matches any characters, _ a single one",
+		}
+	}
+	return map[string]any{
+		"anyOf": []any{
+			nullable,
+			map[string]any{
+				"type":                 "object",
+				"properties":           ops,
+				"additionalProperties": false,
+				"minProperties":        1,
+			},
+		},
+	}
+}
+
 // columnDescription generates a human-readable description for a column.
 func columnDescription(col schema.ColumnInfo) string {
 	parts := []string{col.Type}
@@ -0,0 +1,334 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// rolePolicy is the access policy a role has on one table, resolved for a
+// single request.
+type rolePolicy struct {
+	role      string
+	denied    map[string]bool // lower-cased column names
+	masked    []string
+	rowFilter *connector.Filter
+}
+
+// identity returns the caller's identity from ctx, or nil when no RBAC
+// applies: the engine has no access engine, or the caller has no identity or
+// role.
+func (e *Engine) identity(ctx context.Context) *access.Identity {
+	if e.access == nil {
+		return nil
+	}
+	id := access.IdentityFromContext(ctx)
+	if id == nil || id.Role == "" {
+		return nil
+	}
+	return id
+}
+
+// role returns the caller's role from ctx, or "" when no RBAC applies.
+func (e *Engine) role(ctx context.Context) string {
+	if id := e.identity(ctx); id != nil {
+		return id.Role
+	}
+	return ""
+}
+
+// authorize checks that the caller may perform verb on table and returns the
+// caller's policy for the table, including its compiled row filter. It
+// returns a nil policy when no RBAC applies.
+func (e *Engine) authorize(ctx context.Context, table string, verb access.Verb) (*rolePolicy, error) {
+	id := e.identity(ctx)
+	if id == nil {
+		return nil, nil
+	}
+	if err := e.access.CheckAccess(id.Role, table, verb); err != nil {
+		return nil, err
+	}
+	p := e.policyFor(id.Role, table)
+	if expr := e.access.GetRowFilter(id.Role, table); expr != "" {
+		f, err := CompileRowFilter(expr, id)
+		if err != nil {
+			// Fail closed: a row filter that cannot be applied denies access.
+			return nil, fmt.Errorf("row filter for role %q on table %q: %w", id.Role, table, err)
+		}
+		p.rowFilter = f
+	}
+	return p, nil
+}
+
+// rawWriteVerbs maps the data changes a raw statement makes to the verbs
+// they require.
+var rawWriteVerbs = map[string][]access.Verb{
+	"INSERT":  {access.VerbInsert},
+	"UPDATE":  {access.VerbUpdate},
+	"DELETE":  {access.VerbDelete},
+	"REPLACE": {access.VerbInsert, access.VerbDelete},
+	"MERGE":   {access.VerbInsert, access.VerbUpdate, access.VerbDelete},
+}
+
+// authorizeRaw checks a raw SQL statement against the caller's role before it
+// runs. Every referenced table must allow SELECT, or the verbs of the changes
+// the statement makes to it; the statement may not name a denied or masked
+// column, or select * from a table with a denied one; and tables with a row
+// filter, which raw SQL cannot apply, are refused unless the policy sets
+// allow_raw_sql.
+// Statements other than queries and DML are refused outright, as are SHOW
+// and DESCRIBE, whose tables cannot be resolved, statements that read from
+// anything but a table, such as a table function, and statements that call a
+// function which runs SQL of its own (see Statement.QueryCalls). It returns
+// the policies to apply to the results, keyed by table, or nil when no RBAC
+// applies.
+func (e *Engine) authorizeRaw(ctx context.Context, stmt *Statement) (map[string]*rolePolicy, error) {
+	role := e.role(ctx)
+	if role == "" {
+		return nil, nil
+	}
+	switch {
+	case stmt.Verb == "SHOW", stmt.Verb == "DESCRIBE":
+		return nil, fmt.Errorf("role %q may not run %s statements; use list_tables and describe_table", role, stmt.Verb)
+	case !readOnlyVerbs[stmt.Verb] && rawWriteVerbs[stmt.Verb] == nil:
+		return nil, fmt.Errorf("role %q may not run %s statements", role, stmt.Verb)
+	case len(stmt.Unresolved) > 0:
+		return nil, fmt.Errorf("role %q may only read tables in raw SQL, and %q is not one", role, stmt.Unresolved[0])
+	case len(stmt.QueryCalls) > 0:
+		return nil, fmt.Errorf("role %q may not call %s in raw SQL; it can read tables outside the role's access", role, stmt.QueryCalls[0])
+	}
+
+	verbs := make(map[string][]access.Verb)
+	for _, w := range stmt.Writes {
+		v, ok := rawWriteVerbs[w.Verb]
+		if !ok {
+			return nil, fmt.Errorf("role %q may not run %s statements on table %q", role, w.Verb, w.Table)
+		}
+		verbs[w.Table] = append(verbs[w.Table], v...)
+	}
+
+	policies := make(map[string]*rolePolicy, len(stmt.Tables))
+	for _, table := range stmt.Tables {
+		if strings.EqualFold(table, "dual") {
+			continue
+		}
+		need, ok := verbs[table]
+		if !ok {
+			need = []access.Verb{access.VerbSelect}
+		}
+		for _, verb := range need {
+			if err := e.access.CheckAccess(role, table, verb); err != nil {
+				return nil, err
+			}
+		}
+		if !e.access.AllowsRawSQL(role, table) {
+			return nil, fmt.Errorf("table %q has a row filter for role %q and cannot be used in raw SQL", table, role)
+		}
+		p := e.policyFor(role, table)
+		if len(p.denied) > 0 && stmt.SelectsAll() {
+			return nil, fmt.Errorf("table %q has columns hidden from role %q; select columns by name instead of *", table, role)
+		}
+		for col := range p.denied {
+			if stmt.References(col) {
+				return nil, fmt.Errorf("column %q of table %q is not accessible to role %q", col, table, role)
+			}
+		}
+		// Results are masked by column name, which an alias or expression
+		// would slip past.
+		for _, col := range p.masked {
+			if stmt.References(col) {
+				return nil, fmt.Errorf("column %q of table %q is masked for role %q and cannot be named in raw SQL", col, table, role)
+			}
+		}
+		policies[table] = p
+	}
+	return policies, nil
+}
+
+// CompileRowFilter compiles a role's row filter for a caller. {{user.*}}
+// templates resolve through id.Lookup and are bound as parameters, so
+// attribute values never reach the SQL text. A template the identity cannot
+// resolve is an error.
+func CompileRowFilter(expr string, id *access.Identity) (*connector.Filter, error) {
+	return compileFilter(expr, func(name string) (any, error) {
+		v, ok := id.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("session has no value for {{%s}}", name)
+		}
+		return v, nil
+	})
+}
+
+// ValidateRowFilter checks that expr is a valid row filter, without resolving
+// its templates.
+func ValidateRowFilter(expr string) error {
+	_, err := compileFilter(expr, func(name string) (any, error) {
+		if !strings.HasPrefix(name, "user.") || name == "user." {
+			return nil, fmt.Errorf("unknown template {{%s}} (expected {{user.<attribute>}})", name)
+		}
+		return "", nil
+	})
+	return err
+}
+
+// authorizeAny is like authorize but accepts any verb, for metadata access
+// such as describing a table.
+func (e *Engine) authorizeAny(ctx context.Context, table string) (*rolePolicy, error) {
+	role := e.role(ctx)
+	if role == "" {
+		return nil, nil
+	}
+	if !e.access.CanAccess(role, table) {
+		return nil, fmt.Errorf("role %q does not have access to table %q", role, table)
+	}
+	return e.policyFor(role, table), nil
+}
+
+func (e *Engine) policyFor(role, table string) *rolePolicy {
+	p := &rolePolicy{
+		role:   role,
+		denied: make(map[string]bool),
+		masked: e.access.GetMaskedColumns(role, table),
+	}
+	for _, col := range e.access.GetDeniedColumns(role, table) {
+		p.denied[strings.ToLower(col)] = true
+	}
+	return p
+}
+
+// isDenied reports whether col is hidden from the role.
+func (p *rolePolicy) isDenied(col string) bool {
+	return p != nil && p.denied[strings.ToLower(col)]
+}
+
+// checkColumns rejects references to denied columns.
+func (p *rolePolicy) checkColumns(cols ...string) error {
+	for _, col := range cols {
+		if p.isDenied(col) {
+			return fmt.Errorf("column %q is not accessible to role %q", col, p.role)
+		}
+	}
+	return nil
+}
+
+// checkFilter rejects filters that reference denied columns, which would
+// otherwise let a caller probe hidden values through WHERE conditions.
+func (p *rolePolicy) checkFilter(f *connector.Filter) error {
+	if p == nil || f == nil {
+		return nil
+	}
+	for _, part := range f.Parts {
+		if part.Kind == connector.FilterColumn {
+			if err := p.checkColumns(part.Text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restrict ANDs the role's row filter into where, so that callers only ever
+// see and modify rows the filter admits.
+func (p *rolePolicy) restrict(where *connector.Filter) *connector.Filter {
+	if p == nil {
+		return where
+	}
+	return connector.AndFilters(p.rowFilter, where)
+}
+
+// checkSet rejects updates to columns the row filter constrains, which would
+// let a caller move rows outside the filter.
+func (p *rolePolicy) checkSet(set map[string]any) error {
+	if p == nil || p.rowFilter == nil {
+		return nil
+	}
+	for _, part := range p.rowFilter.Parts {
+		if part.Kind != connector.FilterColumn {
+			continue
+		}
+		for col := range set {
+			if strings.EqualFold(col, part.Text) {
+				return fmt.Errorf("column %q is constrained by the row filter of role %q and cannot be updated", col, p.role)
+			}
+		}
+	}
+	return nil
+}
+
+// checkOrderBy rejects ORDER BY clauses on denied columns. orderBy must
+// already have passed SanitizeOrderBy.
+func (p *rolePolicy) checkOrderBy(orderBy string) error {
+	if p == nil {
+		return nil
+	}
+	for _, part := range strings.Split(orderBy, ",") {
+		if fields := strings.Fields(part); len(fields) > 0 {
+			if err := p.checkColumns(fields[0]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visibleColumns returns the names of td's columns the role may see.
+func (p *rolePolicy) visibleColumns(td *schema.TableDetail) []string {
+	cols := make([]string, 0, len(td.Columns))
+	for _, c := range td.Columns {
+		if !p.isDenied(c.Name) {
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+// apply strips denied columns from rs and masks the role's masked columns.
+func (p *rolePolicy) apply(rs *connector.ResultSet, detector *schema.PIIDetector) {
+	if p == nil || rs == nil {
+		return
+	}
+	if len(p.denied) > 0 {
+		cols := rs.Columns[:0]
+		for _, col := range rs.Columns {
+			if !p.isDenied(col) {
+				cols = append(cols, col)
+			}
+		}
+		rs.Columns = cols
+		for _, row := range rs.Rows {
+			for col := range row {
+				if p.isDenied(col) {
+					delete(row, col)
+				}
+			}
+		}
+	}
+	if len(p.masked) > 0 {
+		masked := make(map[string]schema.PIICategory, len(p.masked))
+		for _, want := range p.masked {
+			for _, col := range rs.Columns {
+				if strings.EqualFold(col, want) {
+					// Keep category-aware masking (e.g. email) where the
+					// column name identifies the kind of value.
+					masked[col] = detector.DetectColumn(col).Category
+				}
+			}
+		}
+		schema.MaskRows(rs.Rows, masked)
+	}
+}
+
+// clampLimit caps limit at the role's max_rows_per_query, if it has one.
+func (e *Engine) clampLimit(p *rolePolicy, limit int) int {
+	if p == nil {
+		return limit
+	}
+	if max := e.access.GetMaxRows(p.role); max > 0 && (limit <= 0 || limit > max) {
+		return max
+	}
+	return limit
+}
@@ -0,0 +1,230 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// aliasPattern validates aggregate aliases, which name result columns and
+// may not be schema-qualified.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Aggregate executes a validated, parameterized GROUP BY query. Group-by and
+// aggregate columns must exist in the table; SUM and AVG need numeric
+// columns; and only COUNT may be applied to masked or hidden columns, since
+// MIN and MAX would return their values under an alias masking does not
+// recognize. Having and OrderBy may only name group-by columns and aliases.
+// An empty Aggregates list counts rows.
+func (e *Engine) Aggregate(ctx context.Context, req connector.AggregateRequest) (*connector.ResultSet, error) {
+	if err := e.validator.ValidateSelect(req.Table, req.Limit, 0); err != nil {
+		return nil, err
+	}
+	if err := e.validator.ValidateColumns(req.GroupBy); err != nil {
+		return nil, err
+	}
+	policy, err := e.authorize(ctx, req.Table, access.VerbSelect)
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = e.clampLimit(policy, e.validator.ClampLimit(req.Limit))
+
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	td, err := e.cache.DescribeTable(queryCtx, req.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.validateAggregate(&req, td, policy); err != nil {
+		return nil, err
+	}
+
+	where, err := compileWhere(req.Filter, req.Where)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.checkFilter(where); err != nil {
+		return nil, err
+	}
+	req.Where = policy.restrict(where)
+
+	having, err := compileWhere(req.HavingFilter, req.Having)
+	if err != nil {
+		return nil, &ValidationError{Field: "having", Message: err.Error()}
+	}
+	if having == nil {
+		having = &connector.Filter{}
+	}
+	for i, part := range having.Parts {
+		if part.Kind != connector.FilterColumn {
+			continue
+		}
+		name, ok := aggregateOutput(req, part.Text)
+		if !ok {
+			return nil, &ValidationError{
+				Field:   "having",
+				Message: fmt.Sprintf("%q is not a group-by column or aggregate alias", part.Text),
+			}
+		}
+		having.Parts[i].Text = name
+	}
+	req.Having = having
+
+	start := time.Now()
+	rs, err := e.connector.Aggregate(queryCtx, req)
+	elapsed := time.Since(start)
+	if err != nil {
+		e.logger.Warn("aggregate failed",
+			slog.String("table", req.Table),
+			slog.Duration("elapsed", elapsed),
+			slog.String("error", err.Error()))
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	e.logger.Debug("aggregate executed",
+		slog.String("table", req.Table),
+		slog.Int("rows", len(rs.Rows)),
+		slog.Duration("elapsed", elapsed))
+
+	// Group-by columns keep their names, so masking by schema applies.
+	if e.maskPII && len(rs.Rows) > 0 {
+		e.maskResult(td, rs)
+	}
+	policy.apply(rs, e.piiDetector)
+	audit.AddRows(ctx, len(rs.Rows))
+	return rs, nil
+}
+
+// validateAggregate checks req's columns against td and the caller's policy,
+// normalizing function names, column names, and default aliases in place.
+func (e *Engine) validateAggregate(req *connector.AggregateRequest, td *schema.TableDetail, policy *rolePolicy) error {
+	columns := make(map[string]schema.ColumnInfo, len(td.Columns))
+	for _, c := range td.Columns {
+		columns[strings.ToLower(c.Name)] = c
+	}
+	lookup := func(field, name string) (schema.ColumnInfo, error) {
+		col, ok := columns[strings.ToLower(name)]
+		if !ok {
+			return col, &ValidationError{Field: field, Message: fmt.Sprintf("table %q has no column %q", td.Name, name)}
+		}
+		if err := policy.checkColumns(col.Name); err != nil {
+			return col, err
+		}
+		return col, nil
+	}
+
+	hidden := e.hiddenColumns(td, policy)
+
+	aliases := make(map[string]bool)
+	for i, name := range req.GroupBy {
+		col, err := lookup("group_by", name)
+		if err != nil {
+			return err
+		}
+		req.GroupBy[i] = col.Name
+		aliases[strings.ToLower(col.Name)] = true
+	}
+
+	if len(req.Aggregates) == 0 {
+		req.Aggregates = []connector.Aggregate{{Func: connector.AggCount}}
+	}
+	for i := range req.Aggregates {
+		a := &req.Aggregates[i]
+		a.Func = connector.AggregateFunc(strings.ToUpper(string(a.Func)))
+		switch a.Func {
+		case connector.AggCount, connector.AggSum, connector.AggAvg, connector.AggMin, connector.AggMax:
+		default:
+			return &ValidationError{
+				Field:   "aggregates",
+				Message: fmt.Sprintf("unknown aggregate function %q (expected count, sum, avg, min, or max)", a.Func),
+			}
+		}
+		if a.Column == "*" {
+			a.Column = ""
+		}
+		if a.Column == "" {
+			if a.Func != connector.AggCount {
+				return &ValidationError{Field: "aggregates", Message: fmt.Sprintf("%s requires a column", a.Func)}
+			}
+		} else {
+			col, err := lookup("aggregates", a.Column)
+			if err != nil {
+				return err
+			}
+			a.Column = col.Name
+			if (a.Func == connector.AggSum || a.Func == connector.AggAvg) && col.Type != "integer" && col.Type != "decimal" {
+				return &ValidationError{
+					Field:   "aggregates",
+					Message: fmt.Sprintf("%s requires a numeric column; %q is %s", a.Func, col.Name, col.Type),
+				}
+			}
+			if a.Func != connector.AggCount && hidden[strings.ToLower(col.Name)] {
+				return &ValidationError{
+					Field:   "aggregates",
+					Message: fmt.Sprintf("column %q is masked; only count may be applied to it", col.Name),
+				}
+			}
+		}
+
+		if a.Alias == "" {
+			a.Alias = strings.ToLower(string(a.Func))
+			if a.Column != "" {
+				a.Alias += "_" + a.Column
+			}
+		}
+		if !aliasPattern.MatchString(a.Alias) {
+			return &ValidationError{Field: "aggregates", Message: fmt.Sprintf("invalid alias %q: must match [a-zA-Z_][a-zA-Z0-9_]*", a.Alias)}
+		}
+		if aliases[strings.ToLower(a.Alias)] {
+			return &ValidationError{Field: "aggregates", Message: fmt.Sprintf("duplicate result column %q; set a distinct alias", a.Alias)}
+		}
+		aliases[strings.ToLower(a.Alias)] = true
+	}
+
+	if req.OrderBy != "" {
+		if err := SanitizeOrderBy(req.OrderBy); err != nil {
+			return err
+		}
+		var terms []string
+		for _, part := range strings.Split(req.OrderBy, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 {
+				continue
+			}
+			name, ok := aggregateOutput(*req, fields[0])
+			if !ok {
+				return &ValidationError{
+					Field:   "order_by",
+					Message: fmt.Sprintf("%q is not a group-by column or aggregate alias", fields[0]),
+				}
+			}
+			terms = append(terms, strings.Join(append([]string{name}, fields[1:]...), " "))
+		}
+		req.OrderBy = strings.Join(terms, ", ")
+	}
+	return nil
+}
+
+// aggregateOutput returns the result column of req that name refers to,
+// case-insensitively: a group-by column or an aggregate alias.
+func aggregateOutput(req connector.AggregateRequest, name string) (string, bool) {
+	for _, col := range req.GroupBy {
+		if strings.EqualFold(col, name) {
+			return col, true
+		}
+	}
+	for _, a := range req.Aggregates {
+		if strings.EqualFold(a.Alias, name) {
+			return a.Alias, true
+		}
+	}
+	return "", false
+}
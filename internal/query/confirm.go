@@ -0,0 +1,258 @@
+package query
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/connector"
+)
+
+// confirmTTL is how long a write confirmation token stays valid.
+const confirmTTL = 5 * time.Minute
+
+// previewSampleRows caps the rows shown in a write preview.
+const previewSampleRows = 5
+
+// errConfirmUsed rejects a confirmation token that already confirmed a write.
+var errConfirmUsed = &ValidationError{
+	Field:   "confirm_token",
+	Message: "confirmation token has already been used; preview the write again",
+}
+
+// errConfirmMismatch rejects a confirmation token used with a write other
+// than the one it previewed.
+var errConfirmMismatch = &ValidationError{
+	Field:   "confirm_token",
+	Message: "token does not match this write; confirm with the same table, filter, and set",
+}
+
+// confirmPayload is the signed content of a write confirmation token. It
+// binds the row count of a preview to the write it previewed and the caller
+// it was shown to.
+type confirmPayload struct {
+	Op      string `json:"op"`
+	Table   string `json:"t"`
+	Write   string `json:"w"`
+	Caller  string `json:"c,omitempty"`
+	Rows    int64  `json:"n"`
+	Expires int64  `json:"e"`
+}
+
+// PreviewUpdate validates an UPDATE operation as Update does and reports
+// the rows it would change, with a token that confirms it.
+func (e *Engine) PreviewUpdate(ctx context.Context, req connector.UpdateRequest) (*connector.WritePreview, error) {
+	prepared, err := e.prepareUpdate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return e.previewWrite(ctx, "update", prepared.Table, prepared.Where, writeDigest(req.Filter, req.Set))
+}
+
+// PreviewDelete validates a DELETE operation as Delete does and reports the
+// rows it would remove, with a token that confirms it.
+func (e *Engine) PreviewDelete(ctx context.Context, req connector.DeleteRequest) (*connector.WritePreview, error) {
+	prepared, err := e.prepareDelete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return e.previewWrite(ctx, "delete", prepared.Table, prepared.Where, writeDigest(req.Filter, nil))
+}
+
+// previewWrite counts the rows of table matching where, and signs the count
+// into a token for the write identified by op and digest. The sample rows
+// are selected as by Select, so they are limited and masked as the caller
+// would see them, and left out if the caller may not select them.
+func (e *Engine) previewWrite(ctx context.Context, op, table string, where *connector.Filter, digest string) (*connector.WritePreview, error) {
+	queryCtx, cancel := e.readContext(ctx)
+	n, err := e.countRows(queryCtx, connector.SelectRequest{Table: table, Where: where})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &connector.WritePreview{
+		Op:           op,
+		Table:        table,
+		RowsAffected: n,
+		Sample:       make([]map[string]any, 0),
+		ExpiresAt:    time.Now().Add(confirmTTL).UTC().Truncate(time.Second),
+	}
+	if n > 0 {
+		rs, err := e.Select(ctx, connector.SelectRequest{Table: table, Where: where, Limit: previewSampleRows})
+		if err == nil {
+			preview.Sample = rs.Rows
+		}
+	}
+
+	data, err := json.Marshal(confirmPayload{
+		Op:      op,
+		Table:   table,
+		Write:   digest,
+		Caller:  caller(ctx),
+		Rows:    n,
+		Expires: preview.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	preview.ConfirmToken = base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(e.sign(data))
+	return preview, nil
+}
+
+// confirmWrite runs a validated UPDATE or DELETE, enforcing confirmation.
+// Without a token, the write fails with connector.ErrConfirmationRequired
+// if all writes need confirmation, or if it would affect at least the
+// confirmation threshold of rows. With a token, it only runs if the token
+// is valid for the write, has not confirmed one already, and as many rows
+// match as the preview counted. The count and the write run in one
+// transaction.
+func (e *Engine) confirmWrite(ctx context.Context, op, table string, where *connector.Filter, digest, token string, run func(context.Context) (*connector.MutationResult, error)) (*connector.MutationResult, error) {
+	threshold := e.validator.ConfirmThreshold()
+	if token == "" {
+		if e.validator.ConfirmWrites() {
+			return nil, fmt.Errorf("%w: preview the %s and confirm it with its token", connector.ErrConfirmationRequired, op)
+		}
+		if threshold <= 0 {
+			return run(ctx)
+		}
+	}
+	var want int64
+	var committed bool
+	if token != "" {
+		var err error
+		if want, err = e.decodeConfirmToken(ctx, token, op, table, digest); err != nil {
+			return nil, err
+		}
+		// Claim the token before counting, so that concurrent uses of it
+		// cannot both pass. It is given back unless the write commits.
+		if !e.usedTokens.claim(token) {
+			return nil, errConfirmUsed
+		}
+		defer func() {
+			if !committed {
+				e.usedTokens.release(token)
+			}
+		}()
+	}
+
+	tx, err := e.connector.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	txCtx := connector.WithTx(ctx, tx)
+
+	n, err := e.countRows(txCtx, connector.SelectRequest{Table: table, Where: where})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case token != "" && n != want:
+		return nil, &ValidationError{
+			Field:   "confirm_token",
+			Message: fmt.Sprintf("the %s now matches %d rows, not the %d previewed; preview it again", op, n, want),
+		}
+	case token == "" && n >= threshold:
+		return nil, fmt.Errorf("%w: the %s would affect %d rows, at or above the confirmation threshold of %d",
+			connector.ErrConfirmationRequired, op, n, threshold)
+	}
+
+	res, err := run(txCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit %s: %w", op, err)
+	}
+	committed = true
+	return res, nil
+}
+
+// tokenSet records the confirmation tokens that confirmed a write, so each
+// confirms only one. Tokens are kept for confirmTTL, by which time they have
+// expired anyway.
+type tokenSet struct {
+	mu   sync.Mutex
+	used map[string]time.Time // token → when it can be forgotten
+}
+
+// claim marks token used, reporting false if it already was.
+func (s *tokenSet) claim(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for t, forget := range s.used {
+		if now.After(forget) {
+			delete(s.used, t)
+		}
+	}
+	if _, ok := s.used[token]; ok {
+		return false
+	}
+	if s.used == nil {
+		s.used = make(map[string]time.Time)
+	}
+	s.used[token] = now.Add(confirmTTL)
+	return true
+}
+
+// release gives back a token claimed for a write that did not commit.
+func (s *tokenSet) release(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.used, token)
+}
+
+// decodeConfirmToken verifies a confirmation token for the write identified
+// by op, table, and digest, and returns the row count it was issued for.
+func (e *Engine) decodeConfirmToken(ctx context.Context, token, op, table, digest string) (int64, error) {
+	invalid := &ValidationError{Field: "confirm_token", Message: "invalid confirmation token"}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, invalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, e.sign(data)) {
+		return 0, invalid
+	}
+	var payload confirmPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return 0, invalid
+	}
+	if time.Now().Unix() > payload.Expires {
+		return 0, &ValidationError{Field: "confirm_token", Message: "confirmation token has expired; preview the write again"}
+	}
+	if payload.Op != op || payload.Table != table || payload.Write != digest || payload.Caller != caller(ctx) {
+		return 0, errConfirmMismatch
+	}
+	return payload.Rows, nil
+}
+
+// writeDigest identifies a write by its filter and the values it sets.
+func writeDigest(filter string, set map[string]any) string {
+	data, _ := json.Marshal(set) // map keys are sorted
+	sum := sha256.Sum256(append([]byte(filter+"\x00"), data...))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// caller names the caller identity carried by ctx, if any.
+func caller(ctx context.Context) string {
+	id := access.IdentityFromContext(ctx)
+	if id == nil {
+		return ""
+	}
+	return id.User + "/" + id.Role
+}
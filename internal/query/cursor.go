@@ -0,0 +1,276 @@
+package query
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// errCursorMismatch rejects a cursor used with a query other than the one
+// that returned it.
+var errCursorMismatch = &ValidationError{
+	Field:   "cursor",
+	Message: "cursor does not match this query; use it with the same table, filter, and order_by",
+}
+
+// cursorPayload is the signed content of a pagination cursor. It binds the
+// key values of the last row returned to the query they came from.
+type cursorPayload struct {
+	Table  string        `json:"t"`
+	Order  string        `json:"o"`
+	Filter string        `json:"f,omitempty"`
+	Values []cursorValue `json:"v"`
+}
+
+// cursorValue is a key value tagged with its Go type, so that it is bound
+// with the type it was scanned as rather than whatever JSON decodes it to.
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// planKeyset switches req to keyset pagination when td allows it, decoding
+// req.Cursor into the position to resume after. It returns the key columns
+// added to req.Columns only to build the next cursor, which the caller
+// removes from the result.
+func (e *Engine) planKeyset(req *connector.SelectRequest, td *schema.TableDetail, policy *rolePolicy) ([]string, error) {
+	if req.Cursor != "" && req.Offset > 0 {
+		return nil, &ValidationError{Field: "cursor", Message: "cursor and offset cannot be combined"}
+	}
+	var keys []connector.SortKey
+	if td != nil {
+		keys = e.keysetFor(req.OrderBy, td, policy)
+	}
+	if keys == nil {
+		if req.Cursor != "" {
+			return nil, errCursorMismatch
+		}
+		return nil, nil
+	}
+
+	req.Keyset = &connector.Keyset{Columns: keys}
+	if req.Cursor != "" {
+		after, err := e.decodeCursor(*req)
+		if err != nil {
+			return nil, err
+		}
+		req.Keyset.After = after
+	}
+
+	var added []string
+	if len(req.Columns) > 0 {
+		for _, k := range keys {
+			if !containsFold(req.Columns, k.Column) {
+				req.Columns = append(req.Columns, k.Column)
+				added = append(added, k.Column)
+			}
+		}
+	}
+	return added, nil
+}
+
+// keysetFor returns the keyset ordering for orderBy on td: its columns
+// followed by any primary key columns it does not name, which make the
+// ordering unique. It returns nil when the ordering cannot be used as a
+// keyset: the table has no primary key, an order_by column is nullable or
+// unknown, or a key column is hidden from the caller, since the cursor
+// carries key values.
+func (e *Engine) keysetFor(orderBy string, td *schema.TableDetail, policy *rolePolicy) []connector.SortKey {
+	if len(td.PrimaryKey) == 0 {
+		return nil
+	}
+	columns := make(map[string]schema.ColumnInfo, len(td.Columns))
+	for _, c := range td.Columns {
+		columns[strings.ToLower(c.Name)] = c
+	}
+
+	var keys []connector.SortKey
+	named := make(map[string]bool)
+	for _, term := range strings.Split(orderBy, ",") {
+		fields := strings.Fields(term)
+		if len(fields) == 0 {
+			continue
+		}
+		col, ok := columns[strings.ToLower(fields[0])]
+		if !ok || (col.Nullable && !col.PK) {
+			return nil
+		}
+		keys = append(keys, connector.SortKey{
+			Column: col.Name,
+			Desc:   len(fields) > 1 && strings.EqualFold(fields[1], "DESC"),
+		})
+		named[strings.ToLower(col.Name)] = true
+	}
+	for _, pk := range td.PrimaryKey {
+		if !named[strings.ToLower(pk)] {
+			keys = append(keys, connector.SortKey{Column: pk})
+		}
+	}
+
+	hidden := e.hiddenColumns(td, policy)
+	for _, k := range keys {
+		if hidden[strings.ToLower(k.Column)] {
+			return nil
+		}
+	}
+	return keys
+}
+
+// encodeCursor returns the signed cursor resuming req after row. It fails if
+// a key value of row was cut at the cell size limit, since resuming after
+// the cut value would repeat or skip rows.
+func (e *Engine) encodeCursor(req connector.SelectRequest, row map[string]any) (string, error) {
+	payload := cursorPayload{
+		Table:  req.Table,
+		Order:  req.Keyset.OrderByClause(func(s string) string { return s }),
+		Filter: req.Filter,
+	}
+	maxCell := e.validator.ScanLimits().MaxCellBytes
+	for _, k := range req.Keyset.Columns {
+		if connector.IsTruncated(row[k.Column], maxCell) {
+			return "", fmt.Errorf("column %q: value is longer than the cell size limit", k.Column)
+		}
+		v, err := encodeCursorValue(row[k.Column])
+		if err != nil {
+			return "", fmt.Errorf("column %q: %w", k.Column, err)
+		}
+		payload.Values = append(payload.Values, v)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(e.sign(data)), nil
+}
+
+// decodeCursor verifies req.Cursor and returns the key values it resumes
+// after.
+func (e *Engine) decodeCursor(req connector.SelectRequest) ([]any, error) {
+	invalid := &ValidationError{Field: "cursor", Message: "invalid cursor"}
+	encoded, sig, ok := strings.Cut(req.Cursor, ".")
+	if !ok {
+		return nil, invalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, e.sign(data)) {
+		return nil, invalid
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, invalid
+	}
+	if payload.Table != req.Table || payload.Filter != req.Filter ||
+		payload.Order != req.Keyset.OrderByClause(func(s string) string { return s }) ||
+		len(payload.Values) != len(req.Keyset.Columns) {
+		return nil, errCursorMismatch
+	}
+
+	values := make([]any, len(payload.Values))
+	for i, v := range payload.Values {
+		if values[i], err = decodeCursorValue(v); err != nil {
+			return nil, invalid
+		}
+	}
+	return values, nil
+}
+
+// sign returns the HMAC-SHA256 of data under the engine's cursor key. It
+// signs pagination cursors and write confirmation tokens.
+func (e *Engine) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, e.cursorKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// encodeCursorValue tags a scanned key value with its type.
+func encodeCursorValue(v any) (cursorValue, error) {
+	switch v := v.(type) {
+	case string:
+		return cursorValue{"string", v}, nil
+	case bool:
+		return cursorValue{"bool", strconv.FormatBool(v)}, nil
+	case time.Time:
+		return cursorValue{"time", v.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return cursorValue{"bytes", base64.StdEncoding.EncodeToString(v)}, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{"int", strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{"uint", strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{"float", strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	}
+	return cursorValue{}, fmt.Errorf("cannot page by a %T value", v)
+}
+
+// decodeCursorValue restores a value encoded by encodeCursorValue.
+func decodeCursorValue(v cursorValue) (any, error) {
+	switch v.Type {
+	case "string":
+		return v.Value, nil
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	}
+	return nil, fmt.Errorf("unknown cursor value type %q", v.Type)
+}
+
+// countRows returns the number of rows of req's table matching req.Where,
+// ignoring its limit, offset, and cursor.
+func (e *Engine) countRows(ctx context.Context, req connector.SelectRequest) (int64, error) {
+	rs, err := e.connector.Aggregate(ctx, connector.AggregateRequest{
+		Table:      req.Table,
+		Aggregates: []connector.Aggregate{{Func: connector.AggCount, Alias: "count"}},
+		Where:      req.Where,
+		Limit:      1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	if len(rs.Rows) == 0 {
+		return 0, nil
+	}
+	switch n := rs.Rows[0]["count"].(type) {
+	case int64:
+		return n, nil
+	case float64:
+		return int64(n), nil
+	case []byte:
+		return strconv.ParseInt(string(n), 10, 64)
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	rv := reflect.ValueOf(rs.Rows[0]["count"])
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return rv.Int(), nil
+	}
+	return 0, fmt.Errorf("count failed: unexpected %T result", rs.Rows[0]["count"])
+}
@@ -2,10 +2,14 @@ package query
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/audit"
 	"github.com/conduitdb/conduit/internal/connector"
 	"github.com/conduitdb/conduit/internal/schema"
 )
@@ -13,13 +17,17 @@ import (
 // Engine orchestrates query execution. It validates inputs, parses filters,
 // applies PII masking, and delegates to the underlying connector. All user
 // input passes through sanitization and parameterization before reaching the
-// database.
+// database. When an access engine is configured, every operation is also
+// checked against the role of the caller identity carried in the context.
 type Engine struct {
 	connector   connector.Connector
 	cache       *schema.Cache
 	validator   *Validator
 	piiDetector *schema.PIIDetector
 	maskPII     bool
+	access      *access.Engine
+	cursorKey   []byte
+	usedTokens  tokenSet
 	logger      *slog.Logger
 }
 
@@ -30,6 +38,16 @@ type EngineConfig struct {
 
 	// MaskPII enables PII detection and masking on query results.
 	MaskPII bool
+
+	// Access enables role-based access control. Callers are evaluated under
+	// the role of the access.Identity in their request context; requests
+	// without one are not restricted.
+	Access *access.Engine
+
+	// CursorKey signs pagination cursors and write confirmation tokens. If
+	// empty, a random key is generated, and both are only valid for the
+	// life of the engine.
+	CursorKey []byte
 }
 
 // NewEngine creates a query engine wired to the given connector and schema cache.
@@ -37,17 +55,30 @@ func NewEngine(conn connector.Connector, cache *schema.Cache, cfg EngineConfig,
 	if logger == nil {
 		logger = slog.Default()
 	}
+	cursorKey := cfg.CursorKey
+	if len(cursorKey) == 0 {
+		cursorKey = make([]byte, 32)
+		rand.Read(cursorKey) // never fails; crashes the program instead
+	}
 	return &Engine{
 		connector:   conn,
 		cache:       cache,
 		validator:   NewValidator(cfg.Limits),
 		piiDetector: schema.NewPIIDetector(),
 		maskPII:     cfg.MaskPII,
+		access:      cfg.Access,
+		cursorKey:   cursorKey,
 		logger:      logger,
 	}
 }
 
-// Select executes a validated, parameterized SELECT query.
+// Select executes a validated, parameterized SELECT query. Expansions in
+// req.Expand may only follow foreign keys declared between the tables, and
+// the related rows are selected under the same access checks as req.
+//
+// Tables with a primary key are paged by key: rows are ordered by
+// req.OrderBy and then the primary key, and when more rows match, the
+// result carries a signed NextCursor that resumes after the last row.
 func (e *Engine) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
 	// Validate the request.
 	if err := e.validator.ValidateSelect(req.Table, req.Limit, req.Offset); err != nil {
@@ -56,29 +87,82 @@ func (e *Engine) Select(ctx context.Context, req connector.SelectRequest) (*conn
 	if err := e.validator.ValidateColumns(req.Columns); err != nil {
 		return nil, err
 	}
+	policy, err := e.authorize(ctx, req.Table, access.VerbSelect)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.checkColumns(req.Columns...); err != nil {
+		return nil, err
+	}
 
 	// Clamp the limit.
-	req.Limit = e.validator.ClampLimit(req.Limit)
+	req.Limit = e.clampLimit(policy, e.validator.ClampLimit(req.Limit))
 
 	// Validate ORDER BY if present.
 	if req.OrderBy != "" {
 		if err := SanitizeOrderBy(req.OrderBy); err != nil {
 			return nil, err
 		}
+		if err := policy.checkOrderBy(req.OrderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compile the filter into a parameterized condition. Connectors only
+	// render req.Where, so caller text never reaches the SQL string.
+	where, err := compileWhere(req.Filter, req.Where)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.checkFilter(where); err != nil {
+		return nil, err
+	}
+	req.Where = policy.restrict(where)
+
+	// Apply the query timeout and result size limits.
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	// The table detail is needed to hide denied columns, resolve expansions,
+	// and page by key. A table that cannot be described can still be
+	// queried without them.
+	td, describeErr := e.cache.DescribeTable(queryCtx, req.Table)
+	if describeErr != nil {
+		td = nil
+	}
+
+	// Never fetch denied columns: expand "all columns" to the visible ones.
+	if len(req.Columns) == 0 && policy != nil && len(policy.denied) > 0 {
+		if describeErr != nil {
+			return nil, describeErr
+		}
+		req.Columns = policy.visibleColumns(td)
 	}
 
-	// Parse and validate the filter (the raw filter string gets replaced with
-	// a parameterized version by the connector — our job is to reject
-	// dangerous input before it reaches the connector).
-	if req.Filter != "" {
-		if err := SanitizeFilterInput(req.Filter); err != nil {
+	// Resolve expansions against declared foreign keys, fetching the key
+	// columns they match on.
+	var expands []expansion
+	var keyColumns []string
+	if len(req.Expand) > 0 {
+		if describeErr != nil {
+			return nil, describeErr
+		}
+		expands, keyColumns, err = e.planExpand(queryCtx, &req, td, policy)
+		if err != nil {
 			return nil, err
 		}
 	}
 
-	// Apply query timeout.
-	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
-	defer cancel()
+	// Page by key rather than by offset where the table allows it.
+	added, err := e.planKeyset(&req, td, policy)
+	if err != nil {
+		return nil, err
+	}
+	keyColumns = append(keyColumns, added...)
+
+	// Fetch one row past the limit to learn whether there are more.
+	limit := req.Limit
+	req.Limit++
 
 	start := time.Now()
 	rs, err := e.connector.Select(queryCtx, req)
@@ -97,70 +181,450 @@ func (e *Engine) Select(ctx context.Context, req connector.SelectRequest) (*conn
 		slog.Int("rows", len(rs.Rows)),
 		slog.Duration("elapsed", elapsed))
 
+	if rs.SizeLimited {
+		// The row that did not fit exists. If it was the look-ahead row,
+		// the page itself is complete.
+		rs.HasMore = true
+		if len(rs.Rows) == limit {
+			rs.SetTruncation(rs.CutValues, false)
+		}
+	}
+	if len(rs.Rows) > limit {
+		rs.Rows = rs.Rows[:limit]
+		rs.HasMore = true
+	}
+	if rs.HasMore && req.Keyset != nil && len(rs.Rows) > 0 {
+		// Without a cursor, callers can still page by offset.
+		if rs.NextCursor, err = e.encodeCursor(req, rs.Rows[len(rs.Rows)-1]); err != nil {
+			e.logger.Warn("cannot build next cursor",
+				slog.String("table", req.Table),
+				slog.String("error", err.Error()))
+		}
+	}
+	if req.Count {
+		if rs.Total, err = e.countRows(queryCtx, req); err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply PII masking if enabled.
 	if e.maskPII && len(rs.Rows) > 0 {
 		if err := e.applyPIIMasking(ctx, req.Table, rs); err != nil {
-			e.logger.Warn("PII masking failed, returning unmasked results",
+			e.logger.Warn("PII masking by table schema failed, masking by column name",
 				slog.String("table", req.Table),
 				slog.String("error", err.Error()))
+			e.maskByColumnName(rs)
+		}
+	}
+	policy.apply(rs, e.piiDetector)
+	audit.AddRows(ctx, len(rs.Rows))
+
+	for _, x := range expands {
+		if err := e.expand(ctx, rs, x); err != nil {
+			return nil, err
+		}
+	}
+	for _, col := range keyColumns {
+		rs.Columns = removeFold(rs.Columns, col)
+		for _, row := range rs.Rows {
+			delete(row, col)
 		}
 	}
 
 	return rs, nil
 }
 
-// Insert executes a validated INSERT operation.
+// Insert executes a validated INSERT operation. The rows are inserted in
+// batches, in one transaction, as insertRows describes: if any row fails,
+// none are inserted, unless req.ContinueOnError, in which case the rows that
+// failed are reported in RowErrors and the others are inserted. With
+// req.Returning, the inserted rows are reported as described by returning.
 func (e *Engine) Insert(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
-	if err := e.validator.ValidateWrite(req.Table); err != nil {
+	if err := e.checkInsert(ctx, req); err != nil {
 		return nil, err
 	}
+	var policy *rolePolicy
+	req.Returning, policy = e.returning(ctx, req.Table, req.Returning)
+
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	tx, err := e.connector.Begin(queryCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := e.insertRows(connector.WithTx(queryCtx, tx), req)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit insert: %w", err)
+	}
+	e.maskReturning(ctx, req.Table, policy, res)
+	return res, nil
+}
+
+// checkInsert validates an INSERT operation and authorizes it for the
+// caller.
+func (e *Engine) checkInsert(ctx context.Context, req connector.InsertRequest) error {
+	if err := e.validator.ValidateWrite(req.Table); err != nil {
+		return err
+	}
 	if len(req.Rows) == 0 {
-		return nil, &ValidationError{Field: "rows", Message: "at least one row is required"}
+		return &ValidationError{Field: "rows", Message: "at least one row is required"}
+	}
+	policy, err := e.authorize(ctx, req.Table, access.VerbInsert)
+	if err != nil {
+		return err
+	}
+	for _, row := range req.Rows {
+		for col := range row {
+			if err := policy.checkColumns(col); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
 
-	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+// Update executes a validated UPDATE operation. It may need confirmation
+// first; see PreviewUpdate. With req.Returning, the updated rows are
+// reported as for Insert.
+func (e *Engine) Update(ctx context.Context, req connector.UpdateRequest) (*connector.MutationResult, error) {
+	prepared, err := e.prepareUpdate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var policy *rolePolicy
+	prepared.Returning, policy = e.returning(ctx, req.Table, req.Returning)
+
+	queryCtx, cancel := e.readContext(ctx)
 	defer cancel()
 
-	return e.connector.Insert(queryCtx, req)
+	res, err := e.confirmWrite(queryCtx, "update", prepared.Table, prepared.Where, writeDigest(req.Filter, req.Set), req.ConfirmToken,
+		func(ctx context.Context) (*connector.MutationResult, error) {
+			return e.connector.Update(ctx, prepared)
+		})
+	if err != nil {
+		return nil, err
+	}
+	e.maskReturning(ctx, req.Table, policy, res)
+	return res, nil
 }
 
-// Update executes a validated UPDATE operation.
-func (e *Engine) Update(ctx context.Context, req connector.UpdateRequest) (*connector.MutationResult, error) {
+// prepareUpdate validates an UPDATE operation and authorizes it for the
+// caller, returning the request with its filter compiled into Where and
+// restricted to the caller's rows.
+func (e *Engine) prepareUpdate(ctx context.Context, req connector.UpdateRequest) (connector.UpdateRequest, error) {
 	if err := e.validator.ValidateWrite(req.Table); err != nil {
+		return req, err
+	}
+	if len(req.Set) == 0 {
+		return req, &ValidationError{Field: "set", Message: "at least one column must be set"}
+	}
+	policy, err := e.authorize(ctx, req.Table, access.VerbUpdate)
+	if err != nil {
+		return req, err
+	}
+	for col := range req.Set {
+		if err := policy.checkColumns(col); err != nil {
+			return req, err
+		}
+	}
+	if err := policy.checkSet(req.Set); err != nil {
+		return req, err
+	}
+	where, err := compileWhere(req.Filter, req.Where)
+	if err != nil {
+		return req, err
+	}
+	if where.IsEmpty() {
+		return req, &ValidationError{Field: "filter", Message: "a filter is required for UPDATE operations (use describe_table to see rows)"}
+	}
+	if err := policy.checkFilter(where); err != nil {
+		return req, err
+	}
+	req.Where = policy.restrict(where)
+	return req, nil
+}
+
+// Delete executes a validated DELETE operation. It may need confirmation
+// first; see PreviewDelete. With req.Returning, the deleted rows are
+// reported as for Insert.
+func (e *Engine) Delete(ctx context.Context, req connector.DeleteRequest) (*connector.MutationResult, error) {
+	prepared, err := e.prepareDelete(ctx, req)
+	if err != nil {
 		return nil, err
 	}
-	if req.Filter == "" {
-		return nil, &ValidationError{Field: "filter", Message: "a filter is required for UPDATE operations (use describe_table to see rows)"}
+	var policy *rolePolicy
+	prepared.Returning, policy = e.returning(ctx, req.Table, req.Returning)
+
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	res, err := e.confirmWrite(queryCtx, "delete", prepared.Table, prepared.Where, writeDigest(req.Filter, nil), req.ConfirmToken,
+		func(ctx context.Context) (*connector.MutationResult, error) {
+			return e.connector.Delete(ctx, prepared)
+		})
+	if err != nil {
+		return nil, err
 	}
-	if len(req.Set) == 0 {
-		return nil, &ValidationError{Field: "set", Message: "at least one column must be set"}
+	e.maskReturning(ctx, req.Table, policy, res)
+	return res, nil
+}
+
+// prepareDelete validates a DELETE operation and authorizes it for the
+// caller, returning the request with its filter compiled into Where and
+// restricted to the caller's rows.
+func (e *Engine) prepareDelete(ctx context.Context, req connector.DeleteRequest) (connector.DeleteRequest, error) {
+	if err := e.validator.ValidateWrite(req.Table); err != nil {
+		return req, err
+	}
+	policy, err := e.authorize(ctx, req.Table, access.VerbDelete)
+	if err != nil {
+		return req, err
+	}
+	where, err := compileWhere(req.Filter, req.Where)
+	if err != nil {
+		return req, err
+	}
+	if where.IsEmpty() {
+		return req, &ValidationError{Field: "filter", Message: "a filter is required for DELETE operations"}
+	}
+	if err := policy.checkFilter(where); err != nil {
+		return req, err
+	}
+	req.Where = policy.restrict(where)
+	return req, nil
+}
+
+// returning reports whether the rows a write to table affects are reported
+// back to the caller, as asked by want, along with the policy they are
+// masked by. The rows are only reported to callers who may select them;
+// for others the write reports its row count alone.
+func (e *Engine) returning(ctx context.Context, table string, want bool) (bool, *rolePolicy) {
+	if !want {
+		return false, nil
+	}
+	policy, err := e.authorize(ctx, table, access.VerbSelect)
+	if err != nil {
+		return false, nil
+	}
+	return true, policy
+}
+
+// maskReturning masks the rows reported by a write to table as Select
+// masks the rows it returns.
+func (e *Engine) maskReturning(ctx context.Context, table string, policy *rolePolicy, res *connector.MutationResult) {
+	if len(res.Returning) == 0 {
+		return
 	}
-	if err := SanitizeFilterInput(req.Filter); err != nil {
+	rs := &connector.ResultSet{Rows: res.Returning}
+	for col := range res.Returning[0] {
+		rs.Columns = append(rs.Columns, col)
+	}
+	if e.maskPII {
+		if err := e.applyPIIMasking(ctx, table, rs); err != nil {
+			e.logger.Warn("PII masking by table schema failed, masking by column name",
+				slog.String("table", table),
+				slog.String("error", err.Error()))
+			e.maskByColumnName(rs)
+		}
+	}
+	policy.apply(rs, e.piiDetector)
+}
+
+// compileWhere compiles a caller's filter expression and ANDs it with any
+// condition the request already carries.
+func compileWhere(filter string, where *connector.Filter) (*connector.Filter, error) {
+	compiled, err := CompileFilter(filter)
+	if err != nil {
 		return nil, err
 	}
+	return connector.AndFilters(where, compiled), nil
+}
 
+// ListTables returns the tables visible through the engine's connector,
+// limited to those the caller's role can access.
+func (e *Engine) ListTables(ctx context.Context) ([]schema.TableSummary, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+	defer cancel()
+	tables, err := e.cache.ListTables(queryCtx)
+	if err != nil {
+		return nil, err
+	}
+	role := e.role(ctx)
+	if role == "" {
+		return tables, nil
+	}
+	visible := make([]schema.TableSummary, 0, len(tables))
+	for _, t := range tables {
+		if e.access.CanAccess(role, t.Name) {
+			visible = append(visible, t)
+		}
+	}
+	return visible, nil
+}
+
+// DescribeTable returns the schema for a table. When PII masking is enabled,
+// columns that would be excluded from results are omitted so that generated
+// tools never advertise them. Columns denied to the caller's role are omitted
+// for the same reason.
+func (e *Engine) DescribeTable(ctx context.Context, table string) (*schema.TableDetail, error) {
+	if err := ValidateIdentifier(table); err != nil {
+		return nil, &ValidationError{Field: "table", Message: err.Error()}
+	}
+	policy, err := e.authorizeAny(ctx, table)
+	if err != nil {
+		return nil, err
+	}
 	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
 	defer cancel()
 
-	return e.connector.Update(queryCtx, req)
+	cached, err := e.cache.DescribeTable(queryCtx, table)
+	if err != nil {
+		return nil, err
+	}
+	// The cache may hand out its own entry, which masking and access checks
+	// read the full columns from, so only filter a copy.
+	detail := *cached
+	td := &detail
+	if e.maskPII {
+		td.Columns = e.piiDetector.FilterColumns(td.Columns)
+	}
+	if policy != nil && len(policy.denied) > 0 {
+		cols := make([]schema.ColumnInfo, 0, len(td.Columns))
+		for _, c := range td.Columns {
+			if !policy.isDenied(c.Name) {
+				cols = append(cols, c)
+			}
+		}
+		td.Columns = cols
+	}
+	return td, nil
 }
 
-// Delete executes a validated DELETE operation.
-func (e *Engine) Delete(ctx context.Context, req connector.DeleteRequest) (*connector.MutationResult, error) {
-	if err := e.validator.ValidateWrite(req.Table); err != nil {
+// RefreshSchema discards all cached schema metadata and reloads the table list.
+func (e *Engine) RefreshSchema(ctx context.Context) error {
+	e.cache.InvalidateAll()
+	_, err := e.ListTables(ctx)
+	return err
+}
+
+// ListProcedures returns the stored procedures visible to the connector.
+func (e *Engine) ListProcedures(ctx context.Context) ([]schema.ProcedureSummary, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+	defer cancel()
+	return e.connector.ListProcedures(queryCtx)
+}
+
+// CallProcedure executes a stored procedure. Results are masked by column
+// name when PII masking is enabled, since they are not tied to a table.
+func (e *Engine) CallProcedure(ctx context.Context, req connector.ProcedureCallRequest) (*connector.ResultSet, error) {
+	if err := ValidateIdentifier(req.Name); err != nil {
+		return nil, &ValidationError{Field: "name", Message: err.Error()}
+	}
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	rs, err := e.connector.CallProcedure(queryCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	e.maskByColumnName(rs)
+	audit.AddRows(ctx, len(rs.Rows))
+	return rs, nil
+}
+
+// QueryRaw executes a read-only SQL statement written by the caller. The
+// statement must pass Statement.CheckReadOnly and the caller's table
+// policies, and the connector caps the rows read at the configured maximum.
+func (e *Engine) QueryRaw(ctx context.Context, sql string) (*connector.ResultSet, error) {
+	stmt, err := ParseStatement(sql, e.connector.DriverName())
+	if err != nil {
 		return nil, err
 	}
-	if req.Filter == "" {
-		return nil, &ValidationError{Field: "filter", Message: "a filter is required for DELETE operations"}
+	if err := stmt.CheckReadOnly(); err != nil {
+		return nil, err
 	}
-	if err := SanitizeFilterInput(req.Filter); err != nil {
+	audit.AddTables(ctx, stmt.Tables...)
+	policies, err := e.authorizeRaw(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := e.connector.QueryRaw(connector.WithScanLimits(ctx, e.validator.ScanLimits()), connector.RawQueryRequest{
+		SQL:     sql,
+		MaxRows: e.validator.MaxRows(),
+		Timeout: e.validator.QueryTimeout(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Denied columns cannot be named in the statement, so this only masks
+	// the tables' masked columns, matched by result column name.
+	for _, p := range policies {
+		p.apply(rs, e.piiDetector)
+	}
+	e.maskByColumnName(rs)
+	audit.AddRows(ctx, len(rs.Rows))
+	return rs, nil
+}
+
+// ExecRaw executes an arbitrary SQL statement written by the caller. It
+// requires writes to be enabled and a role, if any, that holds the verbs the
+// statement needs on every table it references.
+func (e *Engine) ExecRaw(ctx context.Context, sql string) (*connector.MutationResult, error) {
+	if !e.validator.AllowWrites() {
+		return nil, &ValidationError{
+			Field:   "operation",
+			Message: "write operations are disabled (start with --allow-writes to enable)",
+		}
+	}
+	if role := e.role(ctx); role != "" && !e.access.CanWrite(role) {
+		return nil, fmt.Errorf("role %q does not have write access", role)
+	}
+	stmt, err := ParseStatement(sql, e.connector.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	audit.AddTables(ctx, stmt.Tables...)
+	if _, err := e.authorizeRaw(ctx, stmt); err != nil {
 		return nil, err
 	}
 
 	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
 	defer cancel()
 
-	return e.connector.Delete(queryCtx, req)
+	return e.connector.ExecRaw(queryCtx, sql)
+}
+
+// readContext bounds a read, or a write that reports its rows, by the query
+// timeout and has the connector scan its result within the result size
+// limits.
+func (e *Engine) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+	return connector.WithScanLimits(ctx, e.validator.ScanLimits()), cancel
+}
+
+// DriverName returns the underlying connector's driver name.
+func (e *Engine) DriverName() string {
+	return e.connector.DriverName()
+}
+
+// maskByColumnName applies PII masking to a result set that has no table
+// schema to consult, using the result column names alone.
+func (e *Engine) maskByColumnName(rs *connector.ResultSet) {
+	if !e.maskPII || rs == nil || len(rs.Columns) == 0 {
+		return
+	}
+	cols := make([]schema.ColumnInfo, len(rs.Columns))
+	for i, c := range rs.Columns {
+		cols[i] = schema.ColumnInfo{Name: c}
+	}
+	e.maskResult(&schema.TableDetail{Columns: cols}, rs)
 }
 
 // applyPIIMasking detects and masks PII columns in query results.
@@ -170,12 +634,18 @@ func (e *Engine) applyPIIMasking(ctx context.Context, tableName string, rs *conn
 		return fmt.Errorf("failed to get table detail for PII detection: %w", err)
 	}
 
-	// Get columns that need masking.
+	e.maskResult(td, rs)
+	return nil
+}
+
+// maskResult removes excluded PII columns from rs and masks the values of
+// masked ones, using td to classify the columns.
+func (e *Engine) maskResult(td *schema.TableDetail, rs *connector.ResultSet) {
 	maskedCols := e.piiDetector.MaskedColumns(td)
 	excludedCols := e.piiDetector.ExcludedColumns(td)
 
 	if len(maskedCols) == 0 && len(excludedCols) == 0 {
-		return nil
+		return
 	}
 
 	// Remove excluded columns from results.
@@ -198,8 +668,31 @@ func (e *Engine) applyPIIMasking(ctx context.Context, tableName string, rs *conn
 
 	// Mask sensitive values.
 	schema.MaskRows(rs.Rows, maskedCols)
+}
 
-	return nil
+// hiddenColumns returns the lower-cased names of the columns of td whose
+// values are masked or removed from the results policy's caller sees.
+func (e *Engine) hiddenColumns(td *schema.TableDetail, policy *rolePolicy) map[string]bool {
+	hidden := make(map[string]bool)
+	if e.maskPII {
+		for col := range e.piiDetector.ExcludedColumns(td) {
+			hidden[strings.ToLower(col)] = true
+		}
+		for col := range e.piiDetector.MaskedColumns(td) {
+			hidden[strings.ToLower(col)] = true
+		}
+	}
+	if policy != nil {
+		for _, col := range policy.masked {
+			hidden[strings.ToLower(col)] = true
+		}
+		for _, c := range td.Columns {
+			if policy.isDenied(c.Name) {
+				hidden[strings.ToLower(c.Name)] = true
+			}
+		}
+	}
+	return hidden
 }
 
 // Validator returns the engine's validator for external use.
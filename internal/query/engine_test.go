@@ -0,0 +1,978 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/connector/sqlite"
+	"github.com/conduitdb/conduit/internal/demo"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// newTenantEngine returns an engine over the demo database whose "customer"
+// role only sees the orders of the session's customer.
+func newTenantEngine(t *testing.T) *Engine {
+	t.Helper()
+	return newRoleEngine(t, access.Role{
+		Name: "customer",
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT", "UPDATE", "DELETE"}, RowFilter: "customer_id = {{user.customer}}"},
+		},
+	})
+}
+
+// newRoleEngine returns a writable engine over the demo database with the
+// given roles.
+func newRoleEngine(t *testing.T, roles ...access.Role) *Engine {
+	t.Helper()
+	ctx := context.Background()
+	dsn, cleanup, err := demo.CreateDemoDB(ctx)
+	if err != nil {
+		t.Fatalf("failed to create demo db: %v", err)
+	}
+	t.Cleanup(cleanup)
+	conn := &sqlite.Connector{}
+	if err := conn.Open(ctx, connector.ConnectionConfig{DSN: dsn}); err != nil {
+		t.Fatalf("failed to open connector: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	rbac := access.NewEngine(roles)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), logger)
+	return NewEngine(conn, cache, EngineConfig{
+		Limits: Limits{MaxRows: 100, AllowWrites: true},
+		Access: rbac,
+	}, logger)
+}
+
+func TestEngine_RowFilter(t *testing.T) {
+	e := newTenantEngine(t)
+	ctx := access.WithIdentity(context.Background(), &access.Identity{
+		User:       "alice",
+		Role:       "customer",
+		Attributes: map[string]any{"customer": 1},
+	})
+
+	rs, err := e.Select(ctx, connector.SelectRequest{Table: "orders", Filter: "status = 'delivered' OR status <> 'delivered'"})
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(rs.Rows) != 2 {
+		t.Fatalf("expected customer 1's 2 orders, got %d", len(rs.Rows))
+	}
+	for _, row := range rs.Rows {
+		if row["customer_id"] != int64(1) {
+			t.Errorf("row outside the row filter: %v", row)
+		}
+	}
+
+	// By-id lookups of another customer's order find nothing.
+	rs, err = e.Select(ctx, connector.SelectRequest{Table: "orders", Where: connector.Eq([]string{"id"}, []any{2})})
+	if err != nil {
+		t.Fatalf("select by id: %v", err)
+	}
+	if len(rs.Rows) != 0 {
+		t.Errorf("by-id lookup bypassed the row filter: %v", rs.Rows)
+	}
+
+	res, err := e.Update(ctx, connector.UpdateRequest{Table: "orders", Set: map[string]any{"status": "cancelled"}, Filter: "id > 0"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if res.RowsAffected != 2 {
+		t.Errorf("update affected %d rows, want 2", res.RowsAffected)
+	}
+	if _, err := e.Update(ctx, connector.UpdateRequest{Table: "orders", Set: map[string]any{"customer_id": 2}, Filter: "id = 1"}); err == nil {
+		t.Error("updating a row filter column should fail")
+	}
+	res, err = e.Delete(ctx, connector.DeleteRequest{Table: "orders", Filter: "id = 2"})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if res.RowsAffected != 0 {
+		t.Errorf("deleted another customer's order")
+	}
+
+	// A session without the attribute is denied rather than unfiltered.
+	noTenant := access.WithIdentity(context.Background(), &access.Identity{Role: "customer"})
+	if _, err := e.Select(noTenant, connector.SelectRequest{Table: "orders"}); err == nil || !strings.Contains(err.Error(), "{{user.customer}}") {
+		t.Errorf("expected missing attribute error, got %v", err)
+	}
+}
+
+func TestEngine_DescribeTableKeepsCache(t *testing.T) {
+	e := newRoleEngine(t)
+	e.maskPII = true
+	ctx := context.Background()
+	if _, err := e.connector.ExecRaw(ctx, "CREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT, password TEXT)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := e.connector.ExecRaw(ctx, "INSERT INTO accounts (name, password) VALUES ('ann', 'hunter2')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	// Describing the table on a cold cache must not strip the excluded
+	// column from the cached schema that masking reads.
+	td, err := e.DescribeTable(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	for _, col := range td.Columns {
+		if col.Name == "password" {
+			t.Errorf("describe_table advertised an excluded column: %v", td.Columns)
+		}
+	}
+	rs, err := e.Select(ctx, connector.SelectRequest{Table: "accounts"})
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(rs.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %v", rs.Rows)
+	}
+	if _, ok := rs.Rows[0]["password"]; ok {
+		t.Errorf("excluded column returned after describe: %v", rs.Rows[0])
+	}
+}
+
+func TestEngine_DescribeTableDeniedColumnsPerRole(t *testing.T) {
+	e := newRoleEngine(t,
+		access.Role{Name: "clerk", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"SELECT"}, DenyColumns: []string{"phone"}},
+		}},
+		access.Role{Name: "manager", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"SELECT"}},
+		}},
+	)
+	clerk := access.WithIdentity(context.Background(), &access.Identity{User: "c", Role: "clerk"})
+	manager := access.WithIdentity(context.Background(), &access.Identity{User: "m", Role: "manager"})
+	hasPhone := func(td *schema.TableDetail) bool {
+		for _, col := range td.Columns {
+			if col.Name == "phone" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The clerk describes the table first, on a cold cache.
+	td, err := e.DescribeTable(clerk, "customers")
+	if err != nil {
+		t.Fatalf("describe as clerk: %v", err)
+	}
+	if hasPhone(td) {
+		t.Error("denied column phone described to the clerk")
+	}
+
+	td, err = e.DescribeTable(manager, "customers")
+	if err != nil {
+		t.Fatalf("describe as manager: %v", err)
+	}
+	if !hasPhone(td) {
+		t.Error("the clerk's denied column was dropped for the manager")
+	}
+	rs, err := e.Select(manager, connector.SelectRequest{Table: "customers", Columns: []string{"id", "phone"}, Limit: 1})
+	if err != nil {
+		t.Fatalf("select as manager: %v", err)
+	}
+	if len(rs.Rows) != 1 || rs.Rows[0]["phone"] == nil {
+		t.Errorf("expected the manager to see phone, got %v", rs.Rows)
+	}
+	if _, err := e.Select(clerk, connector.SelectRequest{Table: "customers", Columns: []string{"phone"}}); err == nil {
+		t.Error("expected selecting phone as the clerk to fail")
+	}
+}
+
+func TestEngine_RawSQLAccess(t *testing.T) {
+	e := newRoleEngine(t, access.Role{
+		Name: "analyst",
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT"}},
+			{Name: "customers", DenyColumns: []string{"phone"}, MaskColumns: []string{"email"}},
+			{Name: "orders", RowFilter: "customer_id = {{user.customer}}"},
+			{Name: "products", Verbs: []string{"SELECT", "UPDATE"}, RowFilter: "stock > 0", AllowRawSQL: true},
+			{Name: "reviews", Verbs: []string{}},
+		},
+	}, access.Role{
+		Name:   "catalog",
+		Tables: []access.TablePolicy{{Name: "products", Verbs: []string{"SELECT"}}},
+	})
+	ctx := access.WithIdentity(context.Background(), &access.Identity{
+		User:       "alice",
+		Role:       "analyst",
+		Attributes: map[string]any{"customer": 1},
+	})
+	catalog := access.WithIdentity(context.Background(), &access.Identity{User: "bob", Role: "catalog"})
+
+	queries := []struct {
+		name string
+		sql  string
+		want string // error substring; empty if allowed
+	}{
+		{"allowed", "SELECT p.name, i.quantity FROM products p JOIN order_items i ON i.product_id = p.id", ""},
+		{"denied table", "SELECT * FROM products WHERE id IN (SELECT product_id FROM reviews)", `table "reviews"`},
+		{"denied column", "SELECT first_name, phone FROM customers", `column "phone" of table "customers"`},
+		{"denied column in filter", "SELECT id FROM customers WHERE phone LIKE '555%'", `column "phone"`},
+		{"star with denied column", "SELECT c.* FROM customers c", `table "customers" has columns hidden`},
+		{"masked column", "SELECT first_name, email FROM customers WHERE id = 1", `column "email" of table "customers" is masked`},
+		{"aliased masked column", "SELECT email AS x FROM customers", `column "email" of table "customers" is masked`},
+		{"masked column in expression", "SELECT lower(email) FROM customers", `column "email" of table "customers" is masked`},
+		{"unmasked columns", "SELECT first_name, city FROM customers WHERE id = 1", ""},
+		{"row filter", "SELECT COUNT(*) FROM orders", `table "orders" has a row filter`},
+		{"show", "SHOW TABLES", "SHOW statements"},
+	}
+	for _, tt := range queries {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.QueryRaw(ctx, tt.sql)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Tables hidden in parentheses or behind table functions must be
+	// checked, or refused, like any other.
+	hidden := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"allowed", "SELECT name FROM (products)", ""},
+		{"table", "SELECT * FROM customers", `table "customers"`},
+		{"parenthesized table", "SELECT * FROM (customers)", `table "customers"`},
+		{"doubly parenthesized", "SELECT * FROM ((customers) c)", `table "customers"`},
+		{"parenthesized join", "SELECT * FROM products JOIN (customers) ON 1=1", `table "customers"`},
+		{"nested join", "SELECT * FROM (products p JOIN customers c ON 1=1)", `table "customers"`},
+		{"parenthesized in list", "SELECT * FROM products, (customers)", `table "customers"`},
+		{"table function", "SELECT * FROM pragma_table_info('customers')", `"pragma_table_info" is not one`},
+		{"joined table function", "SELECT * FROM products JOIN pragma_table_info('customers') ON 1=1", `"pragma_table_info" is not one`},
+		{"straight join", "SELECT * FROM products STRAIGHT_JOIN customers", `table "customers"`},
+		{"unknown join", "SELECT * FROM products p UNKNOWN_JOIN customers", `"UNKNOWN_JOIN" is not one`},
+		{"file read", "SELECT name, readfile('conduit.db') FROM products", "may not call readfile"},
+		{"extension", "SELECT load_extension('evil') FROM products", "may not call load_extension"},
+	}
+	for _, tt := range hidden {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.QueryRaw(catalog, tt.sql)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	execs := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"update allowed", "UPDATE products SET stock = stock + 1 WHERE id = 1", ""},
+		{"insert denied", "INSERT INTO products (name) SELECT first_name FROM customers", `INSERT access on table "products"`},
+		{"delete denied", "DELETE FROM order_items WHERE id = 1", `DELETE access on table "order_items"`},
+		{"ddl denied", "DROP TABLE products", "may not run DROP statements"},
+		{"select into denied", "SELECT * INTO copy FROM products", "may not run CREATE statements"},
+	}
+	for _, tt := range execs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.ExecRaw(ctx, tt.sql)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEngine_Aggregate(t *testing.T) {
+	e := newRoleEngine(t, access.Role{
+		Name: "analyst",
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT"}},
+			{Name: "customers", DenyColumns: []string{"phone"}, MaskColumns: []string{"email"}},
+		},
+	})
+	ctx := access.WithIdentity(context.Background(), &access.Identity{User: "alice", Role: "analyst"})
+
+	rs, err := e.Aggregate(ctx, connector.AggregateRequest{
+		Table:        "orders",
+		GroupBy:      []string{"STATUS"},
+		Aggregates:   []connector.Aggregate{{Func: "count"}, {Func: "avg", Column: "total"}},
+		Filter:       "total > 10",
+		HavingFilter: "count >= 2",
+		OrderBy:      "count DESC, status",
+	})
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	if got := strings.Join(rs.Columns, ","); got != "status,count,avg_total" {
+		t.Errorf("columns = %s", got)
+	}
+	if len(rs.Rows) != 3 || rs.Rows[0]["status"] != "delivered" || rs.Rows[0]["count"] != int64(5) {
+		t.Errorf("unexpected rows: %v", rs.Rows)
+	}
+
+	// Grouping by a masked column masks the group values.
+	rs, err = e.Aggregate(ctx, connector.AggregateRequest{Table: "customers", GroupBy: []string{"email"}, Limit: 1})
+	if err != nil {
+		t.Fatalf("aggregate by masked column: %v", err)
+	}
+	if email, _ := rs.Rows[0]["email"].(string); !strings.Contains(email, "*") {
+		t.Errorf("expected masked email, got %q", email)
+	}
+
+	errs := []struct {
+		name string
+		req  connector.AggregateRequest
+		want string
+	}{
+		{"unknown column", connector.AggregateRequest{Table: "orders", GroupBy: []string{"region"}}, `no column "region"`},
+		{"unknown function", connector.AggregateRequest{Table: "orders", Aggregates: []connector.Aggregate{{Func: "median", Column: "total"}}}, "unknown aggregate function"},
+		{"sum of text", connector.AggregateRequest{Table: "orders", Aggregates: []connector.Aggregate{{Func: "sum", Column: "status"}}}, "requires a numeric column"},
+		{"sum without column", connector.AggregateRequest{Table: "orders", Aggregates: []connector.Aggregate{{Func: "sum"}}}, "requires a column"},
+		{"duplicate alias", connector.AggregateRequest{Table: "orders", GroupBy: []string{"status"}, Aggregates: []connector.Aggregate{{Func: "count", Alias: "status"}}}, "duplicate result column"},
+		{"bad alias", connector.AggregateRequest{Table: "orders", Aggregates: []connector.Aggregate{{Func: "count", Alias: "n; --"}}}, "invalid alias"},
+		{"having on input column", connector.AggregateRequest{Table: "orders", GroupBy: []string{"status"}, HavingFilter: "total > 5"}, `"total" is not a group-by column`},
+		{"order by input column", connector.AggregateRequest{Table: "orders", GroupBy: []string{"status"}, OrderBy: "total"}, `"total" is not a group-by column`},
+		{"denied column", connector.AggregateRequest{Table: "customers", GroupBy: []string{"phone"}}, `column "phone" is not accessible`},
+		{"max of masked column", connector.AggregateRequest{Table: "customers", Aggregates: []connector.Aggregate{{Func: "max", Column: "email"}}}, "only count"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Aggregate(ctx, tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEngine_Expand(t *testing.T) {
+	e := newRoleEngine(t, access.Role{
+		Name: "analyst",
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT"}},
+			{Name: "customers", DenyColumns: []string{"phone"}, MaskColumns: []string{"email"}},
+			{Name: "reviews", Verbs: []string{}},
+		},
+	})
+	ctx := access.WithIdentity(context.Background(), &access.Identity{User: "alice", Role: "analyst"})
+
+	rs, err := e.Select(ctx, connector.SelectRequest{
+		Table:   "orders",
+		Columns: []string{"id", "total"},
+		OrderBy: "id",
+		Limit:   3,
+		Expand: []connector.Expand{
+			{Table: "customers", Columns: []string{"first_name", "email"}},
+			{Table: "order_items", As: "items", Columns: []string{"product_id"}, Limit: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if got := strings.Join(rs.Columns, ","); got != "id,total,customers,items" {
+		t.Errorf("columns = %s", got)
+	}
+	if _, ok := rs.Rows[0]["customer_id"]; ok {
+		t.Error("key column added for the expansion should not be returned")
+	}
+	customer, _ := rs.Rows[0]["customers"].(map[string]any)
+	if customer["first_name"] == nil || customer["id"] != nil {
+		t.Errorf("unexpected customer: %v", customer)
+	}
+	if email, _ := customer["email"].(string); !strings.Contains(email, "*") {
+		t.Errorf("expected masked email, got %q", email)
+	}
+	for i, want := range []int{2, 1, 2} {
+		items, _ := rs.Rows[i]["items"].([]map[string]any)
+		if len(items) != want {
+			t.Errorf("order %v: got %d items, want %d", rs.Rows[i]["id"], len(items), want)
+		}
+		for _, item := range items {
+			if _, ok := item["order_id"]; ok || item["product_id"] == nil {
+				t.Errorf("unexpected item: %v", item)
+			}
+		}
+	}
+
+	rs, err = e.Select(ctx, connector.SelectRequest{
+		Table:  "order_items",
+		Where:  connector.Eq([]string{"id"}, []any{1}),
+		Expand: []connector.Expand{{Table: "products", As: "product", Columns: []string{"name"}, Flatten: true}},
+	})
+	if err != nil {
+		t.Fatalf("select flattened: %v", err)
+	}
+	if rs.Rows[0]["product.name"] == nil || rs.Rows[0]["product_id"] == nil {
+		t.Errorf("unexpected row: %v", rs.Rows[0])
+	}
+
+	errs := []struct {
+		name   string
+		expand connector.Expand
+		want   string
+	}{
+		{"undeclared relationship", connector.Expand{Table: "products"}, `no foreign key relates "orders" and "products"`},
+		{"unknown via", connector.Expand{Table: "customers", Via: "id"}, `no foreign key "id"`},
+		{"flatten one-to-many", connector.Expand{Table: "order_items", Flatten: true}, "cannot be flattened"},
+		{"name clash", connector.Expand{Table: "customers", As: "status"}, "clashes"},
+		{"denied table", connector.Expand{Table: "reviews"}, `SELECT access on table "reviews"`},
+		{"denied column", connector.Expand{Table: "customers", Columns: []string{"phone"}}, `column "phone" is not accessible`},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Select(ctx, connector.SelectRequest{Table: "orders", Expand: []connector.Expand{tt.expand}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEngine_Pagination(t *testing.T) {
+	e := newRoleEngine(t)
+	ctx := context.Background()
+
+	req := connector.SelectRequest{Table: "orders", Columns: []string{"id"}, OrderBy: "total DESC", Limit: 4, Count: true}
+	seen := make(map[int64]bool)
+	var pages []int
+	for {
+		rs, err := e.Select(ctx, req)
+		if err != nil {
+			t.Fatalf("page %d: %v", len(pages)+1, err)
+		}
+		if rs.Total != 10 {
+			t.Errorf("total = %d, want 10", rs.Total)
+		}
+		if got := strings.Join(rs.Columns, ","); got != "id" {
+			t.Errorf("columns = %s; key columns should not be returned", got)
+		}
+		pages = append(pages, len(rs.Rows))
+		for _, row := range rs.Rows {
+			id := row["id"].(int64)
+			if seen[id] {
+				t.Errorf("order %d returned twice", id)
+			}
+			seen[id] = true
+		}
+		if rs.HasMore != (rs.NextCursor != "") {
+			t.Fatalf("has_more = %v with cursor %q", rs.HasMore, rs.NextCursor)
+		}
+		if !rs.HasMore {
+			break
+		}
+		req.Cursor = rs.NextCursor
+	}
+	if len(pages) != 3 || pages[0] != 4 || pages[1] != 4 || pages[2] != 2 {
+		t.Errorf("pages = %v, want [4 4 2]", pages)
+	}
+
+	// Nullable sort columns cannot form a keyset: more rows, but no cursor.
+	rs, err := e.Select(ctx, connector.SelectRequest{Table: "orders", OrderBy: "shipping_address", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.HasMore || rs.NextCursor != "" {
+		t.Errorf("has_more = %v, cursor = %q", rs.HasMore, rs.NextCursor)
+	}
+
+	rs, err = e.Select(ctx, connector.SelectRequest{Table: "orders", Filter: "status = 'delivered'", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor := rs.NextCursor
+	errs := []struct {
+		name string
+		req  connector.SelectRequest
+		want string
+	}{
+		{"tampered", connector.SelectRequest{Table: "orders", Filter: "status = 'delivered'", Cursor: "x" + cursor}, "invalid cursor"},
+		{"other filter", connector.SelectRequest{Table: "orders", Filter: "status = 'shipped'", Cursor: cursor}, "does not match"},
+		{"other order", connector.SelectRequest{Table: "orders", Filter: "status = 'delivered'", OrderBy: "total", Cursor: cursor}, "does not match"},
+		{"other table", connector.SelectRequest{Table: "products", Filter: "status = 'delivered'", Cursor: cursor}, "does not match"},
+		{"with offset", connector.SelectRequest{Table: "orders", Filter: "status = 'delivered'", Cursor: cursor, Offset: 2}, "cannot be combined"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Select(ctx, tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEngine_PaginationTruncatedKey(t *testing.T) {
+	e := newRoleEngine(t)
+	e.validator = NewValidator(Limits{MaxRows: 100, MaxCellBytes: 16})
+	ctx := context.Background()
+	if _, err := e.connector.ExecRaw(ctx, "CREATE TABLE codes (code TEXT PRIMARY KEY NOT NULL)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	prefix := strings.Repeat("a", 20)
+	for _, n := range []string{"1", "2", "3"} {
+		if _, err := e.connector.ExecRaw(ctx, "INSERT INTO codes VALUES ('"+prefix+n+"')"); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	// The cut key would resume after every row of the table.
+	rs, err := e.Select(ctx, connector.SelectRequest{Table: "codes", Limit: 1})
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if len(rs.Rows) != 1 || !strings.HasSuffix(rs.Rows[0]["code"].(string), connector.TruncationMarker) {
+		t.Fatalf("expected one cut key, got %v", rs.Rows)
+	}
+	if !rs.HasMore || rs.NextCursor != "" {
+		t.Errorf("has_more = %v, cursor = %q; want more rows but no cursor from a cut key", rs.HasMore, rs.NextCursor)
+	}
+	rs, err = e.Select(ctx, connector.SelectRequest{Table: "codes", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("select by offset: %v", err)
+	}
+	if len(rs.Rows) != 1 || !strings.HasPrefix(rs.Rows[0]["code"].(string), prefix[:16]) || !rs.HasMore {
+		t.Errorf("offset page = %v, has_more = %v", rs.Rows, rs.HasMore)
+	}
+
+	// Short keys still page by cursor.
+	e.validator = NewValidator(Limits{MaxRows: 100, MaxCellBytes: 64})
+	rs, err = e.Select(ctx, connector.SelectRequest{Table: "codes", Limit: 1})
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if rs.NextCursor == "" {
+		t.Fatal("expected a cursor for uncut keys")
+	}
+	rs, err = e.Select(ctx, connector.SelectRequest{Table: "codes", Limit: 1, Cursor: rs.NextCursor})
+	if err != nil {
+		t.Fatalf("next page: %v", err)
+	}
+	if len(rs.Rows) != 1 || rs.Rows[0]["code"] != prefix+"2" {
+		t.Errorf("next page = %v, want %s2", rs.Rows, prefix)
+	}
+}
+
+func TestEngine_ResultSizeLimit(t *testing.T) {
+	e := newRoleEngine(t)
+	e.validator = NewValidator(Limits{MaxRows: 100, MaxResultSizeBytes: 1500})
+	ctx := context.Background()
+
+	req := connector.SelectRequest{Table: "products", Limit: 10}
+	seen := make(map[int64]bool)
+	for pages := 0; ; pages++ {
+		if pages == 10 {
+			t.Fatal("pagination did not finish")
+		}
+		rs, err := e.Select(ctx, req)
+		if err != nil {
+			t.Fatalf("select: %v", err)
+		}
+		if len(rs.Rows) == 0 {
+			t.Fatal("expected rows within the size limit")
+		}
+		for _, row := range rs.Rows {
+			seen[row["id"].(int64)] = true
+		}
+		if !rs.HasMore {
+			break
+		}
+		if len(rs.Rows) < 10 && (!rs.Truncated || !strings.Contains(rs.TruncatedReason, "size limit")) {
+			t.Errorf("short page of %d rows not reported as truncated", len(rs.Rows))
+		}
+		req.Cursor = rs.NextCursor
+	}
+	if len(seen) != 10 {
+		t.Errorf("paged through %d products, want 10", len(seen))
+	}
+}
+
+func TestEngine_Transaction(t *testing.T) {
+	e := newRoleEngine(t)
+	ctx := context.Background()
+	count := func(table, filter string) int {
+		t.Helper()
+		rs, err := e.Select(ctx, connector.SelectRequest{Table: table, Filter: filter})
+		if err != nil {
+			t.Fatalf("select %s: %v", table, err)
+		}
+		return len(rs.Rows)
+	}
+	newOrder := connector.WriteStep{Insert: &connector.InsertRequest{
+		Table: "orders",
+		Rows:  []map[string]any{{"id": 100, "customer_id": 1, "total": 25.0}},
+	}}
+	items := func(quantity any) connector.WriteStep {
+		return connector.WriteStep{Insert: &connector.InsertRequest{
+			Table: "order_items",
+			Rows: []map[string]any{
+				{"order_id": 100, "product_id": 1, "quantity": 1, "unit_price": 5.0},
+				{"order_id": 100, "product_id": 2, "quantity": quantity, "unit_price": 10.0},
+			},
+		}}
+	}
+
+	// A failing step rolls back the steps before it.
+	_, err := e.Transaction(ctx, connector.TransactionRequest{Steps: []connector.WriteStep{newOrder, items(nil)}})
+	if err == nil || !strings.Contains(err.Error(), "step 2 (insert order_items)") {
+		t.Fatalf("expected step 2 to fail, got %v", err)
+	}
+	if n := count("orders", "id = 100"); n != 0 {
+		t.Error("order of a failed transaction was committed")
+	}
+
+	// Steps are validated before any runs.
+	_, err = e.Transaction(ctx, connector.TransactionRequest{Steps: []connector.WriteStep{
+		newOrder,
+		{Delete: &connector.DeleteRequest{Table: "order_items"}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "step 2: ") {
+		t.Fatalf("expected unfiltered delete to be rejected, got %v", err)
+	}
+
+	res, err := e.Transaction(ctx, connector.TransactionRequest{Steps: []connector.WriteStep{
+		newOrder,
+		items(2),
+		{Update: &connector.UpdateRequest{Table: "orders", Set: map[string]any{"status": "shipped"}, Filter: "id = 100"}},
+	}})
+	if err != nil {
+		t.Fatalf("transaction: %v", err)
+	}
+	if !res.Committed || res.Steps[1].RowsAffected != 2 || res.Steps[2].RowsAffected != 1 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if n := count("orders", "id = 100 AND status = 'shipped'"); n != 1 {
+		t.Error("committed order not found")
+	}
+
+	// A dry run reports what each step would do and changes nothing.
+	res, err = e.Transaction(ctx, connector.TransactionRequest{DryRun: true, Steps: []connector.WriteStep{
+		{Delete: &connector.DeleteRequest{Table: "order_items", Filter: "order_id = 100"}},
+		{Delete: &connector.DeleteRequest{Table: "orders", Filter: "id = 100"}},
+	}})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if res.Committed || !res.DryRun || res.Steps[0].RowsAffected != 2 || res.Steps[1].RowsAffected != 1 {
+		t.Errorf("unexpected dry run result: %+v", res)
+	}
+	if n := count("order_items", "order_id = 100"); n != 2 {
+		t.Errorf("dry run deleted order items: %d left", n)
+	}
+}
+
+func TestEngine_ConfirmWrites(t *testing.T) {
+	e := newRoleEngine(t)
+	e.validator = NewValidator(Limits{MaxRows: 100, AllowWrites: true, ConfirmThreshold: 3})
+	ctx := context.Background()
+	archive := connector.UpdateRequest{Table: "orders", Filter: "status = 'delivered'", Set: map[string]any{"status": "archived"}}
+
+	// Writes below the threshold run at once.
+	res, err := e.Update(ctx, connector.UpdateRequest{Table: "orders", Filter: "status = 'processing'", Set: map[string]any{"status": "on_hold"}})
+	if err != nil || res.RowsAffected != 2 {
+		t.Fatalf("update below the threshold: %v, %+v", err, res)
+	}
+
+	if _, err := e.Update(ctx, archive); !errors.Is(err, connector.ErrConfirmationRequired) {
+		t.Fatalf("expected confirmation to be required, got %v", err)
+	}
+	preview, err := e.PreviewUpdate(ctx, archive)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if preview.RowsAffected != 5 || len(preview.Sample) != 5 || preview.ConfirmToken == "" {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+	for _, row := range preview.Sample {
+		if row["status"] != "delivered" {
+			t.Errorf("sample row would not be updated: %v", row)
+		}
+	}
+
+	for name, tt := range map[string]struct {
+		req  connector.UpdateRequest
+		want string
+	}{
+		"other values": {connector.UpdateRequest{Table: "orders", Filter: archive.Filter, Set: map[string]any{"status": "lost"}}, "does not match this write"},
+		"bad token":    {connector.UpdateRequest{Table: "orders", Filter: archive.Filter, Set: archive.Set, ConfirmToken: "x.y"}, "invalid confirmation token"},
+	} {
+		if tt.req.ConfirmToken == "" {
+			tt.req.ConfirmToken = preview.ConfirmToken
+		}
+		if _, err := e.Update(ctx, tt.req); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q error, got %v", name, tt.want, err)
+		}
+	}
+
+	// The count is checked again when the write runs.
+	if _, err := e.Insert(ctx, connector.InsertRequest{Table: "orders", Rows: []map[string]any{{"customer_id": 1, "status": "delivered", "total": 1.0}}}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	archive.ConfirmToken = preview.ConfirmToken
+	if _, err := e.Update(ctx, archive); err == nil || !strings.Contains(err.Error(), "now matches 6 rows, not the 5 previewed") {
+		t.Fatalf("expected count mismatch, got %v", err)
+	}
+	if preview, err = e.PreviewUpdate(ctx, archive); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	archive.ConfirmToken = preview.ConfirmToken
+	if res, err = e.Update(ctx, archive); err != nil || res.RowsAffected != 6 {
+		t.Fatalf("confirmed update: %v, %+v", err, res)
+	}
+
+	// A token confirms one write, even if the rows still match.
+	rename := connector.UpdateRequest{Table: "customers", Filter: "id <= 3", Set: map[string]any{"city": "Paris"}}
+	if preview, err = e.PreviewUpdate(ctx, rename); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	rename.ConfirmToken = preview.ConfirmToken
+	if res, err = e.Update(ctx, rename); err != nil || res.RowsAffected != 3 {
+		t.Fatalf("confirmed update: %v, %+v", err, res)
+	}
+	if _, err := e.Update(ctx, rename); err == nil || !strings.Contains(err.Error(), "already been used") {
+		t.Errorf("expected the replayed token to be refused, got %v", err)
+	}
+
+	// Transactions cannot be confirmed, so a step at the threshold fails.
+	deleteItems := []connector.WriteStep{{Delete: &connector.DeleteRequest{Table: "order_items", Filter: "order_id <= 3"}}}
+	if _, err := e.Transaction(ctx, connector.TransactionRequest{Steps: deleteItems}); !errors.Is(err, connector.ErrConfirmationRequired) {
+		t.Errorf("expected transaction to need confirmation, got %v", err)
+	}
+	if _, err := e.Transaction(ctx, connector.TransactionRequest{Steps: deleteItems, DryRun: true}); err != nil {
+		t.Errorf("dry run: %v", err)
+	}
+}
+
+func TestEngine_Returning(t *testing.T) {
+	e := newRoleEngine(t,
+		access.Role{Name: "clerk", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"SELECT", "INSERT", "UPDATE", "DELETE"}, DenyColumns: []string{"created_at"}, MaskColumns: []string{"email"}},
+		}},
+		access.Role{Name: "loader", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"INSERT"}},
+		}},
+	)
+	clerk := access.WithIdentity(context.Background(), &access.Identity{User: "c", Role: "clerk"})
+	row := func(email string) map[string]any {
+		return map[string]any{"first_name": "Ann", "last_name": "Lee", "email": email}
+	}
+
+	res, err := e.Insert(clerk, connector.InsertRequest{Table: "customers", Rows: []map[string]any{row("ann@example.com")}, Returning: true})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if res.RowsAffected != 1 || len(res.Returning) != 1 {
+		t.Fatalf("expected the inserted row, got %+v", res)
+	}
+	got := res.Returning[0]
+	if got["id"] == nil || got["country"] != "US" {
+		t.Errorf("expected the generated id and defaults, got %v", got)
+	}
+	if _, ok := got["created_at"]; ok {
+		t.Errorf("denied column returned: %v", got)
+	}
+	if got["email"] == "ann@example.com" {
+		t.Errorf("masked column returned in the clear: %v", got)
+	}
+
+	res, err = e.Delete(clerk, connector.DeleteRequest{Table: "customers", Filter: "last_name = 'Lee'", Returning: true})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if res.RowsAffected != 1 || len(res.Returning) != 1 || res.Returning[0]["first_name"] != "Ann" {
+		t.Errorf("expected the deleted row, got %+v", res)
+	}
+
+	// Callers who may not select from the table only get the row count.
+	loader := access.WithIdentity(context.Background(), &access.Identity{User: "l", Role: "loader"})
+	res, err = e.Insert(loader, connector.InsertRequest{Table: "customers", Rows: []map[string]any{row("ben@example.com")}, Returning: true})
+	if err != nil {
+		t.Fatalf("insert without select: %v", err)
+	}
+	if res.RowsAffected != 1 || res.Returning != nil {
+		t.Errorf("expected the row count only, got %+v", res)
+	}
+}
+
+func TestEngine_InsertBatches(t *testing.T) {
+	e := newRoleEngine(t)
+	e.validator = NewValidator(Limits{MaxRows: 100, AllowWrites: true, InsertBatchSize: 2})
+	ctx := context.Background()
+	row := func(email string) map[string]any {
+		return map[string]any{"first_name": "Ann", "last_name": "Batch", "email": email}
+	}
+	// Row 2 repeats row 1 in the same batch, and row 4 an existing customer.
+	rows := []map[string]any{
+		row("a@example.com"), row("a@example.com"), row("c@example.com"), row("bob@example.com"), row("e@example.com"),
+	}
+	inserted := func() int {
+		t.Helper()
+		rs, err := e.Select(ctx, connector.SelectRequest{Table: "customers", Filter: "last_name = 'Batch'"})
+		if err != nil {
+			t.Fatalf("select: %v", err)
+		}
+		return len(rs.Rows)
+	}
+
+	// By default, a failed row fails the whole insert.
+	_, err := e.Insert(ctx, connector.InsertRequest{Table: "customers", Rows: rows})
+	if err == nil || !strings.Contains(err.Error(), "row 2:") {
+		t.Fatalf("expected row 2 to fail the insert, got %v", err)
+	}
+	if n := inserted(); n != 0 {
+		t.Errorf("expected no rows inserted, got %d", n)
+	}
+
+	res, err := e.Insert(ctx, connector.InsertRequest{Table: "customers", Rows: rows, Returning: true, ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if res.RowsAffected != 3 || len(res.Returning) != 3 {
+		t.Errorf("expected rows 1, 3, and 5 inserted, got %+v", res)
+	}
+	if len(res.RowErrors) != 2 || res.RowErrors[0].Row != 2 || res.RowErrors[1].Row != 4 ||
+		!strings.Contains(res.RowErrors[1].Error, "UNIQUE") {
+		t.Errorf("expected rows 2 and 4 reported, got %+v", res.RowErrors)
+	}
+	if n := inserted(); n != 3 {
+		t.Errorf("expected 3 rows inserted, got %d", n)
+	}
+}
+
+func TestEngine_Upsert(t *testing.T) {
+	e := newRoleEngine(t,
+		access.Role{Name: "clerk", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"SELECT", "INSERT", "UPDATE"}},
+		}},
+		access.Role{Name: "loader", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"INSERT"}},
+		}},
+		access.Role{Name: "scoped", Tables: []access.TablePolicy{
+			{Name: "customers", Verbs: []string{"INSERT", "UPDATE"}, RowFilter: "state = 'CA'"},
+		}},
+	)
+	clerk := access.WithIdentity(context.Background(), &access.Identity{User: "c", Role: "clerk"})
+	row := func(email, last string) map[string]any {
+		return map[string]any{"first_name": "Ann", "last_name": last, "email": email}
+	}
+
+	res, err := e.Upsert(clerk, connector.UpsertRequest{
+		Table:           "customers",
+		Rows:            []map[string]any{row("alice@example.com", "Jones"), {"FIRST_NAME": "Ann", "Last_Name": "Lee", "Email": "ann@example.com"}},
+		ConflictColumns: []string{"EMAIL"},
+		Returning:       true,
+	})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if res.RowsAffected != 2 || len(res.Returning) != 2 {
+		t.Fatalf("expected 2 rows written, got %+v", res)
+	}
+	if res.Returning[0]["id"] != int64(1) || res.Returning[0]["last_name"] != "Jones" {
+		t.Errorf("expected customer 1 to be updated, got %v", res.Returning[0])
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		req     connector.UpsertRequest
+		wantErr string
+	}{
+		{
+			"not a key",
+			clerk,
+			connector.UpsertRequest{Table: "customers", Rows: []map[string]any{row("a@example.com", "Lee")}, ConflictColumns: []string{"last_name"}},
+			"use one of (id), (email)",
+		},
+		{
+			"primary key by default",
+			clerk,
+			connector.UpsertRequest{Table: "customers", Rows: []map[string]any{row("a@example.com", "Lee")}},
+			`row 1 does not set conflict column "id"`,
+		},
+		{
+			"different columns",
+			clerk,
+			connector.UpsertRequest{
+				Table:           "customers",
+				Rows:            []map[string]any{row("a@example.com", "Lee"), {"email": "b@example.com"}},
+				ConflictColumns: []string{"email"},
+			},
+			"row 2 sets different columns",
+		},
+		{
+			"repeated key",
+			clerk,
+			connector.UpsertRequest{
+				Table:           "customers",
+				Rows:            []map[string]any{row("a@example.com", "Lee"), row("a@example.com", "Li")},
+				ConflictColumns: []string{"email"},
+			},
+			"rows 1 and 2 have the same email",
+		},
+		{
+			"insert only",
+			access.WithIdentity(context.Background(), &access.Identity{User: "l", Role: "loader"}),
+			connector.UpsertRequest{Table: "customers", Rows: []map[string]any{row("a@example.com", "Lee")}, ConflictColumns: []string{"email"}},
+			"does not have",
+		},
+		{
+			"row filter",
+			access.WithIdentity(context.Background(), &access.Identity{User: "s", Role: "scoped"}),
+			connector.UpsertRequest{Table: "customers", Rows: []map[string]any{row("a@example.com", "Lee")}, ConflictColumns: []string{"email"}},
+			"cannot upsert",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.Upsert(tt.ctx, tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileRowFilter(t *testing.T) {
+	id := &access.Identity{User: "alice", Role: "analyst", Attributes: map[string]any{"tenant": "t' OR '1'='1"}}
+	f, err := CompileRowFilter("tenant_id = {{ user.tenant }} AND owner = {{user.id}}", id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clause, params := f.Render(testQuoter, PostgresPlaceholder, 1)
+	if want := `"tenant_id" = $1 AND "owner" = $2`; clause != want {
+		t.Errorf("got %q, want %q", clause, want)
+	}
+	if len(params) != 2 || params[0] != "t' OR '1'='1" || params[1] != "alice" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	if _, err := CompileFilter("tenant_id = {{user.tenant}}"); err == nil {
+		t.Error("caller filters must not resolve templates")
+	}
+	if err := ValidateRowFilter("tenant_id = {{org}}"); err == nil {
+		t.Error("expected error for a non-user template")
+	}
+}
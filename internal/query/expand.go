@@ -0,0 +1,312 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+const (
+	// maxExpands caps the relationships a single select may expand.
+	maxExpands = 5
+	// defaultExpandLimit is the per-row limit of one-to-many expansions.
+	defaultExpandLimit = 10
+	// expandBatch caps the key values bound in one related-table select,
+	// staying under Oracle's 1000-item IN lists and SQL Server's 2100
+	// parameters.
+	expandBatch = 500
+)
+
+// expansion is an Expand resolved against a declared foreign key.
+type expansion struct {
+	connector.Expand
+	manyToOne bool
+	localKey  string // column of the queried table
+	remoteKey string // column of the related table
+	orderBy   string // primary key of the related table, for stable results
+	// hideRemote is set when remoteKey was only added to Columns to match
+	// related rows, and is removed from them afterwards.
+	hideRemote bool
+	// maxRows is the most rows one select on the related table returns.
+	maxRows int
+}
+
+// planExpand resolves req.Expand against the foreign keys of td, adding the
+// key columns each expansion needs to req.Columns. It returns the resolved
+// expansions and the columns added only to match rows, which the caller
+// removes from the result.
+func (e *Engine) planExpand(ctx context.Context, req *connector.SelectRequest, td *schema.TableDetail, policy *rolePolicy) ([]expansion, []string, error) {
+	if len(req.Expand) > maxExpands {
+		return nil, nil, &ValidationError{Field: "expand", Message: fmt.Sprintf("at most %d relationships may be expanded", maxExpands)}
+	}
+	names := make(map[string]bool, len(td.Columns)+len(req.Expand))
+	for _, c := range td.Columns {
+		names[strings.ToLower(c.Name)] = true
+	}
+
+	var plan []expansion
+	var added []string
+	for _, x := range req.Expand {
+		if err := ValidateIdentifier(x.Table); err != nil {
+			return nil, nil, &ValidationError{Field: "expand", Message: err.Error()}
+		}
+		if err := e.validator.ValidateColumns(x.Columns); err != nil {
+			return nil, nil, err
+		}
+		related, err := e.authorize(ctx, x.Table, access.VerbSelect)
+		if err != nil {
+			return nil, nil, err
+		}
+		exp, err := e.resolveExpand(ctx, td, x)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := policy.checkColumns(exp.localKey); err != nil {
+			return nil, nil, err
+		}
+
+		if exp.As == "" {
+			exp.As = exp.Table
+		}
+		if !aliasPattern.MatchString(exp.As) {
+			return nil, nil, &ValidationError{Field: "expand", Message: fmt.Sprintf("invalid name %q: must match [a-zA-Z_][a-zA-Z0-9_]*", exp.As)}
+		}
+		if names[strings.ToLower(exp.As)] {
+			return nil, nil, &ValidationError{Field: "expand", Message: fmt.Sprintf("expansion %q clashes with a column or another expansion; set a distinct as", exp.As)}
+		}
+		names[strings.ToLower(exp.As)] = true
+		if exp.Flatten && !exp.manyToOne {
+			return nil, nil, &ValidationError{Field: "expand", Message: fmt.Sprintf("%q is a one-to-many relationship and cannot be flattened", exp.Table)}
+		}
+
+		exp.maxRows = e.clampLimit(related, e.validator.ClampLimit(0))
+		if exp.Limit <= 0 {
+			exp.Limit = defaultExpandLimit
+		}
+		if exp.Limit > exp.maxRows {
+			exp.Limit = exp.maxRows
+		}
+		if len(exp.Columns) > 0 && !containsFold(exp.Columns, exp.remoteKey) {
+			exp.Columns = append(append([]string(nil), exp.Columns...), exp.remoteKey)
+			exp.hideRemote = true
+		}
+		if len(req.Columns) > 0 && !containsFold(req.Columns, exp.localKey) && !containsFold(added, exp.localKey) {
+			req.Columns = append(req.Columns, exp.localKey)
+			added = append(added, exp.localKey)
+		}
+		plan = append(plan, exp)
+	}
+	return plan, added, nil
+}
+
+// resolveExpand finds the declared foreign key relating td to x.Table. Keys
+// of td referencing x.Table are tried first, then keys of x.Table
+// referencing td.
+func (e *Engine) resolveExpand(ctx context.Context, td *schema.TableDetail, x connector.Expand) (expansion, error) {
+	var matches []expansion
+	for _, fk := range td.ForeignKeys {
+		if sameTable(fk.RefTable, x.Table) && (x.Via == "" || strings.EqualFold(fk.Column, x.Via)) {
+			matches = append(matches, expansion{Expand: x, manyToOne: true, localKey: fk.Column, remoteKey: fk.RefColumn})
+		}
+	}
+
+	related, err := e.cache.DescribeTable(ctx, x.Table)
+	if err != nil {
+		return expansion{}, err
+	}
+	if len(matches) == 0 {
+		for _, fk := range related.ForeignKeys {
+			if sameTable(fk.RefTable, td.Name) && (x.Via == "" || strings.EqualFold(fk.Column, x.Via)) {
+				matches = append(matches, expansion{Expand: x, localKey: fk.RefColumn, remoteKey: fk.Column})
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		msg := fmt.Sprintf("no foreign key relates %q and %q", td.Name, x.Table)
+		if x.Via != "" {
+			msg = fmt.Sprintf("no foreign key %q relates %q and %q", x.Via, td.Name, x.Table)
+		}
+		return expansion{}, &ValidationError{Field: "expand", Message: msg}
+	case 1:
+		exp := matches[0]
+		exp.Table = related.Name
+		exp.orderBy = strings.Join(related.PrimaryKey, ", ")
+		return exp, nil
+	}
+	vias := make([]string, len(matches))
+	for i, m := range matches {
+		vias[i] = m.localKey
+		if !m.manyToOne {
+			vias[i] = m.remoteKey
+		}
+	}
+	return expansion{}, &ValidationError{
+		Field:   "expand",
+		Message: fmt.Sprintf("%d foreign keys relate %q and %q; set via to one of %s", len(matches), td.Name, x.Table, strings.Join(vias, ", ")),
+	}
+}
+
+// expand attaches the rows related to rs by x, selecting them through
+// Select so the caller's access policy and masking apply to the related
+// table as well.
+func (e *Engine) expand(ctx context.Context, rs *connector.ResultSet, x expansion) error {
+	// Distinct, non-null key values in row order.
+	seen := make(map[string]bool)
+	var keys []any
+	for _, row := range rs.Rows {
+		v := row[x.localKey]
+		if v == nil || seen[expandKey(v)] {
+			continue
+		}
+		seen[expandKey(v)] = true
+		keys = append(keys, v)
+	}
+
+	related := make(map[string][]map[string]any, len(keys))
+	var columns []string
+	// Batches of many-to-one keys must fit in one select.
+	size := min(expandBatch, x.maxRows)
+	for start := 0; start < len(keys); start += size {
+		batch := keys[start:min(start+size, len(keys))]
+		limit := len(batch)
+		if !x.manyToOne {
+			limit = min(len(batch)*x.Limit, x.maxRows)
+		}
+		rel, err := e.selectRelated(ctx, x, connector.In(x.remoteKey, batch), limit)
+		if err != nil {
+			return err
+		}
+		columns = rel.Columns
+		if rel.Truncated {
+			rs.SetTruncation(rs.CutValues+rel.CutValues, rs.SizeLimited || rel.SizeLimited)
+		}
+		for _, row := range rel.Rows {
+			k := expandKey(row[x.remoteKey])
+			if len(related[k]) < x.Limit {
+				related[k] = append(related[k], row)
+			}
+		}
+
+		// A full batch may have spent its limit on some parents; fetch
+		// the children of the others one parent at a time.
+		if !x.manyToOne && rel.HasMore {
+			for _, v := range batch {
+				k := expandKey(v)
+				if len(related[k]) >= x.Limit {
+					continue
+				}
+				rel, err := e.selectRelated(ctx, x, connector.Eq([]string{x.remoteKey}, []any{v}), x.Limit)
+				if err != nil {
+					return err
+				}
+				related[k] = rel.Rows
+			}
+		}
+	}
+	if columns == nil {
+		columns = x.Columns
+	}
+	if x.hideRemote {
+		columns = removeFold(columns, x.remoteKey)
+		for _, rows := range related {
+			for _, row := range rows {
+				delete(row, x.remoteKey)
+			}
+		}
+	}
+
+	switch {
+	case x.Flatten:
+		for _, col := range columns {
+			rs.Columns = append(rs.Columns, x.As+"."+col)
+		}
+		for _, row := range rs.Rows {
+			var match map[string]any
+			if v := row[x.localKey]; v != nil && len(related[expandKey(v)]) > 0 {
+				match = related[expandKey(v)][0]
+			}
+			for _, col := range columns {
+				row[x.As+"."+col] = match[col]
+			}
+		}
+	case x.manyToOne:
+		rs.Columns = append(rs.Columns, x.As)
+		for _, row := range rs.Rows {
+			var match map[string]any
+			if v := row[x.localKey]; v != nil && len(related[expandKey(v)]) > 0 {
+				match = related[expandKey(v)][0]
+			}
+			row[x.As] = match
+		}
+	default:
+		rs.Columns = append(rs.Columns, x.As)
+		for _, row := range rs.Rows {
+			children := []map[string]any{}
+			if v := row[x.localKey]; v != nil {
+				children = append(children, related[expandKey(v)]...)
+			}
+			row[x.As] = children
+		}
+	}
+	return nil
+}
+
+// selectRelated selects the rows of x's related table matching where.
+func (e *Engine) selectRelated(ctx context.Context, x expansion, where *connector.Filter, limit int) (*connector.ResultSet, error) {
+	rs, err := e.Select(ctx, connector.SelectRequest{
+		Table:   x.Table,
+		Columns: x.Columns,
+		Where:   where,
+		OrderBy: x.orderBy,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("expand %s: %w", x.As, err)
+	}
+	return rs, nil
+}
+
+// expandKey normalizes a key value for matching rows across tables, whose
+// drivers may scan the same value into different Go types.
+func expandKey(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// sameTable reports whether two table names refer to the same table,
+// ignoring the schema when either name is unqualified.
+func sameTable(a, b string) bool {
+	if strings.Contains(a, ".") && strings.Contains(b, ".") {
+		return strings.EqualFold(a, b)
+	}
+	return strings.EqualFold(a[strings.LastIndex(a, ".")+1:], b[strings.LastIndex(b, ".")+1:])
+}
+
+// containsFold reports whether list contains s, case-insensitively.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFold returns list without s, compared case-insensitively.
+func removeFold(list []string, s string) []string {
+	var out []string
+	for _, item := range list {
+		if !strings.EqualFold(item, s) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
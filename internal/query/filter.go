@@ -1,11 +1,14 @@
 package query
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/conduitdb/conduit/internal/connector"
 )
 
 // PlaceholderFunc generates a parameter placeholder for the given 1-based
@@ -42,9 +45,40 @@ type ParsedFilter struct {
 // JSON filters use the format: {"column": {"op": value}} or {"column": value}
 // for equality.
 func ParseFilter(input string, quoter func(string) string, placeholder PlaceholderFunc) (*ParsedFilter, error) {
+	f, err := CompileFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	clause, params := f.Render(quoter, placeholder, 1)
+	return &ParsedFilter{WhereClause: clause, Params: params}, nil
+}
+
+// CompileFilter parses a filter expression (string-based or JSON) into a
+// dialect-neutral connector.Filter. Every literal in the input becomes a bound
+// parameter and every column is validated as an identifier, so the result can
+// be rendered by any connector without splicing caller text into SQL. An empty
+// input yields an empty filter.
+func CompileFilter(input string) (*connector.Filter, error) {
+	return compileFilter(input, nil)
+}
+
+// TemplateResolver returns the value bound for a {{name}} template in a
+// filter expression.
+type TemplateResolver func(name string) (any, error)
+
+// compileFilter is CompileFilter with template support. Templates are only
+// accepted when resolve is non-nil; each resolves to a bound parameter.
+func compileFilter(input string, resolve TemplateResolver) (*connector.Filter, error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
-		return &ParsedFilter{}, nil
+		return &connector.Filter{}, nil
+	}
+
+	// Detect JSON object filters. Their keys must be identifiers and their
+	// values are bound as parameters, so the text checks below, which only
+	// skip single-quoted strings, would reject harmless values.
+	if input[0] == '{' && !strings.HasPrefix(input, "{{") {
+		return parseJSONFilter(input)
 	}
 
 	// Run injection check first as defense-in-depth.
@@ -52,36 +86,54 @@ func ParseFilter(input string, quoter func(string) string, placeholder Placehold
 		return nil, err
 	}
 
-	// Detect JSON object filters.
-	if len(input) > 0 && input[0] == '{' {
-		return parseJSONFilter(input, quoter, placeholder)
-	}
-
 	// Parse string-based filter expression.
-	return parseStringFilter(input, quoter, placeholder)
+	return parseStringFilter(input, resolve)
 }
 
+// maxJSONFilterDepth bounds the nesting of $and and $or in JSON filters.
+const maxJSONFilterDepth = 8
+
 // parseJSONFilter handles JSON object-based filters.
-// Format: {"column": value} for equality, {"column": {"$gt": value}} for operators.
-func parseJSONFilter(input string, quoter func(string) string, placeholder PlaceholderFunc) (*ParsedFilter, error) {
+// Format: {"column": value} for equality, {"column": {"$gt": value}} for
+// operators, and {"$or": [{...}, {...}]} or {"$and": [...]} to combine
+// filter objects. The conditions of an object's keys are ANDed.
+func parseJSONFilter(input string) (*connector.Filter, error) {
 	var obj map[string]json.RawMessage
 	if err := json.Unmarshal([]byte(input), &obj); err != nil {
 		return nil, fmt.Errorf("invalid JSON filter: %w", err)
 	}
+	return compileJSONObject(obj, 0)
+}
 
-	var clauses []string
-	var params []any
-	paramIdx := 1
+// compileJSONObject compiles one JSON filter object at the given $and/$or
+// nesting depth.
+func compileJSONObject(obj map[string]json.RawMessage, depth int) (*connector.Filter, error) {
+	f := &connector.Filter{}
+	and := func() {
+		if !f.IsEmpty() {
+			f.SQL(" AND ")
+		}
+	}
 
 	// Process keys in a deterministic order for testability.
 	keys := sortedKeys(obj)
 
 	for _, col := range keys {
 		raw := obj[col]
+		if col == "$and" || col == "$or" {
+			group, err := compileJSONGroup(col, raw, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			and()
+			f.SQL("(")
+			f.Parts = append(f.Parts, group.Parts...)
+			f.SQL(")")
+			continue
+		}
 		if err := ValidateIdentifier(col); err != nil {
 			return nil, fmt.Errorf("invalid column in JSON filter: %w", err)
 		}
-		quotedCol := quoter(col)
 
 		// Try to unmarshal as an operator object.
 		var opObj map[string]json.RawMessage
@@ -96,40 +148,160 @@ func parseJSONFilter(input string, quoter func(string) string, placeholder Place
 			}
 			if isOpObj {
 				for _, opKey := range sortedKeys(opObj) {
-					op, err := jsonOperator(opKey)
+					val, err := decodeJSONValue(opObj[opKey])
 					if err != nil {
-						return nil, err
-					}
-					var val any
-					if err := json.Unmarshal(opObj[opKey], &val); err != nil {
 						return nil, fmt.Errorf("invalid value for %s.%s: %w", col, opKey, err)
 					}
-					clauses = append(clauses, fmt.Sprintf("%s %s %s", quotedCol, op, placeholder(paramIdx)))
-					params = append(params, val)
-					paramIdx++
+					and()
+					if err := compileJSONCondition(f, col, opKey, val); err != nil {
+						return nil, err
+					}
 				}
 				continue
 			}
 		}
 
 		// Simple equality: {"column": value}
-		var val any
-		if err := json.Unmarshal(raw, &val); err != nil {
+		val, err := decodeJSONValue(raw)
+		if err != nil {
 			return nil, fmt.Errorf("invalid value for column %q: %w", col, err)
 		}
+		if _, ok := val.([]any); ok {
+			return nil, fmt.Errorf("column %q: use {\"$in\": [...]} to match a list of values", col)
+		}
+		and()
 		if val == nil {
-			clauses = append(clauses, fmt.Sprintf("%s IS NULL", quotedCol))
+			f.Column(col).SQL(" IS NULL")
 		} else {
-			clauses = append(clauses, fmt.Sprintf("%s = %s", quotedCol, placeholder(paramIdx)))
-			params = append(params, val)
-			paramIdx++
+			f.Column(col).SQL(" = ").Param(val)
+		}
+	}
+
+	return f, nil
+}
+
+// compileJSONGroup compiles the array of filter objects under a $and or $or
+// key, joining their conditions with AND or OR.
+func compileJSONGroup(key string, raw json.RawMessage, depth int) (*connector.Filter, error) {
+	if depth > maxJSONFilterDepth {
+		return nil, fmt.Errorf("JSON filter nests $and/$or deeper than %d levels", maxJSONFilterDepth)
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil || len(items) == 0 {
+		return nil, fmt.Errorf("%s requires a non-empty array of filter objects", key)
+	}
+	join := " AND "
+	if key == "$or" {
+		join = " OR "
+	}
+
+	f := &connector.Filter{}
+	for i, item := range items {
+		sub, err := compileJSONObject(item, depth)
+		if err != nil {
+			return nil, err
+		}
+		if sub.IsEmpty() {
+			return nil, fmt.Errorf("%s[%d] is an empty filter object", key, i)
+		}
+		if i > 0 {
+			f.SQL(join)
+		}
+		f.SQL("(")
+		f.Parts = append(f.Parts, sub.Parts...)
+		f.SQL(")")
+	}
+	return f, nil
+}
+
+// compileJSONCondition appends the condition {col: {opKey: val}} to f.
+func compileJSONCondition(f *connector.Filter, col, opKey string, val any) error {
+	switch opKey {
+	case "$in", "$nin":
+		values, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("%s.%s requires an array of values", col, opKey)
+		}
+		if opKey == "$in" {
+			f.Parts = append(f.Parts, connector.In(col, values).Parts...)
+			return nil
+		}
+		if len(values) == 0 {
+			f.SQL("1 = 1")
+			return nil
+		}
+		f.Column(col).SQL(" NOT IN (")
+		for i, v := range values {
+			if i > 0 {
+				f.SQL(", ")
+			}
+			f.Param(v)
+		}
+		f.SQL(")")
+		return nil
+	case "$like":
+		pattern, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("%s.$like requires a string pattern", col)
+		}
+		f.Column(col).SQL(" LIKE ").Param(pattern)
+		return nil
+	}
+
+	op, err := jsonOperator(opKey)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		switch opKey {
+		case "$eq":
+			f.Column(col).SQL(" IS NULL")
+		case "$ne":
+			f.Column(col).SQL(" IS NOT NULL")
+		default:
+			return fmt.Errorf("%s.%s cannot compare with null", col, opKey)
 		}
+		return nil
+	}
+	if _, ok := val.([]any); ok {
+		return fmt.Errorf("%s.%s requires a single value; use $in for a list", col, opKey)
+	}
+	f.Column(col).SQL(" " + op + " ").Param(val)
+	return nil
+}
+
+// decodeJSONValue decodes a JSON filter value. Integral numbers decode to
+// int64 rather than float64, so they bind exactly against integer columns.
+func decodeJSONValue(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
 	}
+	return filterValue(val)
+}
 
-	return &ParsedFilter{
-		WhereClause: strings.Join(clauses, " AND "),
-		Params:      params,
-	}, nil
+// filterValue replaces the json.Numbers in v with int64 or float64, and
+// rejects objects, which no condition compares with.
+func filterValue(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case []any:
+		for i := range v {
+			var err error
+			if v[i], err = filterValue(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		return nil, fmt.Errorf("objects are not valid filter values")
+	}
+	return v, nil
 }
 
 // jsonOperator maps JSON filter operator keys to SQL operators.
@@ -190,6 +362,7 @@ const (
 	tokIN
 	tokLIKE
 	tokBETWEEN
+	tokTemplate
 )
 
 // token holds a single lexed token.
@@ -234,6 +407,12 @@ func (l *lexer) run() error {
 		case ch == ',':
 			l.tokens = append(l.tokens, token{tokComma, ",", l.pos})
 			l.pos++
+		case ch == '{' && l.pos+1 < len(l.input) && l.input[l.pos+1] == '{':
+			tok, err := l.readTemplate()
+			if err != nil {
+				return err
+			}
+			l.tokens = append(l.tokens, tok)
 		case ch == '\'':
 			tok, err := l.readString()
 			if err != nil {
@@ -304,6 +483,18 @@ func (l *lexer) readString() (token, error) {
 	return token{}, fmt.Errorf("unterminated string literal at position %d", start)
 }
 
+// readTemplate reads a {{name}} template reference.
+func (l *lexer) readTemplate() (token, error) {
+	start := l.pos
+	end := strings.Index(l.input[l.pos:], "}}")
+	if end < 0 {
+		return token{}, fmt.Errorf("unterminated template at position %d", start)
+	}
+	name := strings.TrimSpace(l.input[l.pos+2 : l.pos+end])
+	l.pos += end + 2
+	return token{tokTemplate, name, start}, nil
+}
+
 func (l *lexer) readNumber() token {
 	start := l.pos
 	if l.input[l.pos] == '-' {
@@ -364,32 +555,28 @@ func isIdentChar(ch byte) bool {
 	return isIdentStart(ch) || (ch >= '0' && ch <= '9')
 }
 
-// parser builds a ParsedFilter from tokens.
+// parser builds a connector.Filter from tokens.
 type parser struct {
-	tokens      []token
-	pos         int
-	quoter      func(string) string
-	placeholder PlaceholderFunc
-	params      []any
-	paramIdx    int
+	tokens  []token
+	pos     int
+	out     *connector.Filter
+	resolve TemplateResolver
 }
 
 // parseStringFilter parses a string-based filter expression.
-func parseStringFilter(input string, quoter func(string) string, placeholder PlaceholderFunc) (*ParsedFilter, error) {
+func parseStringFilter(input string, resolve TemplateResolver) (*connector.Filter, error) {
 	tokens, err := lex(input)
 	if err != nil {
 		return nil, fmt.Errorf("filter lexer error: %w", err)
 	}
 
 	p := &parser{
-		tokens:      tokens,
-		quoter:      quoter,
-		placeholder: placeholder,
-		paramIdx:    1,
+		tokens:  tokens,
+		out:     &connector.Filter{},
+		resolve: resolve,
 	}
 
-	clause, err := p.parseExpression()
-	if err != nil {
+	if err := p.parseExpression(); err != nil {
 		return nil, fmt.Errorf("filter parse error: %w", err)
 	}
 
@@ -398,10 +585,7 @@ func parseStringFilter(input string, quoter func(string) string, placeholder Pla
 		return nil, fmt.Errorf("unexpected token %q at position %d", p.current().val, p.current().pos)
 	}
 
-	return &ParsedFilter{
-		WhereClause: clause,
-		Params:      p.params,
-	}, nil
+	return p.out, nil
 }
 
 func (p *parser) current() token {
@@ -426,106 +610,113 @@ func (p *parser) expect(typ tokenType) (token, error) {
 	return tok, nil
 }
 
-// addParam adds a value to the parameter list and returns the placeholder string.
-func (p *parser) addParam(val any) string {
-	p.params = append(p.params, val)
-	ph := p.placeholder(p.paramIdx)
-	p.paramIdx++
-	return ph
-}
-
-// parseExpression handles: comparison { ("AND" | "OR") comparison }
-func (p *parser) parseExpression() (string, error) {
-	left, err := p.parseComparison()
-	if err != nil {
-		return "", err
+// parseExpression handles: term { ("AND" | "OR") term }
+func (p *parser) parseExpression() error {
+	if err := p.parseTerm(); err != nil {
+		return err
 	}
 
 	for p.current().typ == tokAND || p.current().typ == tokOR {
 		op := strings.ToUpper(p.advance().val)
-		right, err := p.parseComparison()
-		if err != nil {
-			return "", err
+		p.out.SQL(" " + op + " ")
+		if err := p.parseTerm(); err != nil {
+			return err
 		}
-		left = left + " " + op + " " + right
 	}
 
-	return left, nil
+	return nil
+}
+
+// parseTerm handles: "(" expression ")" | comparison
+func (p *parser) parseTerm() error {
+	if p.current().typ != tokLParen {
+		return p.parseComparison()
+	}
+	p.advance()
+	p.out.SQL("(")
+	if err := p.parseExpression(); err != nil {
+		return err
+	}
+	if _, err := p.expect(tokRParen); err != nil {
+		return fmt.Errorf("expected ')' at position %d", p.current().pos)
+	}
+	p.out.SQL(")")
+	return nil
 }
 
 // parseComparison handles all comparison forms.
-func (p *parser) parseComparison() (string, error) {
+func (p *parser) parseComparison() error {
 	// Expect a column identifier.
 	colTok := p.current()
 	if colTok.typ != tokIdentifier {
-		return "", fmt.Errorf("expected column name, got %q at position %d", colTok.val, colTok.pos)
+		return fmt.Errorf("expected column name, got %q at position %d", colTok.val, colTok.pos)
 	}
 	p.advance()
 
 	colName := colTok.val
 	if err := ValidateIdentifier(colName); err != nil {
-		return "", fmt.Errorf("invalid column name: %w", err)
+		return fmt.Errorf("invalid column name: %w", err)
 	}
-	quotedCol := p.quoter(colName)
 
 	cur := p.current()
 
 	switch cur.typ {
 	case tokIS:
-		return p.parseIsNull(quotedCol)
+		return p.parseIsNull(colName)
 	case tokIN:
-		return p.parseIn(quotedCol, false)
+		return p.parseIn(colName, false)
 	case tokNOT:
 		// NOT IN
 		p.advance()
 		if p.current().typ != tokIN {
-			return "", fmt.Errorf("expected IN after NOT at position %d", p.current().pos)
+			return fmt.Errorf("expected IN after NOT at position %d", p.current().pos)
 		}
-		return p.parseIn(quotedCol, true)
+		return p.parseIn(colName, true)
 	case tokLIKE:
-		return p.parseLike(quotedCol)
+		return p.parseLike(colName)
 	case tokBETWEEN:
-		return p.parseBetween(quotedCol)
+		return p.parseBetween(colName)
 	case tokOperator:
-		return p.parseOperatorComparison(quotedCol)
+		return p.parseOperatorComparison(colName)
 	default:
-		return "", fmt.Errorf("expected operator after column %q, got %q at position %d", colName, cur.val, cur.pos)
+		return fmt.Errorf("expected operator after column %q, got %q at position %d", colName, cur.val, cur.pos)
 	}
 }
 
 // parseIsNull handles: IS NULL | IS NOT NULL
-func (p *parser) parseIsNull(quotedCol string) (string, error) {
+func (p *parser) parseIsNull(col string) error {
 	p.advance() // consume IS
 
 	if p.current().typ == tokNOT {
 		p.advance() // consume NOT
 		if _, err := p.expect(tokNull); err != nil {
-			return "", fmt.Errorf("expected NULL after IS NOT")
+			return fmt.Errorf("expected NULL after IS NOT")
 		}
-		return quotedCol + " IS NOT NULL", nil
+		p.out.Column(col).SQL(" IS NOT NULL")
+		return nil
 	}
 
 	if _, err := p.expect(tokNull); err != nil {
-		return "", fmt.Errorf("expected NULL after IS")
+		return fmt.Errorf("expected NULL after IS")
 	}
-	return quotedCol + " IS NULL", nil
+	p.out.Column(col).SQL(" IS NULL")
+	return nil
 }
 
 // parseIn handles: IN (value, value, ...) and NOT IN (...)
-func (p *parser) parseIn(quotedCol string, negated bool) (string, error) {
+func (p *parser) parseIn(col string, negated bool) error {
 	p.advance() // consume IN
 	if _, err := p.expect(tokLParen); err != nil {
-		return "", fmt.Errorf("expected '(' after IN")
+		return fmt.Errorf("expected '(' after IN")
 	}
 
-	var placeholders []string
+	var values []any
 	for {
 		val, err := p.parseValue()
 		if err != nil {
-			return "", fmt.Errorf("in IN list: %w", err)
+			return fmt.Errorf("in IN list: %w", err)
 		}
-		ph := p.addParam(val)
-		placeholders = append(placeholders, ph)
+		values = append(values, val)
 
 		if p.current().typ == tokComma {
 			p.advance()
@@ -535,55 +726,62 @@ func (p *parser) parseIn(quotedCol string, negated bool) (string, error) {
 	}
 
 	if _, err := p.expect(tokRParen); err != nil {
-		return "", fmt.Errorf("expected ')' to close IN list")
+		return fmt.Errorf("expected ')' to close IN list")
 	}
 
-	op := "IN"
+	op := " IN ("
 	if negated {
-		op = "NOT IN"
+		op = " NOT IN ("
+	}
+	p.out.Column(col).SQL(op)
+	for i, val := range values {
+		if i > 0 {
+			p.out.SQL(", ")
+		}
+		p.out.Param(val)
 	}
-	return fmt.Sprintf("%s %s (%s)", quotedCol, op, strings.Join(placeholders, ", ")), nil
+	p.out.SQL(")")
+	return nil
 }
 
 // parseLike handles: LIKE string_literal
-func (p *parser) parseLike(quotedCol string) (string, error) {
+func (p *parser) parseLike(col string) error {
 	p.advance() // consume LIKE
 
 	strTok := p.current()
 	if strTok.typ != tokString {
-		return "", fmt.Errorf("LIKE requires a string pattern, got %q at position %d", strTok.val, strTok.pos)
+		return fmt.Errorf("LIKE requires a string pattern, got %q at position %d", strTok.val, strTok.pos)
 	}
 	p.advance()
 
-	ph := p.addParam(strTok.val)
-	return fmt.Sprintf("%s LIKE %s", quotedCol, ph), nil
+	p.out.Column(col).SQL(" LIKE ").Param(strTok.val)
+	return nil
 }
 
 // parseBetween handles: BETWEEN value AND value
-func (p *parser) parseBetween(quotedCol string) (string, error) {
+func (p *parser) parseBetween(col string) error {
 	p.advance() // consume BETWEEN
 
 	low, err := p.parseValue()
 	if err != nil {
-		return "", fmt.Errorf("in BETWEEN low: %w", err)
+		return fmt.Errorf("in BETWEEN low: %w", err)
 	}
 
 	if _, err := p.expect(tokAND); err != nil {
-		return "", fmt.Errorf("expected AND in BETWEEN expression")
+		return fmt.Errorf("expected AND in BETWEEN expression")
 	}
 
 	high, err := p.parseValue()
 	if err != nil {
-		return "", fmt.Errorf("in BETWEEN high: %w", err)
+		return fmt.Errorf("in BETWEEN high: %w", err)
 	}
 
-	phLow := p.addParam(low)
-	phHigh := p.addParam(high)
-	return fmt.Sprintf("%s BETWEEN %s AND %s", quotedCol, phLow, phHigh), nil
+	p.out.Column(col).SQL(" BETWEEN ").Param(low).SQL(" AND ").Param(high)
+	return nil
 }
 
 // parseOperatorComparison handles: column op value
-func (p *parser) parseOperatorComparison(quotedCol string) (string, error) {
+func (p *parser) parseOperatorComparison(col string) error {
 	opTok := p.advance() // consume operator
 	op := opTok.val
 
@@ -591,20 +789,22 @@ func (p *parser) parseOperatorComparison(quotedCol string) (string, error) {
 	if p.current().typ == tokNull {
 		p.advance()
 		if op == "=" {
-			return quotedCol + " IS NULL", nil
+			p.out.Column(col).SQL(" IS NULL")
+			return nil
 		} else if op == "!=" || op == "<>" {
-			return quotedCol + " IS NOT NULL", nil
+			p.out.Column(col).SQL(" IS NOT NULL")
+			return nil
 		}
-		return "", fmt.Errorf("cannot use operator %q with NULL", op)
+		return fmt.Errorf("cannot use operator %q with NULL", op)
 	}
 
 	val, err := p.parseValue()
 	if err != nil {
-		return "", fmt.Errorf("after %q operator: %w", op, err)
+		return fmt.Errorf("after %q operator: %w", op, err)
 	}
 
-	ph := p.addParam(val)
-	return fmt.Sprintf("%s %s %s", quotedCol, op, ph), nil
+	p.out.Column(col).SQL(" " + op + " ").Param(val)
+	return nil
 }
 
 // parseValue parses a literal value (string, number, boolean, or NULL).
@@ -623,6 +823,16 @@ func (p *parser) parseValue() (any, error) {
 	case tokNull:
 		p.advance()
 		return nil, nil
+	case tokTemplate:
+		if p.resolve == nil {
+			return nil, fmt.Errorf("template {{%s}} is not allowed here", tok.val)
+		}
+		p.advance()
+		val, err := p.resolve(tok.val)
+		if err != nil {
+			return nil, err
+		}
+		return val, nil
 	default:
 		return nil, fmt.Errorf("expected value, got %q at position %d", tok.val, tok.pos)
 	}
@@ -344,6 +344,76 @@ func TestParseFilter_JSONMultipleKeys(t *testing.T) {
 	}
 }
 
+func TestParseFilter_JSONOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantClause string
+		wantParams []any
+	}{
+		{"in", `{"id": {"$in": [1, 2]}}`, `"id" IN ($1, $2)`, []any{int64(1), int64(2)}},
+		{"in empty", `{"id": {"$in": []}}`, `1 = 0`, nil},
+		{"nin", `{"status": {"$nin": ["a", "b"]}}`, `"status" NOT IN ($1, $2)`, []any{"a", "b"}},
+		{"like", `{"name": {"$like": "A%"}}`, `"name" LIKE $1`, []any{"A%"}},
+		{"ne null", `{"email": {"$ne": null}}`, `"email" IS NOT NULL`, nil},
+		{"keywords in value", `{"note": "sent from home; DROP TABLE x --"}`, `"note" = $1`, []any{"sent from home; DROP TABLE x --"}},
+		{"semicolon in pattern", `{"name": {"$like": "%; x"}}`, `"name" LIKE $1`, []any{"%; x"}},
+		{"range", `{"total": {"$gte": 10, "$lt": 99.5}}`, `"total" >= $1 AND "total" < $2`, []any{int64(10), 99.5}},
+		{
+			"or",
+			`{"$or": [{"status": "new"}, {"total": {"$gt": 100}}], "customer_id": 3}`,
+			`(("status" = $1) OR ("total" > $2)) AND "customer_id" = $3`,
+			[]any{"new", int64(100), int64(3)},
+		},
+		{
+			"nested",
+			`{"$and": [{"a": 1}, {"$or": [{"b": 2}, {"c": 3}]}]}`,
+			`(("a" = $1) AND ((("b" = $2) OR ("c" = $3))))`,
+			[]any{int64(1), int64(2), int64(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseFilter(tt.input, testQuoter, PostgresPlaceholder)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.WhereClause != tt.wantClause {
+				t.Errorf("where clause = %q, want %q", result.WhereClause, tt.wantClause)
+			}
+			if len(result.Params) != len(tt.wantParams) || fmt.Sprintf("%#v", result.Params) != fmt.Sprintf("%#v", tt.wantParams) && len(tt.wantParams) > 0 {
+				t.Errorf("params = %#v, want %#v", result.Params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestParseFilter_JSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"id": {"$in": 1}}`, "requires an array"},
+		{`{"id": {"$gt": [1]}}`, "use $in"},
+		{`{"id": [1, 2]}`, "$in"},
+		{`{"name": {"$like": 5}}`, "string pattern"},
+		{`{"id": {"$gt": null}}`, "cannot compare with null"},
+		{`{"$or": []}`, "non-empty array"},
+		{`{"$or": [{}]}`, "empty filter object"},
+		{`{"id": {"$regex": "x"}}`, "unsupported JSON filter operator"},
+		{`{"id": {"$eq": {"a": 1}}}`, "objects are not valid"},
+		{`{"id; DROP TABLE x": 1}`, "invalid column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseFilter(tt.input, testQuoter, PostgresPlaceholder)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
 // --- SQL injection rejection tests ---
 
 func TestParseFilter_RejectSemicolon(t *testing.T) {
@@ -631,6 +701,49 @@ func TestParseFilter_ComplexExpression(t *testing.T) {
 	}
 }
 
+func TestParseFilter_Grouping(t *testing.T) {
+	result, err := ParseFilter("(status = 'active' OR status = 'trial') AND age >= 18", testQuoter, PostgresPlaceholder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `("status" = $1 OR "status" = $2) AND "age" >= $3`
+	if result.WhereClause != expected {
+		t.Errorf("unexpected where clause:\n  got:  %q\n  want: %q", result.WhereClause, expected)
+	}
+	if len(result.Params) != 3 || result.Params[2] != int64(18) {
+		t.Errorf("unexpected params: %v", result.Params)
+	}
+}
+
+func TestParseFilter_UnbalancedParens(t *testing.T) {
+	for _, input := range []string{"(age > 1", "age > 1)", "()"} {
+		if _, err := ParseFilter(input, testQuoter, PostgresPlaceholder); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestCompileFilter_RendersPerDialect(t *testing.T) {
+	f, err := CompileFilter("name = 'x'' OR ''1''=''1' AND id IN (1, 2)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Rendering at a later index lets the filter follow already-bound params.
+	clause, params := f.Render(testQuoter, MSSQLPlaceholder, 3)
+	if want := `"name" = @p3 AND "id" IN (@p4, @p5)`; clause != want {
+		t.Errorf("got %q, want %q", clause, want)
+	}
+	if len(params) != 3 || params[0] != "x' OR '1'='1" {
+		t.Errorf("unexpected params: %v", params)
+	}
+
+	clause, _ = f.Render(testQuoter, QuestionPlaceholder, 1)
+	if want := `"name" = ? AND "id" IN (?, ?)`; clause != want {
+		t.Errorf("got %q, want %q", clause, want)
+	}
+}
+
 // --- Sanitizer tests ---
 
 func TestSanitizeFilterInput_AllowsCleanInput(t *testing.T) {
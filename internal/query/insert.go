@@ -0,0 +1,102 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conduitdb/conduit/internal/connector"
+)
+
+// insertRows inserts the rows of req in the transaction carried by ctx, in
+// batches of as many rows as a statement can take; see insertBatchSize.
+// Each batch runs under a savepoint, and if it fails, its rows are inserted
+// one at a time to find those that fail. Unless req.ContinueOnError, the
+// first failed row ends the insert with an error naming it. Otherwise, the
+// failed rows are reported in RowErrors and the others inserted.
+func (e *Engine) insertRows(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
+	res := &connector.MutationResult{}
+	rowFailed := func(row int, err error) error {
+		if !req.ContinueOnError {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		res.RowErrors = append(res.RowErrors, connector.RowError{Row: row, Error: err.Error()})
+		return nil
+	}
+
+	size := e.insertBatchSize(req.Rows)
+	for start := 0; start < len(req.Rows); start += size {
+		batch := req
+		batch.Rows = req.Rows[start:min(start+size, len(req.Rows))]
+		r, err := e.insertSavepoint(ctx, batch)
+		switch {
+		case err == nil:
+			mergeInserted(res, r)
+			continue
+		case ctx.Err() != nil:
+			return nil, err
+		case len(batch.Rows) == 1:
+			if err := rowFailed(start+1, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for i := range batch.Rows {
+			single := batch
+			single.Rows = batch.Rows[i : i+1]
+			r, err := e.insertSavepoint(ctx, single)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				if err := rowFailed(start+i+1, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			mergeInserted(res, r)
+		}
+	}
+	return res, nil
+}
+
+// insertBatchSize returns the rows to insert per statement: as many as the
+// connector's dialect allows for the columns rows set, up to the configured
+// batch size.
+func (e *Engine) insertBatchSize(rows []map[string]any) int {
+	cols := make(map[string]bool)
+	for _, row := range rows {
+		for col := range row {
+			cols[col] = true
+		}
+	}
+	size := e.connector.InsertBatchSize(len(cols))
+	if limit := e.validator.InsertBatchSize(); limit > 0 && limit < size {
+		size = limit
+	}
+	return size
+}
+
+// insertSavepoint runs req under a savepoint, so that if it fails, the
+// transaction carried by ctx goes on as before it.
+func (e *Engine) insertSavepoint(ctx context.Context, req connector.InsertRequest) (*connector.MutationResult, error) {
+	var res *connector.MutationResult
+	err := e.connector.Savepoint(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = e.connector.Insert(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// mergeInserted adds the rows inserted by one batch to res. Once a batch
+// leaves rows out of Returning at the result size limit, the rows of later
+// batches are only counted.
+func mergeInserted(res, batch *connector.MutationResult) {
+	res.RowsAffected += batch.RowsAffected
+	if res.ReturningTruncated {
+		return
+	}
+	res.Returning = append(res.Returning, batch.Returning...)
+	res.ReturningTruncated = batch.ReturningTruncated
+}
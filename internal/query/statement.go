@@ -0,0 +1,887 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statement is a caller-written SQL statement, tokenized in the dialect of
+// the database it runs against. Raw SQL is checked with ParseStatement and,
+// for read-only tools, CheckReadOnly before it reaches the connector.
+type Statement struct {
+	// Verb is the statement's leading keyword, upper-cased, e.g. SELECT,
+	// WITH, or INSERT.
+	Verb string
+
+	// Tables lists the tables the statement references, unquoted and in the
+	// order first seen. Schema-qualified names are joined with ".". Names
+	// defined by the statement's own CTEs are not included.
+	Tables []string
+
+	// Writes lists the tables in Tables that the statement modifies.
+	Writes []TableWrite
+
+	// Unresolved lists the FROM, JOIN, and APPLY targets that are not table
+	// names, such as table functions, which may read tables not listed in
+	// Tables.
+	Unresolved []string
+
+	// QueryCalls lists calls to functions that run SQL passed as text, read
+	// tables or files by name, or query other servers, such as PostgreSQL's
+	// query_to_xml or SQL Server's OPENQUERY. They may read tables not
+	// listed in Tables.
+	QueryCalls []string
+
+	tokens []sqlToken
+}
+
+// ParseStatement tokenizes sql using the comment and quoting rules of driver
+// (a connector driver name such as "postgres") and checks that it holds
+// exactly one statement. A trailing semicolon is allowed.
+func ParseStatement(sql, driver string) (*Statement, error) {
+	tokens, err := lexSQL(sql, dialectFor(driver))
+	if err != nil {
+		return nil, err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].is(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	for _, t := range tokens {
+		if t.is(";") {
+			return nil, fmt.Errorf("multiple statements are not allowed")
+		}
+	}
+
+	s := &Statement{tokens: tokens}
+	for _, t := range tokens {
+		if t.is("(") {
+			continue
+		}
+		if t.kind == sqlWord {
+			s.Verb = strings.ToUpper(t.text)
+		}
+		break
+	}
+	if s.Verb == "" {
+		return nil, fmt.Errorf("empty or unrecognized SQL statement")
+	}
+	s.resolveTables()
+	s.findQueryCalls(queryFunctions[driver])
+	return s, nil
+}
+
+// queryFunctions are, by driver, the functions (or, for Oracle, packages)
+// that run SQL given as text, read tables or files by name, or reach other
+// servers. Names are lower-case.
+var queryFunctions = map[string]map[string]bool{
+	"postgres": {
+		"query_to_xml": true, "query_to_xmlschema": true, "query_to_xml_and_xmlschema": true,
+		"query_to_json": true, "cursor_to_xml": true, "cursor_to_xmlschema": true,
+		"table_to_xml": true, "table_to_xmlschema": true, "table_to_xml_and_xmlschema": true,
+		"schema_to_xml": true, "schema_to_xmlschema": true, "schema_to_xml_and_xmlschema": true,
+		"database_to_xml": true, "database_to_xmlschema": true, "database_to_xml_and_xmlschema": true,
+		"ts_stat": true, "crosstab": true, "connectby": true, "set_config": true,
+		"dblink": true, "dblink_exec": true, "dblink_open": true, "dblink_fetch": true,
+		"dblink_send_query": true, "dblink_get_result": true, "dblink_connect": true,
+		"dblink_connect_u": true, "lo_import": true, "lo_export": true,
+		"pg_read_file": true, "pg_read_binary_file": true, "pg_ls_dir": true,
+	},
+	"mysql": {
+		"load_file": true,
+	},
+	"sqlite": {
+		"load_extension": true, "readfile": true, "writefile": true,
+	},
+	"mssql": {
+		"openquery": true, "openrowset": true, "opendatasource": true,
+	},
+	"oracle": {
+		"dbms_xmlgen": true, "dbms_xmlquery": true, "dbms_xmlstore": true,
+		"dbms_sql": true, "utl_file": true, "utl_http": true,
+	},
+	"snowflake": {
+		"result_scan": true,
+	},
+}
+
+// findQueryCalls sets QueryCalls to the function calls whose name, or any
+// qualifier of it, is in funcs.
+func (s *Statement) findQueryCalls(funcs map[string]bool) {
+	for i, t := range s.tokens {
+		if i+1 >= len(s.tokens) || !s.tokens[i+1].is("(") || (t.kind != sqlWord && t.kind != sqlQuotedIdent) {
+			continue
+		}
+		start, found := i, false
+		for {
+			if funcs[strings.ToLower(s.tokens[start].text)] {
+				found = true
+			}
+			if start < 2 || !s.tokens[start-1].is(".") {
+				break
+			}
+			start -= 2
+		}
+		if !found {
+			continue
+		}
+		parts := make([]string, 0, (i-start)/2+1)
+		for j := start; j <= i; j += 2 {
+			parts = append(parts, s.tokens[j].text)
+		}
+		s.QueryCalls = append(s.QueryCalls, strings.Join(parts, "."))
+	}
+}
+
+// TableWrite is a table a statement modifies.
+type TableWrite struct {
+	Table string
+	// Verb is INSERT, UPDATE, DELETE, MERGE, or REPLACE for data changes,
+	// or the statement verb (e.g. DROP, TRUNCATE) for DDL. SELECT ... INTO
+	// is reported as CREATE.
+	Verb string
+}
+
+// References reports whether name appears in the statement as an identifier,
+// compared case-insensitively. Strings and comments are not searched.
+func (s *Statement) References(name string) bool {
+	for _, t := range s.tokens {
+		if (t.kind == sqlWord || t.kind == sqlQuotedIdent) && strings.EqualFold(t.text, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectsAll reports whether the statement selects all columns with * or
+// table.*, which would include columns it does not name.
+func (s *Statement) SelectsAll() bool {
+	for i, t := range s.tokens {
+		if !t.is("*") || i == 0 {
+			continue
+		}
+		prev := s.tokens[i-1]
+		switch {
+		case prev.is("."), prev.is(","):
+			return true
+		case prev.kind == sqlWord:
+			switch strings.ToUpper(prev.text) {
+			case "SELECT", "DISTINCT", "ALL", "RETURNING":
+				return true
+			}
+		case prev.kind == sqlNumber && s.word(i-2) == "TOP":
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyVerbs are the statement types CheckReadOnly accepts.
+var readOnlyVerbs = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true,
+	"EXPLAIN": true, "SHOW": true, "DESCRIBE": true,
+}
+
+// lockingHints are SQL Server table hints that take locks beyond a plain
+// read.
+var lockingHints = map[string]bool{
+	"UPDLOCK": true, "XLOCK": true, "HOLDLOCK": true, "TABLOCK": true,
+	"TABLOCKX": true, "ROWLOCK": true, "PAGLOCK": true,
+}
+
+// statementStarts are keywords that begin a statement of their own. SQL
+// Server runs statements that follow one another without a semicolon, so in
+// a read-only statement they may not appear past its verb.
+var statementStarts = map[string]bool{
+	"ALTER": true, "BACKUP": true, "BEGIN": true, "BULK": true, "CALL": true,
+	"CHECKPOINT": true, "COMMIT": true, "CREATE": true, "DBCC": true,
+	"DEALLOCATE": true, "DECLARE": true, "DENY": true, "DROP": true,
+	"EXEC": true, "EXECUTE": true, "GO": true, "GRANT": true, "KILL": true,
+	"PREPARE": true, "RAISERROR": true, "RECONFIGURE": true, "RESTORE": true,
+	"REVOKE": true, "ROLLBACK": true, "SET": true, "SHUTDOWN": true,
+	"TRUNCATE": true, "USE": true, "WAITFOR": true,
+}
+
+// CheckReadOnly returns an error unless the statement only reads data. It
+// rejects other statement types, data-modifying CTEs (and EXPLAIN ANALYZE of
+// writes), SELECT ... INTO, locking clauses such as FOR UPDATE, and keywords
+// that start another statement, such as a DROP after a SELECT.
+func (s *Statement) CheckReadOnly() error {
+	if !readOnlyVerbs[s.Verb] {
+		return fmt.Errorf("%s statements are not allowed; only SELECT, WITH, EXPLAIN, and SHOW are", s.Verb)
+	}
+	for i, t := range s.tokens {
+		if t.kind != sqlWord {
+			continue
+		}
+		switch word := strings.ToUpper(t.text); word {
+		case "UPDATE":
+			if prev := s.word(i - 1); prev == "FOR" || prev == "KEY" {
+				return fmt.Errorf("locking clause FOR %s is not allowed", s.lockingClause(i))
+			}
+			fallthrough
+		case "INSERT", "DELETE", "MERGE":
+			if s.Verb == "WITH" {
+				return fmt.Errorf("data-modifying CTE (%s) is not allowed", word)
+			}
+			return fmt.Errorf("%s is not allowed in a read-only statement", word)
+		case "INTO":
+			return fmt.Errorf("SELECT ... INTO is not allowed")
+		case "FOR":
+			switch s.word(i + 1) {
+			case "SHARE", "NO", "KEY":
+				return fmt.Errorf("locking clause FOR %s is not allowed", s.lockingClause(i+1))
+			}
+		case "LOCK":
+			if s.word(i+1) == "IN" {
+				return fmt.Errorf("locking clause LOCK IN SHARE MODE is not allowed")
+			}
+		default:
+			if lockingHints[word] {
+				return fmt.Errorf("locking hint %s is not allowed", word)
+			}
+			if statementStarts[word] && !s.continuesStatement(i) {
+				return fmt.Errorf("%s starts another statement; only a single read-only statement is allowed", word)
+			}
+		}
+	}
+	return nil
+}
+
+// continuesStatement reports whether the statement-starting keyword at index
+// i is instead part of the statement: the verb itself, the object of SHOW
+// (SHOW CREATE TABLE), MySQL's CHARACTER SET and USE INDEX, or a column
+// qualified by a table name.
+func (s *Statement) continuesStatement(i int) bool {
+	if i == 0 || s.tokens[i-1].is(".") {
+		return true
+	}
+	switch s.word(i) {
+	case "SET":
+		return s.word(i-1) == "CHARACTER"
+	case "USE":
+		return s.word(i+1) == "INDEX" || s.word(i+1) == "KEY"
+	}
+	return s.word(i-1) == "SHOW"
+}
+
+// lockingClause renders the words of a FOR ... locking clause from index i.
+func (s *Statement) lockingClause(i int) string {
+	var words []string
+	for ; i < len(s.tokens); i++ {
+		w := s.word(i)
+		if w != "NO" && w != "KEY" && w != "UPDATE" && w != "SHARE" {
+			break
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// word returns the upper-cased word at index i, or "" if the token there is
+// not a word.
+func (s *Statement) word(i int) string {
+	if i < 0 || i >= len(s.tokens) || s.tokens[i].kind != sqlWord {
+		return ""
+	}
+	return strings.ToUpper(s.tokens[i].text)
+}
+
+// fromListEnd are keywords that end a FROM clause's comma-separated list.
+var fromListEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "UNION": true, "INTERSECT": true, "EXCEPT": true,
+	"MINUS": true, "WINDOW": true, "QUALIFY": true, "FETCH": true,
+	"FOR": true, "SET": true, "RETURNING": true, "SELECT": true,
+	"VALUES": true, "CONNECT": true, "START": true,
+}
+
+// tableFollows are keywords that may follow a FROM or JOIN target (or its
+// alias): joins, index and locking hints, sampling, time travel and the like.
+var tableFollows = map[string]bool{
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"OUTER": true, "CROSS": true, "NATURAL": true, "STRAIGHT_JOIN": true,
+	"ASOF": true, "ON": true, "USING": true, "WITH": true, "USE": true,
+	"FORCE": true, "IGNORE": true, "INDEXED": true, "NOT": true,
+	"PARTITION": true, "TABLESAMPLE": true, "SAMPLE": true, "AS": true,
+	"AT": true, "BEFORE": true, "CHANGES": true, "PIVOT": true,
+	"UNPIVOT": true, "MATCH_RECOGNIZE": true, "MODEL": true, "LOCK": true,
+	"INTO": true, "OPTION": true, "WHEN": true,
+}
+
+// resolveTables walks the tokens for table references, setting Tables,
+// Writes, and Unresolved: the names after FROM (and each comma of its list),
+// JOIN, APPLY, INTO, UPDATE, TABLE, and USING. Only query-level parentheses
+// and those around a FROM or JOIN target, as in FROM (a JOIN b), are
+// searched, so that e.g. the FROM of EXTRACT(YEAR FROM col) is not mistaken
+// for a table.
+func (s *Statement) resolveTables() {
+	ctes := s.cteNames()
+	seen := make(map[string]bool)
+	// add records the table at token i; writeVerb is set if the statement
+	// modifies it.
+	add := func(i int, fromClause bool, writeVerb string) {
+		name, ok := s.tableName(i, fromClause)
+		if !ok {
+			return
+		}
+		if !strings.Contains(name, ".") && ctes[strings.ToLower(name)] {
+			return
+		}
+		if !seen[name] {
+			seen[name] = true
+			s.Tables = append(s.Tables, name)
+		}
+		if writeVerb != "" {
+			s.Writes = append(s.Writes, TableWrite{Table: name, Verb: writeVerb})
+		}
+	}
+	// pending is the DML verb waiting for its INTO or FROM target.
+	var pending string
+	// paren is the index of a "(" that opens a FROM or JOIN target.
+	paren := -1
+	// from records the FROM or JOIN target at token i, or notes the
+	// parenthesis that opens it.
+	from := func(i int) {
+		switch s.word(i) {
+		case "ONLY", "LATERAL":
+			i++
+		}
+		switch {
+		case i >= len(s.tokens):
+		case s.tokens[i].is("("):
+			paren = i
+		case s.tokens[i].kind != sqlWord && s.tokens[i].kind != sqlQuotedIdent:
+			s.Unresolved = append(s.Unresolved, s.tokens[i].text)
+		default:
+			if _, ok := s.tableName(i, true); !ok {
+				// A table function; name it as called.
+				name, _ := s.tableName(i, false)
+				s.Unresolved = append(s.Unresolved, name)
+				return
+			}
+			add(i, true, "")
+			s.afterTable(s.nameEnd(i))
+		}
+	}
+
+	type level struct {
+		query    bool // a statement or subquery, rather than an expression
+		fromList bool // inside a FROM list, where commas separate tables
+		target   bool // a parenthesized FROM or JOIN target
+	}
+	stack := []level{{query: true}}
+	for i, t := range s.tokens {
+		top := &stack[len(stack)-1]
+		switch {
+		case t.is("("):
+			target := i == paren
+			switch s.word(i + 1) {
+			case "SELECT", "WITH", "VALUES", "INSERT", "UPDATE", "DELETE", "MERGE":
+				stack = append(stack, level{query: true, target: target})
+			default:
+				if !target {
+					stack = append(stack, level{})
+					break
+				}
+				// A parenthesized table reference or join.
+				stack = append(stack, level{query: true, fromList: true, target: true})
+				from(i + 1)
+			}
+			continue
+		case t.is(")"):
+			if len(stack) > 1 {
+				if top.target {
+					s.afterTable(i + 1)
+				}
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		case !top.query:
+			continue
+		case t.is(","):
+			if top.fromList {
+				from(i + 1)
+			}
+			continue
+		case t.kind != sqlWord:
+			continue
+		}
+
+		switch word := strings.ToUpper(t.text); word {
+		case "FROM":
+			// a IS [NOT] DISTINCT FROM b
+			if s.word(i-1) == "DISTINCT" {
+				continue
+			}
+			if pending == "DELETE" {
+				add(i+1, false, pending)
+				pending = ""
+			} else {
+				from(i + 1)
+			}
+			top.fromList = true
+		case "INSERT", "DELETE", "MERGE":
+			pending = word
+		case "REPLACE":
+			if s.word(i+1) == "INTO" {
+				pending = word
+			}
+		case "JOIN", "APPLY":
+			from(i + 1)
+		case "STRAIGHT_JOIN":
+			// Not MySQL's SELECT STRAIGHT_JOIN modifier.
+			if top.fromList {
+				from(i + 1)
+			}
+		case "USING":
+			add(i+1, false, "")
+		case "INTO":
+			if w := s.word(i + 1); w == "OUTFILE" || w == "DUMPFILE" {
+				continue
+			}
+			verb := pending
+			if verb == "" {
+				verb = "CREATE" // SELECT ... INTO new_table
+			}
+			add(i+1, false, verb)
+			pending = ""
+		case "UPDATE":
+			// Not FOR [NO KEY] UPDATE, ON DUPLICATE KEY UPDATE, or
+			// ON CONFLICT DO UPDATE SET.
+			if w := s.word(i - 1); w != "FOR" && w != "KEY" && s.word(i+1) != "SET" {
+				add(i+1, false, word)
+			}
+		case "TRUNCATE":
+			if s.word(i+1) != "TABLE" {
+				add(i+1, false, word)
+			}
+		case "TABLE":
+			j := i + 1
+			if s.word(j) == "IF" {
+				for j < len(s.tokens) && s.word(j) != "EXISTS" {
+					j++
+				}
+				j++
+			}
+			if s.Verb == "TABLE" {
+				add(j, false, "")
+			} else {
+				add(j, false, s.Verb)
+			}
+		default:
+			if fromListEnd[word] {
+				top.fromList = false
+			}
+		}
+	}
+}
+
+// afterTable checks token i, just past a FROM or JOIN target. Past an
+// optional alias, a word that is not a keyword known to follow a table
+// reference is added to Unresolved: it may be a join the parser does not
+// recognize, hiding the table after it.
+func (s *Statement) afterTable(i int) {
+	if s.word(i) == "AS" && s.word(i+1) != "OF" {
+		i += 2
+	} else if s.unknownWord(i) {
+		i++ // an alias
+	}
+	if s.unknownWord(i) {
+		s.Unresolved = append(s.Unresolved, s.tokens[i].text)
+	}
+}
+
+// unknownWord reports whether token i is an identifier that neither follows
+// a table reference nor ends a FROM list.
+func (s *Statement) unknownWord(i int) bool {
+	if i >= len(s.tokens) {
+		return false
+	}
+	switch s.tokens[i].kind {
+	case sqlQuotedIdent:
+		return true
+	case sqlWord:
+		word := s.word(i)
+		return !tableFollows[word] && !fromListEnd[word]
+	}
+	return false
+}
+
+// nameEnd returns the index of the token after the possibly qualified name
+// starting at token i.
+func (s *Statement) nameEnd(i int) int {
+	for i < len(s.tokens) && (s.tokens[i].kind == sqlWord || s.tokens[i].kind == sqlQuotedIdent) {
+		if i+2 < len(s.tokens) && s.tokens[i+1].is(".") {
+			i += 2
+			continue
+		}
+		return i + 1
+	}
+	return i
+}
+
+// tableName reads a possibly qualified table name starting at token i and
+// reports false if there is none. In a FROM clause, a name followed by "("
+// is a table function rather than a table; elsewhere the parenthesis opens
+// a column list, as in INSERT INTO t (a, b).
+func (s *Statement) tableName(i int, fromClause bool) (string, bool) {
+	switch s.word(i) {
+	case "ONLY", "LATERAL":
+		i++
+	}
+	var parts []string
+	for i < len(s.tokens) {
+		t := s.tokens[i]
+		if t.kind != sqlWord && t.kind != sqlQuotedIdent {
+			break
+		}
+		parts = append(parts, t.text)
+		if i+2 < len(s.tokens) && s.tokens[i+1].is(".") {
+			i += 2
+			continue
+		}
+		i++
+		break
+	}
+	if len(parts) == 0 {
+		return "", false
+	}
+	if fromClause && i < len(s.tokens) && s.tokens[i].is("(") {
+		return "", false
+	}
+	return strings.Join(parts, "."), true
+}
+
+// cteNames returns the lower-cased names of the statement's common table
+// expressions: a name after WITH [RECURSIVE] or a comma, followed by an
+// optional column list and AS [[NOT] MATERIALIZED] (.
+func (s *Statement) cteNames() map[string]bool {
+	names := make(map[string]bool)
+	for i, t := range s.tokens {
+		if i == 0 || (t.kind != sqlWord && t.kind != sqlQuotedIdent) {
+			continue
+		}
+		if prev := s.word(i - 1); prev != "WITH" && prev != "RECURSIVE" && !s.tokens[i-1].is(",") {
+			continue
+		}
+		j := i + 1
+		if j < len(s.tokens) && s.tokens[j].is("(") {
+			for depth := 0; j < len(s.tokens); j++ {
+				if s.tokens[j].is("(") {
+					depth++
+				} else if s.tokens[j].is(")") {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			j++
+		}
+		if s.word(j) != "AS" {
+			continue
+		}
+		j++
+		for s.word(j) == "NOT" || s.word(j) == "MATERIALIZED" {
+			j++
+		}
+		if j < len(s.tokens) && s.tokens[j].is("(") {
+			names[strings.ToLower(t.text)] = true
+		}
+	}
+	return names
+}
+
+// --- SQL lexer ---
+
+type sqlTokenKind int
+
+const (
+	sqlWord        sqlTokenKind = iota // keyword or unquoted identifier
+	sqlQuotedIdent                     // quoted identifier; text is unquoted
+	sqlString                          // string literal; text is the raw literal
+	sqlNumber                          // numeric literal
+	sqlParam                           // bind parameter or variable
+	sqlPunct                           // any other single character
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// is reports whether t is the punctuation character p.
+func (t sqlToken) is(p string) bool {
+	return t.kind == sqlPunct && t.text == p
+}
+
+// sqlDialect holds the lexical rules that differ between databases.
+type sqlDialect struct {
+	hashComments     bool // # starts a line comment (MySQL)
+	nestedComments   bool // /* */ comments nest (PostgreSQL)
+	backslashEscapes bool // \ escapes characters in '...' (MySQL, Snowflake)
+	doubleQuoteStr   bool // "..." is a string, not an identifier (MySQL)
+	backtickIdents   bool // `...` quotes identifiers (MySQL, SQLite)
+	bracketIdents    bool // [...] quotes identifiers (SQL Server, SQLite)
+	dollarQuotes     bool // $tag$...$tag$ strings (PostgreSQL)
+	dollarDollar     bool // $$...$$ strings without tags (Snowflake)
+	escapeStrings    bool // E'...' strings with backslash escapes (PostgreSQL)
+	qQuotes          bool // q'[...]' alternative quoting (Oracle)
+}
+
+func dialectFor(driver string) sqlDialect {
+	switch driver {
+	case "postgres":
+		return sqlDialect{nestedComments: true, dollarQuotes: true, escapeStrings: true}
+	case "mysql":
+		return sqlDialect{hashComments: true, backslashEscapes: true, doubleQuoteStr: true, backtickIdents: true}
+	case "sqlite":
+		return sqlDialect{backtickIdents: true, bracketIdents: true}
+	case "mssql":
+		return sqlDialect{bracketIdents: true}
+	case "oracle":
+		return sqlDialect{qQuotes: true}
+	case "snowflake":
+		return sqlDialect{backslashEscapes: true, dollarDollar: true}
+	}
+	return sqlDialect{}
+}
+
+type sqlLexer struct {
+	input   string
+	pos     int
+	dialect sqlDialect
+	tokens  []sqlToken
+}
+
+// lexSQL splits input into tokens, dropping whitespace and comments.
+func lexSQL(input string, dialect sqlDialect) ([]sqlToken, error) {
+	l := &sqlLexer{input: input, dialect: dialect}
+	for l.pos < len(l.input) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+func (l *sqlLexer) emit(kind sqlTokenKind, text string) {
+	l.tokens = append(l.tokens, sqlToken{kind: kind, text: text})
+}
+
+func (l *sqlLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.input) {
+		return l.input[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *sqlLexer) next() error {
+	ch := l.input[l.pos]
+	d := l.dialect
+	switch {
+	case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f':
+		l.pos++
+	case ch == '-' && l.peek(1) == '-', ch == '#' && d.hashComments:
+		l.skipLine()
+	case ch == '/' && l.peek(1) == '*':
+		return l.skipBlockComment()
+	case ch == '\'':
+		return l.readQuoted('\'', sqlString, d.backslashEscapes)
+	case ch == '"':
+		if d.doubleQuoteStr {
+			return l.readQuoted('"', sqlString, d.backslashEscapes)
+		}
+		return l.readQuoted('"', sqlQuotedIdent, false)
+	case ch == '`' && d.backtickIdents:
+		return l.readQuoted('`', sqlQuotedIdent, false)
+	case ch == '[' && d.bracketIdents:
+		return l.readQuoted(']', sqlQuotedIdent, false)
+	case ch == '$' && (d.dollarQuotes || d.dollarDollar):
+		return l.readDollar()
+	case ch == '?', ch == '@':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.input) && isSQLWordChar(l.input[l.pos]) {
+			l.pos++
+		}
+		l.emit(sqlParam, l.input[start-1:l.pos])
+	case isDigit(ch) || ch == '.' && isDigit(l.peek(1)):
+		start := l.pos
+		for l.pos < len(l.input) && (isSQLWordChar(l.input[l.pos]) || l.input[l.pos] == '.') {
+			l.pos++
+		}
+		l.emit(sqlNumber, l.input[start:l.pos])
+	case isSQLWordStart(ch):
+		return l.readWord()
+	default:
+		l.pos++
+		l.emit(sqlPunct, string(ch))
+	}
+	return nil
+}
+
+func (l *sqlLexer) skipLine() {
+	for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+func (l *sqlLexer) skipBlockComment() error {
+	if l.dialect.hashComments && l.peek(2) == '!' {
+		// MySQL executes the contents of /*! ... */ comments.
+		return fmt.Errorf("MySQL executable comments (/*! ... */) are not allowed")
+	}
+	depth := 0
+	for l.pos < len(l.input) {
+		switch {
+		case l.input[l.pos] == '/' && l.peek(1) == '*':
+			if depth == 0 || l.dialect.nestedComments {
+				depth++
+			}
+			l.pos += 2
+		case l.input[l.pos] == '*' && l.peek(1) == '/':
+			l.pos += 2
+			if depth--; depth == 0 {
+				return nil
+			}
+		default:
+			l.pos++
+		}
+	}
+	return fmt.Errorf("unterminated block comment")
+}
+
+// readQuoted reads a literal or identifier closed by end, where a doubled
+// end character stands for itself. With backslash set, \ escapes the next
+// character.
+func (l *sqlLexer) readQuoted(end byte, kind sqlTokenKind, backslash bool) error {
+	start := l.pos
+	l.pos++ // opening quote
+	var sb strings.Builder
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		switch {
+		case backslash && ch == '\\' && l.pos+1 < len(l.input):
+			sb.WriteByte(l.input[l.pos+1])
+			l.pos += 2
+		case ch == end && l.peek(1) == end:
+			sb.WriteByte(end)
+			l.pos += 2
+		case ch == end:
+			l.pos++
+			if kind == sqlQuotedIdent {
+				l.emit(kind, sb.String())
+			} else {
+				l.emit(kind, l.input[start:l.pos])
+			}
+			return nil
+		default:
+			sb.WriteByte(ch)
+			l.pos++
+		}
+	}
+	if kind == sqlQuotedIdent {
+		return fmt.Errorf("unterminated quoted identifier")
+	}
+	return fmt.Errorf("unterminated string literal")
+}
+
+// readDollar reads a dollar-quoted string ($tag$...$tag$ or $$...$$) or a
+// positional parameter such as $1.
+func (l *sqlLexer) readDollar() error {
+	start := l.pos
+	if isDigit(l.peek(1)) {
+		l.pos++
+		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+		l.emit(sqlParam, l.input[start:l.pos])
+		return nil
+	}
+	end := l.pos + 1
+	if l.dialect.dollarQuotes {
+		for end < len(l.input) && isSQLWordChar(l.input[end]) && l.input[end] != '$' {
+			end++
+		}
+	}
+	if end >= len(l.input) || l.input[end] != '$' {
+		l.pos++
+		l.emit(sqlPunct, "$")
+		return nil
+	}
+	tag := l.input[start : end+1]
+	body := strings.Index(l.input[end+1:], tag)
+	if body < 0 {
+		return fmt.Errorf("unterminated dollar-quoted string")
+	}
+	l.pos = end + 1 + body + len(tag)
+	l.emit(sqlString, l.input[start:l.pos])
+	return nil
+}
+
+// readWord reads a keyword or identifier, or a prefixed string literal such
+// as N'...', E'...', or Oracle's q'[...]'.
+func (l *sqlLexer) readWord() error {
+	start := l.pos
+	for l.pos < len(l.input) && isSQLWordChar(l.input[l.pos]) {
+		l.pos++
+	}
+	word := l.input[start:l.pos]
+	if l.peek(0) == '\'' {
+		switch strings.ToUpper(word) {
+		case "N", "X", "B":
+			return l.readQuoted('\'', sqlString, l.dialect.backslashEscapes)
+		case "E":
+			if l.dialect.escapeStrings {
+				return l.readQuoted('\'', sqlString, true)
+			}
+		case "Q", "NQ":
+			if l.dialect.qQuotes {
+				return l.readQQuote()
+			}
+		}
+	}
+	l.emit(sqlWord, word)
+	return nil
+}
+
+// readQQuote reads the body of an Oracle q'<delim>...<delim>' literal.
+func (l *sqlLexer) readQQuote() error {
+	start := l.pos
+	if l.pos+1 >= len(l.input) {
+		return fmt.Errorf("unterminated string literal")
+	}
+	open := l.input[l.pos+1]
+	closing := open
+	switch open {
+	case '[':
+		closing = ']'
+	case '{':
+		closing = '}'
+	case '(':
+		closing = ')'
+	case '<':
+		closing = '>'
+	}
+	end := strings.Index(l.input[l.pos+2:], string(closing)+"'")
+	if end < 0 {
+		return fmt.Errorf("unterminated string literal")
+	}
+	l.pos += 2 + end + 2
+	l.emit(sqlString, l.input[start:l.pos])
+	return nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isSQLWordStart reports whether ch can start a keyword or identifier. Bytes
+// of multi-byte UTF-8 characters are treated as letters.
+func isSQLWordStart(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_' || ch == '#' || ch >= 0x80
+}
+
+func isSQLWordChar(ch byte) bool {
+	return isSQLWordStart(ch) || isDigit(ch) || ch == '$'
+}
@@ -0,0 +1,284 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatement_Tables(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		sql    string
+		verb   string
+		tables []string
+	}{
+		{"simple", "postgres", "SELECT * FROM orders", "SELECT", []string{"orders"}},
+		{"trailing semicolons", "postgres", "select 1 from orders;;", "SELECT", []string{"orders"}},
+		{"from list and joins", "postgres",
+			"SELECT * FROM orders o, customers c JOIN order_items i ON i.order_id = o.id WHERE o.id = 1",
+			"SELECT", []string{"orders", "customers", "order_items"}},
+		{"qualified and quoted", "postgres", `SELECT * FROM public."Orders"`, "SELECT", []string{"public.Orders"}},
+		{"subquery", "postgres",
+			"SELECT * FROM customers WHERE id IN (SELECT customer_id FROM orders)",
+			"SELECT", []string{"customers", "orders"}},
+		{"function FROM is not a table", "postgres",
+			"SELECT EXTRACT(YEAR FROM ordered_at), TRIM(BOTH ' ' FROM status) FROM orders",
+			"SELECT", []string{"orders"}},
+		{"table function", "postgres", "SELECT * FROM generate_series(1, 3) g, orders", "SELECT", []string{"orders"}},
+		{"parenthesized tables", "postgres",
+			"SELECT * FROM (orders) o, ((customers c JOIN order_items i ON i.order_id = c.id)) JOIN (products) ON true",
+			"SELECT", []string{"orders", "customers", "order_items", "products"}},
+		{"straight join", "mysql", "SELECT * FROM users STRAIGHT_JOIN vault", "SELECT", []string{"users", "vault"}},
+		{"straight join modifier", "mysql", "SELECT STRAIGHT_JOIN id FROM users u STRAIGHT_JOIN vault v ON v.id = u.id", "SELECT", []string{"users", "vault"}},
+		{"parenthesized subquery", "postgres", "SELECT * FROM ((SELECT id FROM orders) o JOIN customers c ON c.id = o.id)", "SELECT", []string{"orders", "customers"}},
+		{"cte names excluded", "postgres",
+			"WITH recent AS (SELECT * FROM orders), big(id) AS MATERIALIZED (SELECT id FROM recent) SELECT * FROM big JOIN customers ON true",
+			"WITH", []string{"orders", "customers"}},
+		{"distinct from", "postgres", "SELECT * FROM orders WHERE a IS DISTINCT FROM b", "SELECT", []string{"orders"}},
+		{"keywords in strings and comments", "postgres",
+			"SELECT 'FROM secret; DELETE' /* FROM hidden */ FROM orders -- JOIN other",
+			"SELECT", []string{"orders"}},
+		{"dollar quoted", "postgres", "SELECT $tag$ FROM x; $$ ; $tag$ FROM orders", "SELECT", []string{"orders"}},
+		{"escape string", "postgres", `SELECT E'it\'s; FROM x' FROM orders`, "SELECT", []string{"orders"}},
+		{"nested comment", "postgres", "SELECT 1 /* a /* b */ FROM x; */ FROM orders", "SELECT", []string{"orders"}},
+		{"mysql backticks and hash comment", "mysql", "SELECT * FROM `shop`.`orders` # ; DROP TABLE x", "SELECT", []string{"shop.orders"}},
+		{"mysql backslash escape", "mysql", `SELECT 'a\'; DROP TABLE x' FROM orders`, "SELECT", []string{"orders"}},
+		{"mssql brackets", "mssql", "SELECT TOP 5 * FROM [dbo].[orders] WITH (NOLOCK)", "SELECT", []string{"dbo.orders"}},
+		{"oracle q quote", "oracle", "SELECT q'[it's; FROM x]' FROM orders", "SELECT", []string{"orders"}},
+		{"snowflake dollar string", "snowflake", "SELECT $$ ; FROM x $$ FROM orders", "SELECT", []string{"orders"}},
+		{"insert", "sqlite", "INSERT INTO orders (id, status) SELECT id, 'x' FROM customers", "INSERT", []string{"orders", "customers"}},
+		{"update", "postgres", "UPDATE orders SET status = 'x' FROM customers WHERE customers.id = orders.customer_id", "UPDATE", []string{"orders", "customers"}},
+		{"delete using", "postgres", "DELETE FROM orders USING customers WHERE true", "DELETE", []string{"orders", "customers"}},
+		{"drop table", "postgres", "DROP TABLE IF EXISTS audit.old_orders", "DROP", []string{"audit.old_orders"}},
+		{"create table", "postgres", "CREATE TABLE archive (id int)", "CREATE", []string{"archive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseStatement(tt.sql, tt.driver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stmt.Verb != tt.verb {
+				t.Errorf("verb = %q, want %q", stmt.Verb, tt.verb)
+			}
+			if !reflect.DeepEqual(stmt.Tables, tt.tables) {
+				t.Errorf("tables = %q, want %q", stmt.Tables, tt.tables)
+			}
+		})
+	}
+}
+
+func TestParseStatement_Unresolved(t *testing.T) {
+	tests := []struct {
+		name       string
+		driver     string
+		sql        string
+		unresolved []string
+	}{
+		{"tables", "postgres", "SELECT * FROM orders o JOIN (customers) c ON true, LATERAL (SELECT 1) x", nil},
+		{"table function", "sqlite", "SELECT * FROM pragma_table_info('customers')", []string{"pragma_table_info"}},
+		{"qualified table function", "mssql", "SELECT * FROM orders CROSS APPLY dbo.items(orders.id)", []string{"dbo.items"}},
+		{"lateral function in list", "postgres", "SELECT * FROM orders, LATERAL unnest(tags) t", []string{"unnest"}},
+		{"table variable", "mssql", "SELECT * FROM @rows", []string{"@rows"}},
+		{"function FROM", "postgres", "SELECT EXTRACT(YEAR FROM (ordered_at)) FROM orders", nil},
+		{"hints", "mysql", "SELECT * FROM orders AS o USE INDEX (idx) LEFT JOIN customers c FORCE INDEX (pk) ON c.id = o.customer_id", nil},
+		{"flashback", "oracle", "SELECT * FROM orders AS OF TIMESTAMP SYSDATE - 1 WHERE id = 1", nil},
+		{"unknown join", "mysql", "SELECT * FROM users u MYSTERY_JOIN vault", []string{"MYSTERY_JOIN"}},
+		{"unknown word as alias", "mysql", "SELECT * FROM users MYSTERY_JOIN vault", []string{"vault"}},
+		{"after subquery", "postgres", "SELECT * FROM (SELECT 1) x MYSTERY_JOIN vault", []string{"MYSTERY_JOIN"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseStatement(tt.sql, tt.driver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(stmt.Unresolved, tt.unresolved) {
+				t.Errorf("unresolved = %q, want %q", stmt.Unresolved, tt.unresolved)
+			}
+		})
+	}
+}
+
+func TestParseStatement_QueryCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		sql    string
+		calls  []string
+	}{
+		{"plain functions", "postgres", "SELECT lower(name), count(*) FROM orders", nil},
+		{"query_to_xml", "postgres", "SELECT query_to_xml('select * from vault', true, false, '')", []string{"query_to_xml"}},
+		{"qualified", "postgres", "SELECT pg_catalog.query_to_xml('select 1', true, false, '')", []string{"pg_catalog.query_to_xml"}},
+		{"dblink", "postgres", "SELECT * FROM orders WHERE id IN (SELECT id FROM dblink('dbname=x', 'select id from vault') AS t(id int))", []string{"dblink"}},
+		{"in a string", "postgres", "SELECT 'query_to_xml(x)' FROM orders", nil},
+		{"other dialect", "mysql", "SELECT query_to_xml('select 1') FROM orders", nil},
+		{"openquery", "mssql", "SELECT * FROM orders WHERE id IN (SELECT id FROM OPENQUERY(remote, 'select id from vault'))", []string{"OPENQUERY"}},
+		{"oracle package", "oracle", "SELECT DBMS_XMLGEN.GETXML('select * from vault') FROM dual", []string{"DBMS_XMLGEN.GETXML"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseStatement(tt.sql, tt.driver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(stmt.QueryCalls, tt.calls) {
+				t.Errorf("query calls = %q, want %q", stmt.QueryCalls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestParseStatement_Writes(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		sql    string
+		writes []TableWrite
+	}{
+		{"select", "postgres", "SELECT * FROM orders FOR UPDATE", nil},
+		{"insert select", "postgres", "INSERT INTO archive SELECT * FROM orders", []TableWrite{{"archive", "INSERT"}}},
+		{"upsert", "postgres", "INSERT INTO orders (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET status = 'x'", []TableWrite{{"orders", "INSERT"}}},
+		{"mysql upsert", "mysql", "INSERT INTO orders (id) VALUES (1) ON DUPLICATE KEY UPDATE status = 'x'", []TableWrite{{"orders", "INSERT"}}},
+		{"sqlite replace", "sqlite", "INSERT OR REPLACE INTO orders (id) VALUES (1)", []TableWrite{{"orders", "REPLACE"}}},
+		{"update", "postgres", "UPDATE orders SET status = 'x' FROM customers", []TableWrite{{"orders", "UPDATE"}}},
+		{"delete", "postgres", "DELETE FROM orders WHERE id IN (SELECT id FROM reviews)", []TableWrite{{"orders", "DELETE"}}},
+		{"cte delete", "postgres", "WITH gone AS (DELETE FROM orders RETURNING id) SELECT * FROM gone", []TableWrite{{"orders", "DELETE"}}},
+		{"merge", "mssql", "MERGE INTO orders USING staging ON orders.id = staging.id WHEN MATCHED THEN UPDATE SET status = staging.status", []TableWrite{{"orders", "MERGE"}}},
+		{"select into", "mssql", "SELECT * INTO backup FROM orders", []TableWrite{{"backup", "CREATE"}}},
+		{"truncate", "postgres", "TRUNCATE orders", []TableWrite{{"orders", "TRUNCATE"}}},
+		{"drop", "mysql", "DROP TABLE IF EXISTS orders", []TableWrite{{"orders", "DROP"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseStatement(tt.sql, tt.driver)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(stmt.Writes, tt.writes) {
+				t.Errorf("writes = %v, want %v", stmt.Writes, tt.writes)
+			}
+		})
+	}
+}
+
+func TestStatement_Columns(t *testing.T) {
+	tests := []struct {
+		sql        string
+		selectsAll bool
+	}{
+		{"SELECT * FROM orders", true},
+		{"SELECT o.* FROM orders o", true},
+		{"SELECT id, * FROM orders", true},
+		{"SELECT DISTINCT * FROM orders", true},
+		{"SELECT TOP 5 * FROM orders", true},
+		{"SELECT COUNT(*), total * 2 FROM orders", false},
+		{"SELECT id FROM orders", false},
+	}
+	for _, tt := range tests {
+		stmt, err := ParseStatement(tt.sql, "mssql")
+		if err != nil {
+			t.Fatalf("%q: %v", tt.sql, err)
+		}
+		if got := stmt.SelectsAll(); got != tt.selectsAll {
+			t.Errorf("%q: SelectsAll = %v, want %v", tt.sql, got, tt.selectsAll)
+		}
+	}
+
+	stmt, err := ParseStatement(`SELECT "Phone" AS p, 'email' FROM customers -- ssn`, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stmt.References("phone") {
+		t.Error("expected quoted identifier to be referenced")
+	}
+	if stmt.References("email") || stmt.References("ssn") {
+		t.Error("strings and comments are not references")
+	}
+}
+
+func TestParseStatement_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		sql    string
+		want   string
+	}{
+		{"empty", "postgres", "  -- nothing\n", "empty"},
+		{"multiple statements", "postgres", "SELECT 1; DELETE FROM orders", "multiple statements"},
+		{"unterminated string", "postgres", "SELECT 'abc", "unterminated string"},
+		{"unterminated comment", "postgres", "SELECT 1 /* abc", "unterminated block comment"},
+		{"unterminated dollar", "postgres", "SELECT $a$ abc", "unterminated dollar-quoted"},
+		{"unterminated identifier", "mssql", "SELECT * FROM [orders", "unterminated quoted identifier"},
+		{"mysql executable comment", "mysql", "SELECT /*! 1; DROP TABLE x */ 1", "executable comments"},
+		// Without backslash escapes, the quote ends the string in standard SQL.
+		{"standard string ends at quote", "postgres", `SELECT 'a\'; DELETE FROM orders; --'`, "multiple statements"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStatement(tt.sql, tt.driver)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestStatement_CheckReadOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		sql    string
+		want   string // empty if allowed
+	}{
+		{"select", "postgres", "SELECT * FROM orders", ""},
+		{"with", "postgres", "WITH x AS (SELECT 1) SELECT * FROM x", ""},
+		{"explain", "postgres", "EXPLAIN SELECT * FROM orders", ""},
+		{"show", "mysql", "SHOW TABLES", ""},
+		{"parenthesized", "postgres", "(SELECT 1) UNION (SELECT 2)", ""},
+		{"update column name", "postgres", "SELECT updated_at, for_date FROM orders", ""},
+		{"substring for", "postgres", "SELECT SUBSTRING(status FROM 1 FOR 2) FROM orders", ""},
+		{"keywords in strings", "postgres", "SELECT 'DELETE FOR UPDATE INTO' FROM orders", ""},
+		{"delete", "postgres", "DELETE FROM orders", "DELETE statements are not allowed"},
+		{"pragma", "sqlite", "PRAGMA query_only = OFF", "PRAGMA statements are not allowed"},
+		{"data-modifying cte", "postgres", "WITH x AS (DELETE FROM orders RETURNING *) SELECT * FROM x", "data-modifying CTE (DELETE)"},
+		{"cte update", "postgres", "WITH x AS (UPDATE orders SET status = 'x' RETURNING id) SELECT 1", "data-modifying CTE (UPDATE)"},
+		{"explain analyze write", "postgres", "EXPLAIN ANALYZE INSERT INTO orders DEFAULT VALUES", "INSERT is not allowed"},
+		{"select into", "postgres", "SELECT * INTO copy FROM orders", "SELECT ... INTO"},
+		{"mysql into outfile", "mysql", "SELECT * FROM orders INTO OUTFILE '/tmp/x'", "SELECT ... INTO"},
+		{"for update", "postgres", "SELECT * FROM orders FOR UPDATE", "FOR UPDATE"},
+		{"for no key update", "postgres", "SELECT * FROM orders FOR NO KEY UPDATE SKIP LOCKED", "FOR NO KEY UPDATE"},
+		{"for share", "postgres", "SELECT * FROM orders FOR SHARE", "FOR SHARE"},
+		{"lock in share mode", "mysql", "SELECT * FROM orders LOCK IN SHARE MODE", "LOCK IN SHARE MODE"},
+		{"mssql hint", "mssql", "SELECT * FROM orders WITH (UPDLOCK)", "locking hint UPDLOCK"},
+		{"show create", "mysql", "SHOW CREATE TABLE orders", ""},
+		{"character set", "mysql", "SELECT CONVERT(status USING utf8mb4), CAST(status AS CHAR CHARACTER SET latin1) FROM orders", ""},
+		{"index hint", "mysql", "SELECT * FROM orders USE INDEX (idx_status)", ""},
+		{"mssql second statement", "mssql", "SELECT 1 DROP TABLE users", "DROP starts another statement"},
+		{"mssql commit", "mssql", "SELECT 1 COMMIT DROP TABLE users", "COMMIT starts another statement"},
+		{"mssql exec", "mssql", "SELECT 1 EXEC xp_cmdshell 'dir'", "EXEC starts another statement"},
+		{"mssql batch separator", "mssql", "SELECT 1 GO DROP TABLE x", "GO starts another statement"},
+		{"mssql waitfor", "mssql", "SELECT 1 WAITFOR DELAY '00:00:10'", "WAITFOR starts another statement"},
+		{"mssql set", "mssql", "SELECT 1 SET IMPLICIT_TRANSACTIONS OFF", "SET starts another statement"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseStatement(tt.sql, tt.driver)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			err = stmt.CheckReadOnly()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
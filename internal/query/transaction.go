@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/connector"
+)
+
+// maxTransactionSteps caps the steps of a single transaction.
+const maxTransactionSteps = 100
+
+// Transaction executes a list of INSERT, UPDATE, and DELETE operations in
+// one database transaction. Every step is validated and authorized as by
+// Insert, Update, and Delete before any runs. If a step fails, the
+// transaction is rolled back and the error names the step. With
+// req.DryRun, every step runs and the transaction is then rolled back, so
+// the result reports the rows each step would affect.
+//
+// Insert steps are batched as by Insert, but never continue past a failed
+// row.
+//
+// Update and delete steps cannot be confirmed as by Update and Delete, so a
+// transaction that would need confirmation fails with
+// connector.ErrConfirmationRequired: with ConfirmWrites, any committed
+// transaction with such steps, and otherwise one with a step that affects
+// at least the confirmation threshold of rows.
+//
+// The query timeout bounds the transaction as a whole.
+func (e *Engine) Transaction(ctx context.Context, req connector.TransactionRequest) (*connector.TransactionResult, error) {
+	if len(req.Steps) == 0 {
+		return nil, &ValidationError{Field: "steps", Message: "at least one step is required"}
+	}
+	if len(req.Steps) > maxTransactionSteps {
+		return nil, &ValidationError{
+			Field:   "steps",
+			Message: fmt.Sprintf("%d steps exceed the maximum of %d", len(req.Steps), maxTransactionSteps),
+		}
+	}
+
+	if !req.DryRun && e.validator.ConfirmWrites() {
+		for _, step := range req.Steps {
+			if step.Update != nil || step.Delete != nil {
+				return nil, fmt.Errorf("%w: update and delete steps must be confirmed, so they cannot run in a transaction", connector.ErrConfirmationRequired)
+			}
+		}
+	}
+
+	steps := make([]connector.WriteStep, len(req.Steps))
+	result := &connector.TransactionResult{Steps: make([]connector.StepResult, len(req.Steps)), DryRun: req.DryRun}
+	for i, step := range req.Steps {
+		prepared, op, table, err := e.prepareStep(ctx, step)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		steps[i] = prepared
+		result.Steps[i] = connector.StepResult{Op: op, Table: table}
+		audit.AddTables(ctx, table)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+	defer cancel()
+
+	tx, err := e.connector.Begin(queryCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	txCtx := connector.WithTx(queryCtx, tx)
+
+	for i, step := range steps {
+		var res *connector.MutationResult
+		switch {
+		case step.Insert != nil:
+			ins := *step.Insert
+			ins.ContinueOnError = false
+			res, err = e.insertRows(txCtx, ins)
+		case step.Update != nil:
+			res, err = e.connector.Update(txCtx, *step.Update)
+		default:
+			res, err = e.connector.Delete(txCtx, *step.Delete)
+		}
+		if err != nil {
+			s := result.Steps[i]
+			return nil, fmt.Errorf("step %d (%s %s) failed, transaction rolled back: %w", i+1, s.Op, s.Table, err)
+		}
+		result.Steps[i].RowsAffected = res.RowsAffected
+		if threshold := e.validator.ConfirmThreshold(); !req.DryRun && step.Insert == nil && threshold > 0 && res.RowsAffected >= threshold {
+			s := result.Steps[i]
+			return nil, fmt.Errorf("%w: step %d (%s %s) affects %d rows, at or above the confirmation threshold of %d; transaction rolled back",
+				connector.ErrConfirmationRequired, i+1, s.Op, s.Table, res.RowsAffected, threshold)
+		}
+	}
+
+	if req.DryRun {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("failed to roll back dry run: %w", err)
+		}
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// prepareStep validates and authorizes one step of a transaction,
+// returning it ready to run along with its operation and table names.
+func (e *Engine) prepareStep(ctx context.Context, step connector.WriteStep) (connector.WriteStep, string, string, error) {
+	set := 0
+	for _, isSet := range []bool{step.Insert != nil, step.Update != nil, step.Delete != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return step, "", "", &ValidationError{Field: "op", Message: "each step must be exactly one of insert, update, or delete"}
+	}
+
+	switch {
+	case step.Insert != nil:
+		if err := e.checkInsert(ctx, *step.Insert); err != nil {
+			return step, "", "", err
+		}
+		return step, "insert", step.Insert.Table, nil
+	case step.Update != nil:
+		req, err := e.prepareUpdate(ctx, *step.Update)
+		if err != nil {
+			return step, "", "", err
+		}
+		return connector.WriteStep{Update: &req}, "update", req.Table, nil
+	default:
+		req, err := e.prepareDelete(ctx, *step.Delete)
+		if err != nil {
+			return step, "", "", err
+		}
+		return connector.WriteStep{Delete: &req}, "delete", req.Table, nil
+	}
+}
@@ -0,0 +1,194 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/schema"
+)
+
+// Upsert executes a validated insert-or-update of rows. req.ConflictColumns
+// must name the columns of the table's primary key or of one of its unique
+// keys, in any order; if empty, they default to the primary key. Every row
+// must set them, and all rows must set the same columns.
+//
+// The caller needs both INSERT and UPDATE access to the table. Roles whose
+// updates are restricted by a row filter cannot upsert, since the row a new
+// one conflicts with may be outside the filter. Like inserts, upserts name
+// the rows they write, so they are never confirmed. With req.Returning, the
+// written rows are reported as for Insert.
+func (e *Engine) Upsert(ctx context.Context, req connector.UpsertRequest) (*connector.MutationResult, error) {
+	prepared, err := e.prepareUpsert(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var policy *rolePolicy
+	prepared.Returning, policy = e.returning(ctx, req.Table, req.Returning)
+
+	queryCtx, cancel := e.readContext(ctx)
+	defer cancel()
+
+	res, err := e.connector.Upsert(queryCtx, prepared)
+	if err != nil {
+		return nil, err
+	}
+	e.maskReturning(ctx, req.Table, policy, res)
+	return res, nil
+}
+
+// prepareUpsert validates an upsert and authorizes it for the caller,
+// returning the request with its conflict columns and the columns of its
+// rows spelled as in the table's schema.
+func (e *Engine) prepareUpsert(ctx context.Context, req connector.UpsertRequest) (connector.UpsertRequest, error) {
+	if err := e.validator.ValidateWrite(req.Table); err != nil {
+		return req, err
+	}
+	if len(req.Rows) == 0 {
+		return req, &ValidationError{Field: "rows", Message: "at least one row is required"}
+	}
+	insert, err := e.authorize(ctx, req.Table, access.VerbInsert)
+	if err != nil {
+		return req, err
+	}
+	update, err := e.authorize(ctx, req.Table, access.VerbUpdate)
+	if err != nil {
+		return req, err
+	}
+	if update != nil && update.rowFilter != nil {
+		return req, fmt.Errorf("role %q may only update rows of %q matching its row filter, so it cannot upsert into it", update.role, req.Table)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, e.validator.QueryTimeout())
+	td, err := e.cache.DescribeTable(queryCtx, req.Table)
+	cancel()
+	if err != nil {
+		return req, err
+	}
+	key, err := conflictKey(td, req.ConflictColumns)
+	if err != nil {
+		return req, err
+	}
+
+	names := make(map[string]string, len(td.Columns))
+	for _, col := range td.Columns {
+		names[strings.ToLower(col.Name)] = col.Name
+	}
+	rows := make([]map[string]any, len(req.Rows))
+	seen := make(map[string]int, len(req.Rows))
+	for i, row := range req.Rows {
+		rows[i] = make(map[string]any, len(row))
+		for col, val := range row {
+			if err := insert.checkColumns(col); err != nil {
+				return req, err
+			}
+			if err := update.checkColumns(col); err != nil {
+				return req, err
+			}
+			if name, ok := names[strings.ToLower(col)]; ok {
+				col = name
+			}
+			rows[i][col] = val
+		}
+		if i > 0 && !sameColumns(rows[0], rows[i]) {
+			return req, &ValidationError{
+				Field:   "rows",
+				Message: fmt.Sprintf("row %d sets different columns than row 1; every row of an upsert must set the same columns", i+1),
+			}
+		}
+
+		values := make([]any, len(key))
+		for j, col := range key {
+			v, ok := rows[i][col]
+			if !ok {
+				return req, &ValidationError{Field: "rows", Message: fmt.Sprintf("row %d does not set conflict column %q", i+1, col)}
+			}
+			values[j] = v
+		}
+		// A statement may not both insert and update the same key.
+		id := fmt.Sprintf("%v", values)
+		if first, dup := seen[id]; dup {
+			return req, &ValidationError{Field: "rows", Message: fmt.Sprintf("rows %d and %d have the same %s", first+1, i+1, strings.Join(key, ", "))}
+		}
+		seen[id] = i
+	}
+
+	req.Rows = rows
+	req.ConflictColumns = key
+	return req, nil
+}
+
+// conflictKey returns the key of td whose columns are cols, in any order and
+// case: its primary key or one of its unique keys. Empty cols name the
+// primary key.
+func conflictKey(td *schema.TableDetail, cols []string) ([]string, error) {
+	if len(cols) == 0 {
+		if len(td.PrimaryKey) == 0 {
+			return nil, &ValidationError{
+				Field:   "conflict_columns",
+				Message: fmt.Sprintf("table %q has no primary key; name the columns of one of its unique keys", td.Name),
+			}
+		}
+		return td.PrimaryKey, nil
+	}
+
+	var keys [][]string
+	if len(td.PrimaryKey) > 0 {
+		keys = append(keys, td.PrimaryKey)
+	}
+	for _, uk := range td.UniqueKeys {
+		keys = append(keys, uk.Columns)
+	}
+	var names []string
+	for _, key := range keys {
+		if sameColumnSet(key, cols) {
+			return key, nil
+		}
+		names = append(names, "("+strings.Join(key, ", ")+")")
+	}
+	if len(names) == 0 {
+		return nil, &ValidationError{
+			Field:   "conflict_columns",
+			Message: fmt.Sprintf("table %q has no primary or unique key to upsert on", td.Name),
+		}
+	}
+	return nil, &ValidationError{
+		Field: "conflict_columns",
+		Message: fmt.Sprintf("(%s) is not the primary key or a unique key of %q; use one of %s",
+			strings.Join(cols, ", "), td.Name, strings.Join(names, ", ")),
+	}
+}
+
+// sameColumnSet reports whether a and b hold the same column names, in any
+// order, compared case-insensitively.
+func sameColumnSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, col := range a {
+		set[strings.ToLower(col)] = true
+	}
+	for _, col := range b {
+		if !set[strings.ToLower(col)] {
+			return false
+		}
+		delete(set, strings.ToLower(col))
+	}
+	return true
+}
+
+// sameColumns reports whether rows a and b set the same columns.
+func sameColumns(a, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for col := range a {
+		if _, ok := b[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package query
 import (
 	"fmt"
 	"time"
+
+	"github.com/conduitdb/conduit/internal/connector"
 )
 
 // Limits defines the complexity constraints applied to queries.
@@ -10,9 +12,14 @@ type Limits struct {
 	// MaxRows caps the number of rows a single query can return. Default: 1000.
 	MaxRows int
 
-	// MaxResultSizeBytes caps the approximate result payload size. Default: 10MB.
+	// MaxResultSizeBytes caps the approximate result payload size; rows
+	// past it are left out. Default: 10MB.
 	MaxResultSizeBytes int64
 
+	// MaxCellBytes caps each string or binary value in a result; longer
+	// values are cut short. Default: 64KB.
+	MaxCellBytes int
+
 	// QueryTimeout is the maximum time a single query may execute. Default: 30s.
 	QueryTimeout time.Duration
 
@@ -21,6 +28,20 @@ type Limits struct {
 
 	// AllowWrites enables INSERT/UPDATE/DELETE operations. Default: false.
 	AllowWrites bool
+
+	// ConfirmWrites requires every UPDATE and DELETE to be previewed and
+	// then confirmed with the preview's token. Default: false.
+	ConfirmWrites bool
+
+	// ConfirmThreshold requires confirmation of any UPDATE or DELETE
+	// affecting at least this many rows, even without ConfirmWrites.
+	// Default: 0 (no threshold).
+	ConfirmThreshold int64
+
+	// InsertBatchSize caps the rows inserted by each statement of a bulk
+	// INSERT, which otherwise takes as many as the database allows for the
+	// columns the rows set. Default: 0 (no cap).
+	InsertBatchSize int
 }
 
 // DefaultLimits returns conservative query limits suitable for production.
@@ -28,6 +49,7 @@ func DefaultLimits() Limits {
 	return Limits{
 		MaxRows:            1000,
 		MaxResultSizeBytes: 10 * 1024 * 1024, // 10 MB
+		MaxCellBytes:       64 * 1024,        // 64 KB
 		QueryTimeout:       30 * time.Second,
 		MaxFilterDepth:     10,
 		AllowWrites:        false,
@@ -57,6 +79,9 @@ func NewValidator(limits Limits) *Validator {
 	if limits.MaxResultSizeBytes <= 0 {
 		limits.MaxResultSizeBytes = 10 * 1024 * 1024
 	}
+	if limits.MaxCellBytes <= 0 {
+		limits.MaxCellBytes = 64 * 1024
+	}
 	if limits.QueryTimeout <= 0 {
 		limits.QueryTimeout = 30 * time.Second
 	}
@@ -134,23 +159,29 @@ func (v *Validator) AllowWrites() bool {
 	return v.limits.AllowWrites
 }
 
-// ValidateResultSize checks whether a result set exceeds the size limit.
-// The size is approximate — it uses the number of rows and a rough per-row
-// estimate.
-func (v *Validator) ValidateResultSize(rowCount int, estimatedRowBytes int) error {
-	if estimatedRowBytes <= 0 {
-		estimatedRowBytes = 512 // conservative default
-	}
-	totalBytes := int64(rowCount) * int64(estimatedRowBytes)
-	if totalBytes > v.limits.MaxResultSizeBytes {
-		return &ValidationError{
-			Field: "result_size",
-			Message: fmt.Sprintf("estimated result size %dMB exceeds limit of %dMB",
-				totalBytes/(1024*1024),
-				v.limits.MaxResultSizeBytes/(1024*1024)),
-		}
+// ConfirmWrites returns whether every UPDATE and DELETE needs confirmation.
+func (v *Validator) ConfirmWrites() bool {
+	return v.limits.ConfirmWrites
+}
+
+// ConfirmThreshold returns the row count at which an UPDATE or DELETE needs
+// confirmation, or 0 if there is none.
+func (v *Validator) ConfirmThreshold() int64 {
+	return v.limits.ConfirmThreshold
+}
+
+// InsertBatchSize returns the most rows each statement of a bulk INSERT may
+// take, or 0 if the database's limits are the only cap.
+func (v *Validator) InsertBatchSize() int {
+	return v.limits.InsertBatchSize
+}
+
+// ScanLimits returns the limits connectors scan query results within.
+func (v *Validator) ScanLimits() connector.ScanLimits {
+	return connector.ScanLimits{
+		MaxBytes:     v.limits.MaxResultSizeBytes,
+		MaxCellBytes: v.limits.MaxCellBytes,
 	}
-	return nil
 }
 
 // ValidateColumns checks that requested columns are valid identifiers.
@@ -15,6 +15,7 @@ type TableDetail struct {
 	PrimaryKey  []string     `json:"pk,omitempty"`
 	ForeignKeys []FKInfo     `json:"fks,omitempty"`
 	Indexes     []string     `json:"indexes,omitempty"`
+	UniqueKeys  []UniqueKey  `json:"unique,omitempty"`
 	RowCount    int64        `json:"rows"`
 	Description string       `json:"description,omitempty"`
 }
@@ -29,6 +30,25 @@ type ColumnInfo struct {
 	Default  string `json:"default,omitempty"`
 }
 
+// UniqueKey is a unique index or constraint other than the primary key:
+// no two rows share values for all of its columns.
+type UniqueKey struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
+
+// AppendUniqueColumn adds col to the unique key name, which is the last of
+// keys if introspection has already seen it, and returns keys. It lets
+// introspection build the keys from (key, column) rows ordered by key and
+// column position.
+func AppendUniqueColumn(keys []UniqueKey, name, col string) []UniqueKey {
+	if n := len(keys); n > 0 && keys[n-1].Name == name {
+		keys[n-1].Columns = append(keys[n-1].Columns, col)
+		return keys
+	}
+	return append(keys, UniqueKey{Name: name, Columns: []string{col}})
+}
+
 // FKInfo represents a foreign key relationship.
 type FKInfo struct {
 	Column    string `json:"col"`
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/mcpgen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// identity resolves the caller of a request from the verified bearer token,
+// if any. Callers without credentials, and keys without a role, get the
+// configured default role.
+func (s *Server) identity(req mcp.Request) *access.Identity {
+	if extra := req.GetExtra(); extra != nil && extra.TokenInfo != nil {
+		if id, ok := extra.TokenInfo.Extra[identityExtraKey].(*access.Identity); ok {
+			if id.Role == "" {
+				withRole := *id
+				withRole.Role = s.config.DefaultRole
+				return &withRole
+			}
+			return id
+		}
+	}
+	return &access.Identity{Role: s.config.DefaultRole}
+}
+
+// accessMiddleware attaches the caller identity to every request context, so
+// the query engine evaluates it under the caller's role, and hides tools the
+// role cannot use from tools/list and tools/call. With RequireRole, requests
+// of authenticated callers without a role are refused.
+func (s *Server) accessMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		id := s.identity(req)
+		if s.config.RequireRole && id.Role == "" && id.User != "" {
+			return nil, fmt.Errorf("user %q has no role; roles are configured, so every key or token needs one unless auth.default_role is set", id.User)
+		}
+		ctx = access.WithIdentity(ctx, id)
+		if s.config.Access == nil || id.Role == "" {
+			return next(ctx, method, req)
+		}
+
+		switch method {
+		case "tools/list":
+			res, err := next(ctx, method, req)
+			if list, ok := res.(*mcp.ListToolsResult); ok && err == nil {
+				visible := list.Tools[:0]
+				for _, tool := range list.Tools {
+					if s.toolVisible(id.Role, tool.Name) {
+						visible = append(visible, tool)
+					}
+				}
+				list.Tools = visible
+			}
+			return res, err
+		case "tools/call":
+			if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && !s.toolVisible(id.Role, params.Name) {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("tool %q is not available to role %q", params.Name, id.Role))
+				return result, nil
+			}
+		}
+		return next(ctx, method, req)
+	}
+}
+
+// toolVisible reports whether a role may see and call a tool. Tier 2 tools
+// need their verbs on their table; execute_sql and transaction need some
+// write access.
+// Other core tools are visible to every role and enforce access per call.
+func (s *Server) toolVisible(role, name string) bool {
+	s.mu.RLock()
+	def, ok := s.toolScopes[name]
+	s.mu.RUnlock()
+	if ok {
+		for _, verb := range append([]access.Verb{def.Verb}, def.MoreVerbs...) {
+			if s.config.Access.CheckAccess(role, def.Table, verb) != nil {
+				return false
+			}
+		}
+		return true
+	}
+	if name == "execute_sql" || name == "transaction" {
+		return s.config.Access.CanWrite(role)
+	}
+	return true
+}
+
+// addDynamicTool registers a Tier 2 tool of the named source and records its
+// table scope.
+func (s *Server) addDynamicTool(source string, def mcpgen.ToolDef) {
+	s.mu.Lock()
+	s.toolScopes[def.Tool.Name] = toolScope{ToolDef: def, source: source}
+	s.mu.Unlock()
+	s.mcpServer.AddTool(def.Tool, def.Handler)
+}
+
+// removeDynamicTools unregisters Tier 2 tools.
+func (s *Server) removeDynamicTools(names []string) {
+	s.mu.Lock()
+	for _, name := range names {
+		delete(s.toolScopes, name)
+	}
+	s.mu.Unlock()
+	s.mcpServer.RemoveTools(names...)
+}
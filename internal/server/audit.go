@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// clientIPHeader carries the HTTP client address from ClientIPMiddleware to
+// MCP request handlers, which only see request headers.
+const clientIPHeader = "X-Conduit-Client-Ip"
+
+// auditMiddleware records an audit event for every tools/call request,
+// covering core and dynamically registered tools alike.
+func (s *Server) auditMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		ctx, call := audit.WithCall(ctx)
+		start := time.Now()
+		res, err := next(ctx, method, req)
+
+		id := s.identity(req)
+		event := audit.Event{
+			Timestamp:    start,
+			User:         id.User,
+			Role:         id.Role,
+			Tool:         params.Name,
+			Params:       audit.SanitizeParams(params.Arguments),
+			RowsReturned: call.Rows(),
+			DurationMs:   time.Since(start).Milliseconds(),
+		}
+		if session := req.GetSession(); session != nil {
+			event.SessionID = session.ID()
+		}
+		if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+			event.SourceIP = extra.Header.Get(clientIPHeader)
+		}
+		event.Source, event.Table = s.toolTarget(params)
+		if event.Table == "" {
+			event.Table = strings.Join(call.Tables(), ",")
+		}
+		if err != nil {
+			event.Error = err.Error()
+		} else if result, ok := res.(*mcp.CallToolResult); ok && result.IsError {
+			event.Error = resultText(result)
+		}
+		s.config.Audit.Log(event)
+		return res, err
+	}
+}
+
+// toolTarget returns the source and table a tool call operates on: the scope
+// of a Tier 2 tool, or the "source" and "table" arguments of a core tool.
+func (s *Server) toolTarget(params *mcp.CallToolParamsRaw) (source, table string) {
+	s.mu.RLock()
+	scope, ok := s.toolScopes[params.Name]
+	s.mu.RUnlock()
+	if ok {
+		return scope.source, scope.Table
+	}
+	var args struct {
+		Source string `json:"source"`
+		Table  string `json:"table"`
+	}
+	_ = json.Unmarshal(params.Arguments, &args)
+	if args.Source == "" {
+		args.Source = s.sources[0].Name
+	}
+	return args.Source, args.Table
+}
+
+// resultText returns the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if text, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
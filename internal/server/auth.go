@@ -1,29 +1,73 @@
 package server
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"fmt"
-)
+	"net/http"
+	"time"
 
-const (
-	apiKeyPrefix    = "conduit_sk_live_"
-	apiKeyMinLength = 32
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/auth"
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
 )
 
-// GenerateAPIKey creates a new API key with the conduit prefix.
-func GenerateAPIKey() (string, error) {
-	bytes := make([]byte, 24)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+// identityExtraKey is the TokenInfo.Extra key under which verifiers store
+// the caller's access.Identity.
+const identityExtraKey = "conduit.identity"
+
+// apiKeyTokenTTL is the lifetime reported for a verified API key. Keys do not
+// expire, but TokenInfo requires an expiration; it is checked per request.
+const apiKeyTokenTTL = time.Hour
+
+// APIKeyVerifier returns a bearer token verifier that accepts the API keys in
+// keyring and resolves each to the key's identity.
+func APIKeyVerifier(keyring *auth.Keyring) sdkauth.TokenVerifier {
+	return func(ctx context.Context, token string, r *http.Request) (*sdkauth.TokenInfo, error) {
+		key, ok := keyring.Verify(token)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown API key", sdkauth.ErrInvalidToken)
+		}
+		return tokenInfo(key.Identity(), time.Now().Add(apiKeyTokenTTL)), nil
+	}
+}
+
+// OAuthVerifier returns a bearer token verifier that accepts JWT access
+// tokens validated by v, resolved to the identity their claims map to.
+func OAuthVerifier(v *auth.JWTVerifier) sdkauth.TokenVerifier {
+	return func(ctx context.Context, token string, r *http.Request) (*sdkauth.TokenInfo, error) {
+		tok, err := v.Verify(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", sdkauth.ErrInvalidToken, err)
+		}
+		info := tokenInfo(tok.Identity, tok.Expiry)
+		info.Scopes = tok.Scopes
+		return info, nil
+	}
+}
+
+// ProtectedResourceMetadata returns the OAuth protected resource metadata
+// (RFC 9728) that tells MCP clients which authorization server to use.
+func ProtectedResourceMetadata(cfg auth.OAuthConfig) *oauthex.ProtectedResourceMetadata {
+	servers := cfg.AuthorizationServers
+	if len(servers) == 0 {
+		servers = []string{cfg.Issuer}
+	}
+	return &oauthex.ProtectedResourceMetadata{
+		Resource:               cfg.Resource,
+		AuthorizationServers:   servers,
+		ScopesSupported:        cfg.Scopes,
+		BearerMethodsSupported: []string{"header"},
+		ResourceName:           "Conduit",
 	}
-	return apiKeyPrefix + hex.EncodeToString(bytes), nil
 }
 
-// ValidateAPIKeyFormat checks if an API key meets minimum requirements.
-func ValidateAPIKeyFormat(key string) error {
-	if len(key) < apiKeyMinLength {
-		return fmt.Errorf("API key must be at least %d characters (got %d)", apiKeyMinLength, len(key))
+// tokenInfo wraps an identity in the TokenInfo the MCP transport attaches to
+// every request of the session.
+func tokenInfo(id *access.Identity, expires time.Time) *sdkauth.TokenInfo {
+	return &sdkauth.TokenInfo{
+		UserID:     id.User,
+		Expiration: expires,
+		Extra:      map[string]any{identityExtraKey: id},
 	}
-	return nil
 }
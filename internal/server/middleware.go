@@ -2,8 +2,11 @@ package server
 
 import (
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
+
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
 )
 
 // LoggingMiddleware logs HTTP requests.
@@ -38,27 +41,31 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// BearerAuthMiddleware validates bearer tokens on API routes.
-func BearerAuthMiddleware(token string, next http.Handler) http.Handler {
-	if token == "" {
-		return next // No auth configured
-	}
+// ClientIPMiddleware passes the client address of each request on to MCP
+// handlers for audit logging. Any value the client sent in the same header is
+// replaced, so it cannot be spoofed.
+func ClientIPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health checks and static files
-		if r.URL.Path == "/healthz" || r.URL.Path == "/.well-known/mcp.json" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		auth := r.Header.Get("Authorization")
-		if auth != "Bearer "+token {
-			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
-			return
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
 		}
+		r.Header.Set(clientIPHeader, host)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// BearerAuthMiddleware rejects requests without a bearer token accepted by
+// verifier. The verified token's identity is available to MCP sessions
+// through the request's TokenInfo. opts may set the resource metadata URL
+// advertised in WWW-Authenticate.
+func BearerAuthMiddleware(verifier sdkauth.TokenVerifier, opts *sdkauth.RequireBearerTokenOptions, next http.Handler) http.Handler {
+	if verifier == nil {
+		return next // No auth configured
+	}
+	return sdkauth.RequireBearerToken(verifier, opts)(next)
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -6,8 +6,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 
-	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/format"
 	"github.com/conduitdb/conduit/internal/mcpgen"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -16,11 +19,27 @@ import (
 type ServerConfig struct {
 	Name         string
 	Version      string
-	AllowWrites  bool
 	AllowRawSQL  bool
-	MaskPII      bool
-	MaxRows      int
 	Instructions string
+
+	// OutputFormat is the default result format of the query tools.
+	OutputFormat format.Format
+
+	// Access enables role-based tool visibility. The source engines must be
+	// configured with the same access engine to enforce table, column, and
+	// row limits.
+	Access *access.Engine
+
+	// DefaultRole is the role of callers without credentials.
+	DefaultRole string
+
+	// RequireRole refuses authenticated callers that resolve to no role,
+	// who would otherwise not be role-restricted. Set it when roles are
+	// configured.
+	RequireRole bool
+
+	// Audit, if set, records an event for every tool call.
+	Audit *audit.Logger
 }
 
 // DefaultConfig returns a ServerConfig with sensible defaults.
@@ -28,22 +47,49 @@ func DefaultConfig() ServerConfig {
 	return ServerConfig{
 		Name:    "conduit",
 		Version: "0.1.0",
-		MaxRows: 1000,
 	}
 }
 
-// Server wraps an MCP server and its associated connector and tool generator.
+// Source is a named, opened database served by the MCP server together with
+// the settings that apply to it. All tool traffic for the source goes through
+// Engine.
+type Source struct {
+	Name        string
+	Engine      mcpgen.Engine
+	AllowWrites bool
+	MaskPII     bool
+	MaxRows     int
+}
+
+// Server wraps an MCP server and its associated sources and tool generators.
 type Server struct {
 	mcpServer *mcp.Server
 	config    ServerConfig
-	conn      connector.Connector
-	gen       *mcpgen.Generator
+	sources   []*sourceGen
 	logger    *slog.Logger
+
+	mu         sync.RWMutex
+	toolScopes map[string]toolScope // registered Tier 2 tools by name
+}
+
+// toolScope records the source and table a Tier 2 tool operates on.
+type toolScope struct {
+	mcpgen.ToolDef
+	source string
 }
 
-// New creates a new Conduit MCP server backed by the given connector.
-// The connector should already be opened and ready.
-func New(conn connector.Connector, cfg ServerConfig, logger *slog.Logger) *Server {
+// sourceGen pairs a source with the generator producing its tools.
+type sourceGen struct {
+	Source
+	gen *mcpgen.Generator
+}
+
+// New creates a new Conduit MCP server backed by the given sources. Each
+// source's engine should be wired to an opened connector. With a single source the
+// tool surface is unchanged; with several, core tools accept a "source"
+// argument (defaulting to the first source) and Tier 2 tools and resources
+// are prefixed with the source name.
+func New(sources []Source, cfg ServerConfig, logger *slog.Logger) *Server {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -60,26 +106,40 @@ func New(conn connector.Connector, cfg ServerConfig, logger *slog.Logger) *Serve
 
 	mcpSrv := mcp.NewServer(impl, opts)
 
-	gen := mcpgen.NewGenerator(conn, mcpgen.GeneratorConfig{
-		AllowWrites: cfg.AllowWrites,
-		AllowRawSQL: cfg.AllowRawSQL,
-		MaskPII:     cfg.MaskPII,
-		MaxRows:     cfg.MaxRows,
-	})
-
 	s := &Server{
-		mcpServer: mcpSrv,
-		config:    cfg,
-		conn:      conn,
-		gen:       gen,
-		logger:    logger,
+		mcpServer:  mcpSrv,
+		config:     cfg,
+		logger:     logger,
+		toolScopes: make(map[string]toolScope),
+	}
+	// Middleware added later runs first: audit sees every call, including
+	// those the access middleware rejects.
+	mcpSrv.AddReceivingMiddleware(s.accessMiddleware)
+	if cfg.Audit != nil {
+		mcpSrv.AddReceivingMiddleware(s.auditMiddleware)
 	}
 
-	// Wire up the tool registration callback so enable_table_tools can
-	// dynamically register Tier 2 tools on the MCP server.
-	gen.OnRegisterTool = func(tool *mcp.Tool, handler mcp.ToolHandler) {
-		mcpSrv.AddTool(tool, handler)
-		logger.Debug("registered dynamic tool via callback", "name", tool.Name)
+	for _, src := range sources {
+		genCfg := mcpgen.GeneratorConfig{
+			AllowWrites:  src.AllowWrites,
+			AllowRawSQL:  cfg.AllowRawSQL,
+			MaskPII:      src.MaskPII,
+			MaxRows:      src.MaxRows,
+			OutputFormat: cfg.OutputFormat,
+		}
+		if len(sources) > 1 {
+			genCfg.Source = src.Name
+		}
+		gen := mcpgen.NewGenerator(src.Engine, genCfg)
+
+		// Wire up the tool registration callback so enable_table_tools can
+		// dynamically register Tier 2 tools on the MCP server.
+		gen.OnRegisterTool = func(def mcpgen.ToolDef) {
+			s.addDynamicTool(src.Name, def)
+			logger.Debug("registered dynamic tool via callback", "name", def.Tool.Name)
+		}
+
+		s.sources = append(s.sources, &sourceGen{Source: src, gen: gen})
 	}
 
 	// Register Tier 1 core tools (always present).
@@ -102,18 +162,27 @@ func (s *Server) MCPServer() *mcp.Server {
 // Run runs the server over the given transport until it closes or the context
 // is cancelled. For stdio usage, pass &mcp.StdioTransport{}.
 func (s *Server) Run(ctx context.Context, transport mcp.Transport) error {
+	names := make([]string, len(s.sources))
+	for i, src := range s.sources {
+		names[i] = src.Name
+	}
 	s.logger.Info("conduit server starting",
 		"name", s.config.Name,
 		"version", s.config.Version,
-		"allow_writes", s.config.AllowWrites,
+		"sources", names,
 		"allow_raw_sql", s.config.AllowRawSQL,
 	)
 	return s.mcpServer.Run(ctx, transport)
 }
 
-// registerCoreTools registers all Tier 1 core tools on the MCP server.
+// registerCoreTools registers all Tier 1 core tools on the MCP server. With
+// several sources, see registerSourceRoutedTools.
 func (s *Server) registerCoreTools() {
-	tools := s.gen.CoreTools()
+	if len(s.sources) > 1 {
+		s.registerSourceRoutedTools()
+		return
+	}
+	tools := s.sources[0].gen.CoreTools()
 	for _, t := range tools {
 		s.mcpServer.AddTool(t.Tool, t.Handler)
 		s.logger.Debug("registered core tool", "name", t.Tool.Name)
@@ -123,23 +192,29 @@ func (s *Server) registerCoreTools() {
 
 // registerResources registers all resources and resource templates.
 func (s *Server) registerResources() {
-	resources := s.gen.Resources()
-	for _, r := range resources {
-		s.mcpServer.AddResource(r.Resource, r.Handler)
-		s.logger.Debug("registered resource", "uri", r.Resource.URI)
-	}
+	var resourceCount, templateCount int
+	for _, src := range s.sources {
+		resources := src.gen.Resources()
+		for _, r := range resources {
+			s.mcpServer.AddResource(r.Resource, r.Handler)
+			s.logger.Debug("registered resource", "uri", r.Resource.URI)
+		}
 
-	templates := s.gen.ResourceTemplates()
-	for _, t := range templates {
-		s.mcpServer.AddResourceTemplate(t.Template, t.Handler)
-		s.logger.Debug("registered resource template", "uri", t.Template.URITemplate)
+		templates := src.gen.ResourceTemplates()
+		for _, t := range templates {
+			s.mcpServer.AddResourceTemplate(t.Template, t.Handler)
+			s.logger.Debug("registered resource template", "uri", t.Template.URITemplate)
+		}
+		resourceCount += len(resources)
+		templateCount += len(templates)
 	}
-	s.logger.Info(fmt.Sprintf("registered %d resources and %d resource templates", len(resources), len(templates)))
+	s.logger.Info(fmt.Sprintf("registered %d resources and %d resource templates", resourceCount, templateCount))
 }
 
 // registerPrompts registers all built-in prompts.
 func (s *Server) registerPrompts() {
-	prompts := s.gen.Prompts()
+	// Prompts only reference tool names, so the default source's are enough.
+	prompts := s.sources[0].gen.Prompts()
 	for _, p := range prompts {
 		s.mcpServer.AddPrompt(p.Prompt, p.Handler)
 		s.logger.Debug("registered prompt", "name", p.Prompt.Name)
@@ -147,29 +222,40 @@ func (s *Server) registerPrompts() {
 	s.logger.Info(fmt.Sprintf("registered %d prompts", len(prompts)))
 }
 
-// EnableTableTools dynamically registers Tier 2 per-table tools and notifies
-// connected clients of the change. This is called by the enable_table_tools
-// core tool handler.
-func (s *Server) EnableTableTools(ctx context.Context, tables []string) error {
-	toolDefs, err := s.gen.DynamicToolsForTables(ctx, tables)
+// EnableTableTools dynamically registers Tier 2 per-table tools for the named
+// source (empty for the default source) and notifies connected clients of the
+// change. This is called by the enable_table_tools core tool handler.
+func (s *Server) EnableTableTools(ctx context.Context, source string, tables []string) error {
+	src, err := s.source(source)
+	if err != nil {
+		return err
+	}
+	toolDefs, err := src.gen.DynamicToolsForTables(ctx, tables)
 	if err != nil {
 		return err
 	}
 
 	for _, t := range toolDefs {
-		s.mcpServer.AddTool(t.Tool, t.Handler)
+		s.addDynamicTool(src.Name, t)
 		s.logger.Debug("registered dynamic tool", "name", t.Tool.Name)
 	}
-	s.logger.Info(fmt.Sprintf("enabled %d dynamic tools for %d tables", len(toolDefs), len(tables)))
+	s.logger.Info(fmt.Sprintf("enabled %d dynamic tools for %d tables", len(toolDefs), len(tables)),
+		"source", src.Name)
 
 	// tools/list_changed notification is automatically sent by the SDK
 	// when AddTool is called.
 	return nil
 }
 
-// DisableTableTools removes Tier 2 per-table tools.
-func (s *Server) DisableTableTools(tables []string) {
-	names := s.gen.DynamicToolNamesForTables(tables)
-	s.mcpServer.RemoveTools(names...)
-	s.logger.Info(fmt.Sprintf("disabled %d dynamic tools", len(names)))
+// DisableTableTools removes Tier 2 per-table tools for the named source
+// (empty for the default source).
+func (s *Server) DisableTableTools(source string, tables []string) error {
+	src, err := s.source(source)
+	if err != nil {
+		return err
+	}
+	names := src.gen.DynamicToolNamesForTables(tables)
+	s.removeDynamicTools(names)
+	s.logger.Info(fmt.Sprintf("disabled %d dynamic tools", len(names)), "source", src.Name)
+	return nil
 }
@@ -0,0 +1,1040 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitdb/conduit/internal/access"
+	"github.com/conduitdb/conduit/internal/audit"
+	"github.com/conduitdb/conduit/internal/auth"
+	"github.com/conduitdb/conduit/internal/connector"
+	"github.com/conduitdb/conduit/internal/connector/sqlite"
+	"github.com/conduitdb/conduit/internal/demo"
+	"github.com/conduitdb/conduit/internal/format"
+	"github.com/conduitdb/conduit/internal/query"
+	"github.com/conduitdb/conduit/internal/schema"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/jsonschema-go/jsonschema"
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// openDemoSource opens a demo SQLite database as a named source.
+func openDemoSource(t *testing.T, name string, allowWrites bool) Source {
+	t.Helper()
+	conn := openDemoConn(t, !allowWrites)
+	limits := query.Limits{MaxRows: 100, AllowWrites: allowWrites}
+	return Source{Name: name, Engine: newEngine(conn, limits, false), AllowWrites: allowWrites, MaxRows: 100}
+}
+
+// openDemoConn opens a connector on a fresh demo SQLite database.
+func openDemoConn(t *testing.T, readOnly bool) connector.Connector {
+	t.Helper()
+	ctx := context.Background()
+	dsn, cleanup, err := demo.CreateDemoDB(ctx)
+	if err != nil {
+		t.Fatalf("failed to create demo db: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	c := &sqlite.Connector{}
+	if err := c.Open(ctx, connector.ConnectionConfig{DSN: dsn, ReadOnly: readOnly}); err != nil {
+		t.Fatalf("failed to open connector: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// newEngine builds a query engine over conn with its own schema cache.
+func newEngine(conn connector.Connector, limits query.Limits, maskPII bool) *query.Engine {
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), testLogger)
+	return query.NewEngine(conn, cache, query.EngineConfig{Limits: limits, MaskPII: maskPII}, testLogger)
+}
+
+// connect starts srv on an in-memory transport and returns a client session.
+func connect(t *testing.T, srv *Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverT, clientT := mcp.NewInMemoryTransports()
+	if _, err := srv.MCPServer().Connect(ctx, serverT, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	cs, err := client.Connect(ctx, clientT, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+// callTool calls a tool and returns its text output and error flag.
+func callTool(t *testing.T, cs *mcp.ClientSession, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("call %s: %v", name, err)
+	}
+	var text strings.Builder
+	for _, c := range res.Content {
+		if tc, ok := c.(*mcp.TextContent); ok {
+			text.WriteString(tc.Text)
+		}
+	}
+	return text.String(), res.IsError
+}
+
+func toolNames(t *testing.T, cs *mcp.ClientSession) map[string]*mcp.Tool {
+	t.Helper()
+	res, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	tools := make(map[string]*mcp.Tool, len(res.Tools))
+	for _, tool := range res.Tools {
+		tools[tool.Name] = tool
+	}
+	return tools
+}
+
+func TestNew_SingleSourceKeepsToolNames(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	tools := toolNames(t, cs)
+	tool, ok := tools["list_tables"]
+	if !ok {
+		t.Fatal("list_tables not registered")
+	}
+	schema, _ := json.Marshal(tool.InputSchema)
+	if strings.Contains(string(schema), `"source"`) {
+		t.Errorf("single-source tools should not take a source argument: %s", schema)
+	}
+	if _, ok := tools["transaction"]; ok {
+		t.Error("transaction should not be registered without writes")
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"customers"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	if _, ok := toolNames(t, cs)["query_customers"]; !ok {
+		t.Error("expected unprefixed query_customers tool")
+	}
+}
+
+func TestNew_MultipleSources(t *testing.T) {
+	srv := New([]Source{
+		openDemoSource(t, "prod", false),
+		openDemoSource(t, "local", true),
+	}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	tools := toolNames(t, cs)
+	schema, _ := json.Marshal(tools["query"].InputSchema)
+	if !strings.Contains(string(schema), `"source"`) {
+		t.Fatalf("query tool should take a source argument: %s", schema)
+	}
+
+	out, isErr := callTool(t, cs, "list_tables", map[string]any{"source": "local"})
+	if isErr || !strings.Contains(out, "customers") {
+		t.Errorf("list_tables on local: isErr=%v out=%s", isErr, out)
+	}
+
+	out, isErr = callTool(t, cs, "list_tables", map[string]any{"source": "nope"})
+	if !isErr || !strings.Contains(out, `unknown source "nope"`) {
+		t.Errorf("expected unknown source error, got isErr=%v out=%s", isErr, out)
+	}
+
+	// Tier 2 tools are prefixed and keep each source's write setting.
+	for _, src := range []string{"prod", "local"} {
+		if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"source": src, "tables": []string{"products"}}); isErr {
+			t.Fatalf("enable_table_tools on %s failed", src)
+		}
+	}
+	tools = toolNames(t, cs)
+	for _, name := range []string{"prod_query_products", "local_query_products", "local_insert_products"} {
+		if _, ok := tools[name]; !ok {
+			t.Errorf("expected tool %q", name)
+		}
+	}
+	if _, ok := tools["prod_insert_products"]; ok {
+		t.Error("read-only source prod should not get insert tools")
+	}
+
+	out, isErr = callTool(t, cs, "local_insert_products", map[string]any{
+		"rows": []map[string]any{{"name": "Widget", "category": "Tools", "price": 9.99, "sku": "WID-001"}},
+	})
+	if isErr {
+		t.Errorf("insert on local failed: %s", out)
+	}
+	if !strings.Contains(out, `"returning":[{`) || !strings.Contains(out, `"sku":"WID-001"`) {
+		t.Errorf("insert should return the created row, got %s", out)
+	}
+}
+
+func TestDisableTableTools(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", true)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	names := []string{"query_products", "insert_products", "update_products", "delete_products", "upsert_products"}
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"products"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	tools := toolNames(t, cs)
+	for _, name := range names {
+		if _, ok := tools[name]; !ok {
+			t.Errorf("expected tool %q", name)
+		}
+	}
+
+	if err := srv.DisableTableTools("", []string{"products"}); err != nil {
+		t.Fatalf("DisableTableTools: %v", err)
+	}
+	tools = toolNames(t, cs)
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, name := range names {
+		if _, ok := tools[name]; ok {
+			t.Errorf("tool %q still registered after disabling its table", name)
+		}
+		if _, ok := srv.toolScopes[name]; ok {
+			t.Errorf("tool %q still scoped after disabling its table", name)
+		}
+	}
+}
+
+func TestPIIMaskingAppliesToTools(t *testing.T) {
+	conn := openDemoConn(t, true)
+	raw, err := conn.Select(context.Background(), connector.SelectRequest{Table: "customers", Limit: 1, OrderBy: "id"})
+	if err != nil || len(raw.Rows) == 0 {
+		t.Fatalf("direct select: %v", err)
+	}
+	email, _ := raw.Rows[0]["email"].(string)
+
+	srv := New([]Source{{
+		Name:    "default",
+		Engine:  newEngine(conn, query.Limits{MaxRows: 100}, true),
+		MaskPII: true,
+		MaxRows: 100,
+	}}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "query", map[string]any{"table": "customers", "order_by": "id", "limit": 1})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	if strings.Contains(out, email) {
+		t.Errorf("query returned unmasked email %q: %s", email, out)
+	}
+	if !strings.Contains(out, email[:1]+"***@") {
+		t.Errorf("expected masked email in output: %s", out)
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"customers"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	out, isErr = callTool(t, cs, "get_customers_by_id", map[string]any{"id": 1})
+	if isErr {
+		t.Fatalf("get_customers_by_id failed: %s", out)
+	}
+	if strings.Contains(out, email) {
+		t.Errorf("get_customers_by_id returned unmasked email: %s", out)
+	}
+}
+
+func TestAggregateTools(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "aggregate", map[string]any{
+		"table":      "orders",
+		"group_by":   []string{"status"},
+		"aggregates": []map[string]any{{"function": "count"}, {"function": "sum", "column": "total", "alias": "revenue"}},
+		"order_by":   "count DESC",
+		"limit":      1,
+	})
+	if isErr {
+		t.Fatalf("aggregate failed: %s", out)
+	}
+	var rs connector.ResultSet
+	if err := json.Unmarshal([]byte(out), &rs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(rs.Rows) != 1 || rs.Rows[0]["status"] != "delivered" || rs.Rows[0]["count"] != float64(5) {
+		t.Errorf("unexpected result: %s", out)
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"orders"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	out, isErr = callTool(t, cs, "aggregate_orders", map[string]any{
+		"aggregates": []map[string]any{{"function": "max", "column": "total"}},
+	})
+	if isErr || !strings.Contains(out, `"max_total":329.97`) {
+		t.Errorf("aggregate_orders: %s", out)
+	}
+	if out, isErr := callTool(t, cs, "aggregate_orders", map[string]any{"group_by": []string{"status"}, "having": "total > 1"}); !isErr {
+		t.Errorf("expected HAVING on a non-output column to fail: %s", out)
+	}
+}
+
+func TestQueryExpand(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "query", map[string]any{
+		"table":    "customers",
+		"columns":  []string{"first_name"},
+		"order_by": "id",
+		"limit":    1,
+		"expand":   []map[string]any{{"table": "orders", "columns": []string{"total"}}},
+	})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	if want := `"orders":[{"total":199.98},{"total":79.99}]`; !strings.Contains(out, want) {
+		t.Errorf("expected %s in %s", want, out)
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"orders"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	out, isErr = callTool(t, cs, "query_orders", map[string]any{
+		"filter": "id = 1",
+		"expand": []map[string]any{{"table": "customers", "as": "customer", "columns": []string{"city"}, "flatten": true}},
+	})
+	if isErr || !strings.Contains(out, `"customer.city":"San Francisco"`) {
+		t.Errorf("query_orders: %s", out)
+	}
+	if out, isErr := callTool(t, cs, "query_orders", map[string]any{"expand": []map[string]any{{"table": "reviews"}}}); !isErr {
+		t.Errorf("expected expanding an unrelated table to fail: %s", out)
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	var rows int
+	var cursor string
+	for page := 0; page < 5; page++ {
+		out, isErr := callTool(t, cs, "query", map[string]any{
+			"table": "customers", "columns": []string{"id"}, "limit": 3, "cursor": cursor, "count": true,
+		})
+		if isErr {
+			t.Fatalf("query failed: %s", out)
+		}
+		var rs connector.ResultSet
+		if err := json.Unmarshal([]byte(out), &rs); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if rs.Total != 8 {
+			t.Errorf("total = %d, want 8", rs.Total)
+		}
+		rows += len(rs.Rows)
+		if !rs.HasMore {
+			break
+		}
+		cursor = rs.NextCursor
+	}
+	if rows != 8 {
+		t.Errorf("paged through %d customers, want 8", rows)
+	}
+}
+
+func TestQueryFormats(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OutputFormat = format.CSV
+	srv := New([]Source{openDemoSource(t, "default", false)}, cfg, testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "query", map[string]any{
+		"table": "customers", "columns": []string{"id", "first_name"}, "order_by": "id", "limit": 2,
+	})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	if !strings.HasPrefix(out, "id,first_name\n1,Alice\n2,") || !strings.Contains(out, `"has_more":true`) {
+		t.Errorf("default format is not csv: %s", out)
+	}
+
+	out, isErr = callTool(t, cs, "query", map[string]any{
+		"table": "customers", "columns": []string{"id", "first_name"}, "order_by": "id", "limit": 1, "format": "arrays",
+	})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	if !strings.HasPrefix(out, `{"columns":["id","first_name"],"rows":[[1,"Alice"]]`) {
+		t.Errorf("arrays output: %s", out)
+	}
+
+	if out, isErr := callTool(t, cs, "query", map[string]any{"table": "customers", "format": "xml"}); !isErr || !strings.Contains(out, "unsupported format") {
+		t.Errorf("expected unsupported format error, got isErr=%v out=%s", isErr, out)
+	}
+}
+
+func TestStructuredOutputMatchesSchema(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", true)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"orders", "products"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	tools := toolNames(t, cs)
+
+	calls := []struct {
+		tool string
+		args map[string]any
+	}{
+		{"list_tables", nil},
+		{"describe_table", map[string]any{"table": "orders"}},
+		{"query", map[string]any{"table": "customers", "limit": 3}},
+		{"query_orders", map[string]any{"limit": 3, "format": "csv", "expand": []map[string]any{{"table": "customers"}}}},
+		{"aggregate_orders", map[string]any{"group_by": []string{"status"}}},
+		{"get_orders_by_id", map[string]any{"id": 1}},
+		{"insert_products", map[string]any{"rows": []map[string]any{{"name": "Widget", "category": "Tools", "price": 9.99, "sku": "WID-9"}}}},
+		{"upsert_products", map[string]any{"rows": []map[string]any{{"name": "Widget", "category": "Tools", "price": 12.5, "sku": "WID-9"}}, "conflict_columns": []string{"sku"}}},
+		{"insert_products", map[string]any{"continue_on_error": true, "rows": []map[string]any{
+			{"name": "Widget", "category": "Tools", "price": 9.99, "sku": "WID-9"},
+			{"name": "Gadget", "category": "Tools", "price": 4.5, "sku": "GAD-1"},
+		}}},
+		{"update_orders", map[string]any{"filter": "status = 'shipped'", "set": map[string]any{"status": "delivered"}, "preview": true}},
+		{"update_orders", map[string]any{"filter": "id = 1", "set": map[string]any{"status": "delivered"}, "returning": true}},
+		{"transaction", map[string]any{"dry_run": true, "steps": []map[string]any{
+			{"op": "update", "table": "orders", "set": map[string]any{"status": "cancelled"}, "filter": "status = 'pending'"},
+			{"op": "delete", "table": "order_items", "filter": "order_id = 1"},
+		}}},
+	}
+	for _, c := range calls {
+		t.Run(c.tool, func(t *testing.T) {
+			tool, ok := tools[c.tool]
+			if !ok || tool.OutputSchema == nil {
+				t.Fatalf("%s has no output schema", c.tool)
+			}
+			data, _ := json.Marshal(tool.OutputSchema)
+			var s jsonschema.Schema
+			if err := json.Unmarshal(data, &s); err != nil {
+				t.Fatalf("decode output schema: %v", err)
+			}
+			resolved, err := s.Resolve(nil)
+			if err != nil {
+				t.Fatalf("resolve output schema: %v", err)
+			}
+
+			res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: c.tool, Arguments: c.args})
+			if err != nil || res.IsError {
+				t.Fatalf("call failed: %v %+v", err, res)
+			}
+			if res.StructuredContent == nil {
+				t.Fatal("no structured content")
+			}
+			if err := resolved.Validate(res.StructuredContent); err != nil {
+				t.Errorf("structured content does not match the output schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryTableWhere(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"orders"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+
+	where := map[string]any{
+		"$or":         []any{map[string]any{"status": "shipped"}, map[string]any{"total": map[string]any{"$gte": 300}}},
+		"customer_id": map[string]any{"$in": []any{3, 5, 7}},
+	}
+	out, isErr := callTool(t, cs, "query_orders", map[string]any{
+		"columns": []string{"id"}, "where": where, "order_by": "id", "format": "csv",
+	})
+	if isErr {
+		t.Fatalf("query_orders failed: %s", out)
+	}
+	if out != "id\n3\n6\n8\n" {
+		t.Errorf("got %q, want orders 3, 6, and 8", out)
+	}
+
+	if out, isErr := callTool(t, cs, "query_orders", map[string]any{"where": where, "filter": "id = 1"}); !isErr || !strings.Contains(out, "not both") {
+		t.Errorf("expected where/filter conflict error, got isErr=%v out=%s", isErr, out)
+	}
+
+	// Values are bound, so SQL keywords and semicolons in them are data.
+	out, isErr = callTool(t, cs, "query_orders", map[string]any{
+		"columns": []string{"id"}, "where": map[string]any{"status": map[string]any{"$like": "sent from home; %"}}, "format": "csv",
+	})
+	if isErr {
+		t.Fatalf("query_orders with keywords in where values failed: %s", out)
+	}
+	if out != "id\n" {
+		t.Errorf("got %q, want no orders", out)
+	}
+
+	// The input schema types each column's conditions.
+	data, _ := json.Marshal(toolNames(t, cs)["query_orders"].InputSchema)
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("decode input schema: %v", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatalf("resolve input schema: %v", err)
+	}
+	if err := resolved.Validate(map[string]any{"where": where}); err != nil {
+		t.Errorf("valid where rejected: %v", err)
+	}
+	for _, bad := range []map[string]any{
+		{"total": map[string]any{"$like": "1%"}},
+		{"customer_id": "three"},
+		{"nope": 1},
+		{"$or": []any{map[string]any{"status": map[string]any{"$gt": true}}}},
+	} {
+		if err := resolved.Validate(map[string]any{"where": bad}); err == nil {
+			t.Errorf("invalid where %v accepted", bad)
+		}
+	}
+}
+
+func TestConfirmWrites(t *testing.T) {
+	engine := newEngine(openDemoConn(t, false), query.Limits{MaxRows: 100, AllowWrites: true, ConfirmWrites: true}, false)
+	srv := New([]Source{{Name: "default", Engine: engine, AllowWrites: true, MaxRows: 100}}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"orders", "order_items"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+
+	// Without elicitation, the first call returns a preview to confirm.
+	args := map[string]any{"filter": "order_id = 1"}
+	out, isErr := callTool(t, cs, "delete_order_items", args)
+	if isErr {
+		t.Fatalf("delete_order_items failed: %s", out)
+	}
+	var mr connector.MutationResult
+	if err := json.Unmarshal([]byte(out), &mr); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if mr.RowsAffected != 0 || mr.Preview == nil || mr.Preview.RowsAffected != 2 || len(mr.Preview.Sample) != 2 {
+		t.Fatalf("expected a preview of 2 rows, got %s", out)
+	}
+	args["confirm_token"] = mr.Preview.ConfirmToken
+	out, isErr = callTool(t, cs, "delete_order_items", args)
+	if isErr || !strings.HasPrefix(out, `{"rows_affected":2}`) {
+		t.Fatalf("confirmed delete: isErr=%v out=%s", isErr, out)
+	}
+
+	// With elicitation, the user confirms in the client.
+	for _, tt := range []struct {
+		action string
+		want   string
+	}{
+		{"accept", `{"rows_affected":1}`},
+		{"decline", "not confirmed by the user"},
+	} {
+		t.Run(tt.action, func(t *testing.T) {
+			var message string
+			client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, &mcp.ClientOptions{
+				ElicitationHandler: func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+					message = req.Params.Message
+					return &mcp.ElicitResult{Action: tt.action, Content: map[string]any{"confirm": true}}, nil
+				},
+			})
+			serverT, clientT := mcp.NewInMemoryTransports()
+			if _, err := srv.MCPServer().Connect(context.Background(), serverT, nil); err != nil {
+				t.Fatalf("server connect: %v", err)
+			}
+			ecs, err := client.Connect(context.Background(), clientT, nil)
+			if err != nil {
+				t.Fatalf("client connect: %v", err)
+			}
+			defer ecs.Close()
+
+			out, _ := callTool(t, ecs, "update_orders", map[string]any{"filter": "id = 3", "set": map[string]any{"status": "delivered"}})
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("got %s, want %s", out, tt.want)
+			}
+			if !strings.HasPrefix(message, "Confirm update of 1 rows in orders?") {
+				t.Errorf("unexpected confirmation message %q", message)
+			}
+		})
+	}
+}
+
+// slowConnector blocks every Select until its context is cancelled.
+type slowConnector struct {
+	connector.Connector
+}
+
+func (c slowConnector) Select(ctx context.Context, req connector.SelectRequest) (*connector.ResultSet, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestQueryTimeoutAppliesToTools(t *testing.T) {
+	conn := slowConnector{openDemoConn(t, true)}
+	limits := query.Limits{MaxRows: 100, QueryTimeout: 50 * time.Millisecond}
+	srv := New([]Source{{Name: "default", Engine: newEngine(conn, limits, false), MaxRows: 100}},
+		DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	start := time.Now()
+	out, isErr := callTool(t, cs, "query", map[string]any{"table": "customers"})
+	if !isErr || !strings.Contains(out, "deadline exceeded") {
+		t.Errorf("expected timeout error, got isErr=%v out=%s", isErr, out)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("query took %v; timeout was not applied", elapsed)
+	}
+}
+
+func TestRawSQLTools(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AllowRawSQL = true
+	engine := newEngine(openDemoConn(t, false), query.Limits{MaxRows: 5, AllowWrites: true}, false)
+	srv := New([]Source{{Name: "default", Engine: engine, AllowWrites: true, MaxRows: 5}}, cfg, testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "raw_sql", map[string]any{"sql": "SELECT id FROM orders ORDER BY id"})
+	if isErr {
+		t.Fatalf("raw_sql failed: %s", out)
+	}
+	var rs connector.ResultSet
+	if err := json.Unmarshal([]byte(out), &rs); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(rs.Rows) != 5 {
+		t.Errorf("expected the 5-row cap, got %d rows", len(rs.Rows))
+	}
+
+	for sql, want := range map[string]string{
+		"WITH x AS (DELETE FROM orders RETURNING *) SELECT * FROM x": "data-modifying CTE",
+		"SELECT 1; DELETE FROM orders":                               "multiple statements",
+		"DELETE FROM orders":                                         "DELETE statements are not allowed",
+	} {
+		if out, isErr := callTool(t, cs, "raw_sql", map[string]any{"sql": sql}); !isErr || !strings.Contains(out, want) {
+			t.Errorf("raw_sql %q: expected %q error, got isErr=%v out=%s", sql, want, isErr, out)
+		}
+	}
+	if out, isErr := callTool(t, cs, "execute_sql", map[string]any{"sql": "DELETE FROM reviews; DROP TABLE orders"}); !isErr || !strings.Contains(out, "multiple statements") {
+		t.Errorf("execute_sql should reject multiple statements: isErr=%v out=%s", isErr, out)
+	}
+
+	out, isErr = callTool(t, cs, "execute_sql", map[string]any{"sql": "UPDATE orders SET status = 'cancelled' WHERE id <= 2"})
+	if isErr {
+		t.Fatalf("execute_sql failed: %s", out)
+	}
+	var mr connector.MutationResult
+	if err := json.Unmarshal([]byte(out), &mr); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if mr.RowsAffected != 2 {
+		t.Errorf("expected 2 rows affected, got %d", mr.RowsAffected)
+	}
+}
+
+func TestFilterSanitizationAppliesToTools(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", false)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "query", map[string]any{
+		"table":  "customers",
+		"filter": "1=1 UNION SELECT * FROM products",
+	})
+	if !isErr || !strings.Contains(out, "SQL injection") {
+		t.Errorf("expected injection error, got isErr=%v out=%s", isErr, out)
+	}
+}
+
+func TestFiltersAreParameterized(t *testing.T) {
+	srv := New([]Source{openDemoSource(t, "default", true)}, DefaultConfig(), testLogger)
+	cs := connect(t, srv)
+
+	// A quote inside a value must be bound, not spliced into the SQL.
+	out, isErr := callTool(t, cs, "query", map[string]any{
+		"table":  "customers",
+		"filter": "email = 'x'' OR ''1''=''1'",
+	})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	var rs connector.ResultSet
+	if err := json.Unmarshal([]byte(out), &rs); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(rs.Rows) != 0 {
+		t.Errorf("expected no rows, got %d", len(rs.Rows))
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"customers"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	out, isErr = callTool(t, cs, "update_customers", map[string]any{
+		"filter": "id = 1 AND last_name != 'O''Brien'",
+		"set":    map[string]any{"first_name": "Renamed"},
+	})
+	if isErr || !strings.Contains(out, `"rows_affected":1`) {
+		t.Errorf("update with quoted value: isErr=%v out=%s", isErr, out)
+	}
+}
+
+func TestRoleRestrictsTools(t *testing.T) {
+	rbac := access.NewEngine([]access.Role{{
+		Name:            "analyst",
+		MaxRowsPerQuery: 2,
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT"}},
+			{Name: "customers", DenyColumns: []string{"phone"}, MaskColumns: []string{"email"}},
+			{Name: "reviews", Verbs: []string{}},
+		},
+	}})
+	conn := openDemoConn(t, false)
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), testLogger)
+	engine := query.NewEngine(conn, cache, query.EngineConfig{
+		Limits: query.Limits{MaxRows: 100, AllowWrites: true},
+		Access: rbac,
+	}, testLogger)
+
+	cfg := DefaultConfig()
+	cfg.Access = rbac
+	cfg.DefaultRole = "analyst"
+	srv := New([]Source{{Name: "default", Engine: engine, AllowWrites: true, MaxRows: 100}}, cfg, testLogger)
+	cs := connect(t, srv)
+
+	out, isErr := callTool(t, cs, "list_tables", nil)
+	if isErr || !strings.Contains(out, "customers") || strings.Contains(out, "reviews") {
+		t.Errorf("list_tables should hide reviews: isErr=%v out=%s", isErr, out)
+	}
+	if out, isErr := callTool(t, cs, "describe_table", map[string]any{"table": "reviews"}); !isErr {
+		t.Errorf("describe_table on a denied table should fail: %s", out)
+	}
+
+	out, isErr = callTool(t, cs, "query", map[string]any{"table": "customers", "order_by": "id", "limit": 10})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	var rs connector.ResultSet
+	if err := json.Unmarshal([]byte(out), &rs); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(rs.Rows) != 2 {
+		t.Errorf("expected role max of 2 rows, got %d", len(rs.Rows))
+	}
+	for _, row := range rs.Rows {
+		if _, ok := row["phone"]; ok {
+			t.Errorf("denied column phone returned: %v", row)
+		}
+		if email, _ := row["email"].(string); !strings.Contains(email, "***@") {
+			t.Errorf("email not masked: %v", row["email"])
+		}
+	}
+
+	out, isErr = callTool(t, cs, "query", map[string]any{"table": "customers", "filter": "phone IS NOT NULL"})
+	if !isErr || !strings.Contains(out, `column "phone" is not accessible`) {
+		t.Errorf("filtering on a denied column should fail: isErr=%v out=%s", isErr, out)
+	}
+
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"products"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	tools := toolNames(t, cs)
+	if _, ok := tools["query_products"]; !ok {
+		t.Error("expected query_products for a SELECT role")
+	}
+	for _, name := range []string{"insert_products", "upsert_products"} {
+		if _, ok := tools[name]; ok {
+			t.Errorf("%s should be hidden from a read-only role", name)
+		}
+	}
+	if _, ok := tools["transaction"]; ok {
+		t.Error("transaction should be hidden from a read-only role")
+	}
+	out, isErr = callTool(t, cs, "insert_products", map[string]any{
+		"rows": []map[string]any{{"name": "Widget", "category": "Tools", "price": 1, "sku": "WID-002"}},
+	})
+	if !isErr || !strings.Contains(out, "not available to role") {
+		t.Errorf("hidden tool should not be callable: isErr=%v out=%s", isErr, out)
+	}
+}
+
+// headerTransport adds a header to every request.
+type headerTransport struct {
+	key, value string
+}
+
+func (h headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(h.key, h.value)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	rbac := access.NewEngine([]access.Role{{
+		Name: "customer",
+		Tables: []access.TablePolicy{
+			{Name: "orders", Verbs: []string{"SELECT"}, RowFilter: "customer_id = {{user.customer}}"},
+		},
+	}})
+	conn := openDemoConn(t, true)
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), testLogger)
+	engine := query.NewEngine(conn, cache, query.EngineConfig{Limits: query.Limits{MaxRows: 100}, Access: rbac}, testLogger)
+	cfg := DefaultConfig()
+	cfg.Access = rbac
+	cfg.RequireRole = true
+	srv := New([]Source{{Name: "default", Engine: engine, MaxRows: 100}}, cfg, testLogger)
+
+	const apiKey = "conduit_sk_live_0123456789abcdef0123456789abcdef"
+	const noRoleKey = "conduit_sk_live_00000000000000000000000000000000"
+	keyring, err := auth.NewKeyring([]auth.Key{{
+		ID: "k1", Hash: auth.HashAPIKey(apiKey), User: "carol", Role: "customer",
+		Attributes: map[string]string{"customer": "3"},
+	}, {
+		ID: "k2", Hash: auth.HashAPIKey(noRoleKey), User: "mallory",
+	}})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	mcpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv.MCPServer() }, nil)
+	ts := httptest.NewServer(BearerAuthMiddleware(APIKeyVerifier(keyring), nil, mcpHandler))
+	defer ts.Close()
+
+	for _, header := range []string{"", "Bearer conduit_sk_live_ffffffffffffffffffffffffffffffff"} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{}`))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, resp.StatusCode)
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+
+	// A key without a role must not be left unrestricted.
+	if cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL,
+		HTTPClient: &http.Client{Transport: headerTransport{"Authorization", "Bearer " + noRoleKey}},
+	}, nil); err == nil {
+		cs.Close()
+		t.Error("expected a key without a role to be refused")
+	} else if !strings.Contains(err.Error(), `user "mallory" has no role`) {
+		t.Errorf("unexpected error for a key without a role: %v", err)
+	}
+
+	cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL,
+		HTTPClient: &http.Client{Transport: headerTransport{"Authorization", "Bearer " + apiKey}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer cs.Close()
+
+	out, isErr := callTool(t, cs, "list_tables", nil)
+	if isErr || !strings.Contains(out, "orders") || strings.Contains(out, "customers") {
+		t.Errorf("key role should only see orders: isErr=%v out=%s", isErr, out)
+	}
+	out, isErr = callTool(t, cs, "query", map[string]any{"table": "orders"})
+	if isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	var rs connector.ResultSet
+	if err := json.Unmarshal([]byte(out), &rs); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(rs.Rows) == 0 {
+		t.Fatal("expected the key's customer orders")
+	}
+	for _, row := range rs.Rows {
+		if row["customer_id"] != float64(3) {
+			t.Errorf("row outside the key's customer: %v", row)
+		}
+	}
+}
+
+func TestOAuthAuth(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "EC", "kid": "k1", "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y": base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}}})
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oauth := auth.OAuthConfig{
+		Resource:     "https://conduit.example.com/mcp",
+		Issuer:       "https://idp.example.com/",
+		JWKSFile:     jwksFile,
+		RoleMappings: []auth.RoleMapping{{Claim: "groups", Value: "sales", Role: "sales"}},
+	}
+	verifier, err := auth.NewJWTVerifier(oauth, nil)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+
+	rbac := access.NewEngine([]access.Role{{
+		Name:   "sales",
+		Tables: []access.TablePolicy{{Name: "orders", Verbs: []string{"SELECT"}}},
+	}})
+	conn := openDemoConn(t, true)
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), testLogger)
+	engine := query.NewEngine(conn, cache, query.EngineConfig{Limits: query.Limits{MaxRows: 100}, Access: rbac}, testLogger)
+	cfg := DefaultConfig()
+	cfg.Access = rbac
+	cfg.DefaultRole = "readonly"
+	srv := New([]Source{{Name: "default", Engine: engine, MaxRows: 100}}, cfg, testLogger)
+
+	const metadataURL = "https://conduit.example.com/.well-known/oauth-protected-resource/mcp"
+	mux := http.NewServeMux()
+	mcpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv.MCPServer() }, nil)
+	mux.Handle("/mcp", BearerAuthMiddleware(OAuthVerifier(verifier), &sdkauth.RequireBearerTokenOptions{ResourceMetadataURL: metadataURL}, mcpHandler))
+	mux.Handle("/.well-known/oauth-protected-resource/", sdkauth.ProtectedResourceMetadataHandler(ProtectedResourceMetadata(oauth)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || !strings.Contains(resp.Header.Get("WWW-Authenticate"), metadataURL) {
+		t.Errorf("expected 401 with resource metadata, got %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	resp, err = http.Get(ts.URL + "/.well-known/oauth-protected-resource/mcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]any
+	json.NewDecoder(resp.Body).Decode(&meta)
+	resp.Body.Close()
+	if meta["resource"] != oauth.Resource || fmt.Sprint(meta["authorization_servers"]) != "[https://idp.example.com/]" {
+		t.Errorf("unexpected metadata: %v", meta)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": oauth.Issuer, "aud": oauth.Resource, "sub": "dana",
+		"groups": []string{"sales"}, "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL + "/mcp",
+		HTTPClient: &http.Client{Transport: headerTransport{"Authorization", "Bearer " + signed}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer cs.Close()
+
+	out, isErr := callTool(t, cs, "list_tables", nil)
+	if isErr || !strings.Contains(out, "orders") || strings.Contains(out, "customers") {
+		t.Errorf("mapped role should only see orders: isErr=%v out=%s", isErr, out)
+	}
+}
+
+func TestAuditRecordsToolCalls(t *testing.T) {
+	rbac := access.NewEngine([]access.Role{{
+		Name: "analyst",
+		Tables: []access.TablePolicy{
+			{Name: "*", Verbs: []string{"SELECT"}},
+			{Name: "reviews", Verbs: []string{}},
+		},
+	}})
+	conn := openDemoConn(t, true)
+	cache := schema.NewCache(conn, schema.DefaultCacheConfig(), testLogger)
+	engine := query.NewEngine(conn, cache, query.EngineConfig{Limits: query.Limits{MaxRows: 100}, Access: rbac}, testLogger)
+	auditLog, err := audit.NewLogger(audit.Config{Enabled: true, Console: io.Discard}, testLogger)
+	if err != nil {
+		t.Fatalf("audit logger: %v", err)
+	}
+	defer auditLog.Close()
+	cfg := DefaultConfig()
+	cfg.Access = rbac
+	cfg.Audit = auditLog
+	srv := New([]Source{{Name: "default", Engine: engine, MaxRows: 100}}, cfg, testLogger)
+
+	const apiKey = "conduit_sk_live_0123456789abcdef0123456789abcdef"
+	keyring, err := auth.NewKeyring([]auth.Key{{ID: "k1", Hash: auth.HashAPIKey(apiKey), User: "dave", Role: "analyst"}})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	mcpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv.MCPServer() }, nil)
+	ts := httptest.NewServer(ClientIPMiddleware(BearerAuthMiddleware(APIKeyVerifier(keyring), nil, mcpHandler)))
+	defer ts.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "0"}, nil)
+	cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL,
+		HTTPClient: &http.Client{Transport: headerTransport{"Authorization", "Bearer " + apiKey}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer cs.Close()
+
+	if out, isErr := callTool(t, cs, "query", map[string]any{"table": "orders", "limit": 3}); isErr {
+		t.Fatalf("query failed: %s", out)
+	}
+	if _, isErr := callTool(t, cs, "enable_table_tools", map[string]any{"tables": []string{"customers"}}); isErr {
+		t.Fatal("enable_table_tools failed")
+	}
+	if out, isErr := callTool(t, cs, "query_customers", map[string]any{"limit": 2}); isErr {
+		t.Fatalf("query_customers failed: %s", out)
+	}
+	if _, isErr := callTool(t, cs, "query", map[string]any{"table": "reviews"}); !isErr {
+		t.Fatal("query on reviews should be denied")
+	}
+
+	events := auditLog.Recent(10)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 audit events, got %+v", events)
+	}
+	for _, e := range events {
+		if e.User != "dave" || e.Role != "analyst" || e.SessionID == "" || e.SourceIP != "127.0.0.1" || e.Source != "default" {
+			t.Errorf("incomplete audit event: %+v", e)
+		}
+	}
+	denied, dynamic, query := events[0], events[1], events[3]
+	if query.Tool != "query" || query.Table != "orders" || query.RowsReturned != 3 || query.Error != "" {
+		t.Errorf("unexpected query event: %+v", query)
+	}
+	if params, _ := query.Params.(map[string]any); params["table"] != "orders" {
+		t.Errorf("expected params to be recorded: %+v", query.Params)
+	}
+	if dynamic.Tool != "query_customers" || dynamic.Table != "customers" || dynamic.RowsReturned != 2 {
+		t.Errorf("unexpected dynamic tool event: %+v", dynamic)
+	}
+	if denied.Tool != "query" || denied.Table != "reviews" || denied.Error == "" || denied.RowsReturned != 0 {
+		t.Errorf("unexpected denied event: %+v", denied)
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// source returns the named source, or the default (first) source when name
+// is empty.
+func (s *Server) source(name string) (*sourceGen, error) {
+	if name == "" {
+		return s.sources[0], nil
+	}
+	for _, src := range s.sources {
+		if src.Name == name {
+			return src, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(s.sourceNames(), ", "))
+}
+
+// sourceNames returns the source names in configuration order.
+func (s *Server) sourceNames() []string {
+	names := make([]string, len(s.sources))
+	for i, src := range s.sources {
+		names[i] = src.Name
+	}
+	return names
+}
+
+// registerSourceRoutedTools registers one instance of each core tool that
+// accepts a "source" argument and dispatches to that source's generator.
+// A tool is registered if any source provides it (e.g. execute_sql when at
+// least one source allows writes); calling it against a source that does not
+// provide it returns an error.
+func (s *Server) registerSourceRoutedTools() {
+	type routed struct {
+		tool     *mcp.Tool
+		handlers map[string]mcp.ToolHandler
+	}
+	var order []string
+	byName := make(map[string]*routed)
+	for _, src := range s.sources {
+		for _, t := range src.gen.CoreTools() {
+			r, ok := byName[t.Tool.Name]
+			if !ok {
+				r = &routed{tool: t.Tool, handlers: make(map[string]mcp.ToolHandler)}
+				byName[t.Tool.Name] = r
+				order = append(order, t.Tool.Name)
+			}
+			r.handlers[src.Name] = t.Handler
+		}
+	}
+
+	for _, name := range order {
+		r := byName[name]
+		tool := *r.tool
+		tool.InputSchema = s.withSourceArg(r.tool.InputSchema)
+		s.mcpServer.AddTool(&tool, s.routeBySource(name, r.handlers))
+		s.logger.Debug("registered core tool", "name", name, "sources", len(r.handlers))
+	}
+	s.logger.Info(fmt.Sprintf("registered %d core tools for %d sources", len(order), len(s.sources)))
+}
+
+// withSourceArg adds the optional "source" property to a tool input schema.
+func (s *Server) withSourceArg(inputSchema any) json.RawMessage {
+	var schema map[string]any
+	data, _ := json.Marshal(inputSchema)
+	_ = json.Unmarshal(data, &schema)
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	if props == nil {
+		props = make(map[string]any)
+	}
+	names := s.sourceNames()
+	enum := make([]any, len(names))
+	for i, n := range names {
+		enum[i] = n
+	}
+	props["source"] = map[string]any{
+		"type":        "string",
+		"enum":        enum,
+		"description": fmt.Sprintf("Database source to use (default: %s)", names[0]),
+	}
+	schema["properties"] = props
+	out, _ := json.Marshal(schema)
+	return out
+}
+
+// routeBySource returns a handler that reads the "source" argument and
+// invokes the matching source's handler for the tool.
+func (s *Server) routeBySource(tool string, handlers map[string]mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args struct {
+			Source string `json:"source"`
+		}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				result := &mcp.CallToolResult{}
+				result.SetError(fmt.Errorf("invalid arguments: %w", err))
+				return result, nil
+			}
+		}
+
+		src, err := s.source(args.Source)
+		if err != nil {
+			result := &mcp.CallToolResult{}
+			result.SetError(err)
+			return result, nil
+		}
+		handler, ok := handlers[src.Name]
+		if !ok {
+			result := &mcp.CallToolResult{}
+			result.SetError(fmt.Errorf("tool %q is not available for source %q", tool, src.Name))
+			return result, nil
+		}
+		return handler(ctx, req)
+	}
+}